@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ardevd/flash/internal/agent"
+	"github.com/charmbracelet/log"
+)
+
+// Run the key agent in the foreground, or stop a running agent
+func runAgentCommand(args []string) {
+	fs := flag.NewFlagSet("agent", flag.ExitOnError)
+	ttl := fs.Duration("ttl", agent.DefaultTTL, "How long keys are cached")
+	fs.Parse(args)
+
+	socketPath := agent.SocketPath()
+
+	if fs.Arg(0) == "stop" {
+		if err := agent.Stop(socketPath); err != nil {
+			log.Fatal("Unable to stop agent:", err)
+		}
+		log.Info("Agent stopped")
+		return
+	}
+
+	l, err := agent.Listen(socketPath)
+	if err != nil {
+		log.Fatal("Unable to start agent:", err)
+	}
+
+	a := agent.New(*ttl)
+
+	// Forget the keys when interrupted
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		a.Stop()
+	}()
+
+	log.Info("Agent listening on " + socketPath + ", keys are cached for " + ttl.String())
+	if err := a.Serve(l); err != nil {
+		log.Fatal(err)
+	}
+}
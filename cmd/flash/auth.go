@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ardevd/flash/internal/credentials"
+	"github.com/ardevd/flash/internal/tui"
+	"github.com/charmbracelet/log"
+)
+
+// Flags for splitting a new encryption key into shares
+type splitFlags struct {
+	shares    *int
+	threshold *int
+	words     *bool
+}
+
+func addSplitFlags(fs *flag.FlagSet) splitFlags {
+	return splitFlags{
+		shares:    fs.Int("split", 0, "Split the new encryption key into this many shares"),
+		threshold: fs.Int("threshold", 0, "Number of shares needed to reconstruct a split encryption key"),
+		words:     fs.Bool("words", false, "Print key shares as words instead of hex"),
+	}
+}
+
+// Format a new encryption key for printing, split into shares if requested
+func (f splitFlags) formatKey(encKey string) (string, error) {
+	styles := tui.GetDefaultStyles()
+	if *f.shares == 0 {
+		return "Encryption key:" + styles.Keyword(encKey), nil
+	}
+
+	shares, err := credentials.SplitKey(encKey, *f.shares, *f.threshold)
+	if err != nil {
+		return "", err
+	}
+
+	s := fmt.Sprintf("The encryption key was split into %d shares, any %d of them reconstruct it:\n", *f.shares, *f.threshold)
+	for _, share := range shares {
+		encoded := share.Hex()
+		if *f.words {
+			encoded = share.Mnemonic()
+		}
+		s += fmt.Sprintf("\nShare %d: %s", share.Index, styles.Keyword(encoded))
+	}
+
+	return s, nil
+}
+
+// Flags shared by all commands operating on an auth file
+type vaultFlags struct {
+	authFile *string
+	keyFlags
+}
+
+func addVaultFlags(fs *flag.FlagSet) vaultFlags {
+	return vaultFlags{
+		authFile: fs.String("a", "auth.bin", "Authentication file"),
+		keyFlags: addKeyFlags(fs),
+	}
+}
+
+// Load the vault from the auth file
+func (f vaultFlags) load() (*credentials.Vault, credentials.Key, error) {
+	return f.keyFlags.load(*f.authFile)
+}
+
+// Flags restricting the macaroon before it is stored
+type restrictionFlags struct {
+	expiry *time.Duration
+	ip     *string
+	caveat *string
+}
+
+func addRestrictionFlags(fs *flag.FlagSet) restrictionFlags {
+	return restrictionFlags{
+		expiry: fs.Duration("expiry", 0, "Limit the lifetime of the stored macaroon, e.g. 720h"),
+		ip:     fs.String("ip", "", "Only allow the stored macaroon to be used from this IP address"),
+		caveat: fs.String("caveat", "", "Custom caveat \"name [condition]\" added to the stored macaroon. lnd rejects it unless an RPC middleware handles the caveat"),
+	}
+}
+
+func (f restrictionFlags) restrictions() credentials.MacaroonRestrictions {
+	return credentials.MacaroonRestrictions{
+		Timeout:      *f.expiry,
+		IPAddress:    *f.ip,
+		CustomCaveat: *f.caveat,
+	}
+}
+
+// Build a profile from an lndconnect URI, or from certificate and macaroon
+// files. A URI of "-" is read from stdin. The RPC host from the URI is only
+// replaced if rpcHost is set.
+func newProfile(name, certificatePath, macaroonPath, lndConnectURI, rpcHost string) (credentials.Profile, error) {
+	var profile credentials.Profile
+	var err error
+
+	switch {
+	case lndConnectURI != "":
+		if lndConnectURI == "-" {
+			lndConnectURI, err = bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && err != io.EOF {
+				return profile, err
+			}
+		}
+
+		profile, err = credentials.ParseLndConnectURI(lndConnectURI)
+		if err != nil {
+			return profile, err
+		}
+		profile.Name = name
+	case certificatePath != "" && macaroonPath != "":
+		profile, err = credentials.NewProfile(name, certificatePath, macaroonPath)
+		if err != nil {
+			return profile, err
+		}
+	default:
+		return profile, errors.New("lndconnect URI or TLS certificate and macaroon required")
+	}
+
+	if rpcHost != "" {
+		profile.RPCHost = rpcHost
+	}
+
+	return profile, nil
+}
+
+// Create the key for a new or rotated auth file. Unless a passphrase is used,
+// a new encryption key is generated.
+func newKey(usePassphrase bool) credentials.Key {
+	if usePassphrase {
+		passphrase, err := readNewPassphrase()
+		if err != nil {
+			log.Fatal("Unable to read passphrase:", err)
+		}
+		return credentials.Key{Passphrase: passphrase}
+	}
+
+	keyset, err := credentials.GenerateKey()
+	if err != nil {
+		log.Fatal("Unable to generate encryption key:", err)
+	}
+	return credentials.Key{Keyset: keyset}
+}
+
+// Write a new auth file containing the profile. Unless a passphrase is used,
+// a new encryption key is generated and printed, split into shares if requested.
+func createVault(profile credentials.Profile, outputPath string, usePassphrase bool, split splitFlags) {
+	if usePassphrase && *split.shares > 0 {
+		log.Fatal("Only generated encryption keys can be split into shares")
+	}
+
+	key := newKey(usePassphrase)
+
+	var keyText string
+	if !usePassphrase {
+		var err error
+		if keyText, err = split.formatKey(key.Keyset); err != nil {
+			log.Fatal("Unable to split encryption key:", err)
+		}
+	}
+
+	vault := &credentials.Vault{Profiles: []credentials.Profile{profile}}
+	if err := vault.Save(key, outputPath); err != nil {
+		log.Fatal("Unable to save authentication file:", err)
+	}
+
+	if usePassphrase {
+		log.Info("Encrypted credentials file '" + outputPath + "' saved.\n\n" +
+			outputPath + " can now be used with -p to connect to the node")
+		return
+	}
+
+	usage := outputPath + " with the encryption key can now be used to connect to the node"
+	if *split.shares > 0 {
+		usage = outputPath + " can now be used with -s or -share-files to connect to the node"
+	}
+
+	log.Info("Encrypted credentials file '" + outputPath + "' saved.\n" + keyText + "\n\n" + usage)
+}
+
+// Handle the auth subcommands
+func runAuthCommand(args []string) {
+	if len(args) == 0 {
+		printAuthUsage()
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "add":
+		authAdd(args[1:])
+	case "list":
+		authList(args[1:])
+	case "remove":
+		authRemove(args[1:])
+	case "rename":
+		authRename(args[1:])
+	case "rotate":
+		authRotate(args[1:])
+	case "inspect":
+		authInspect(args[1:])
+	default:
+		printAuthUsage()
+		os.Exit(2)
+	}
+}
+
+func printAuthUsage() {
+	fmt.Fprint(os.Stderr, `Usage: flash auth <command> [flags]
+
+Commands:
+  add      Add a node profile, creating the auth file if needed
+  list     List the node profiles
+  remove   Remove a node profile
+  rename   Rename a node profile
+  rotate   Re-encrypt the auth file under a new key
+  inspect  Show the certificates and macaroons of the node profiles
+`)
+}
+
+// Add a node profile to the auth file
+func authAdd(args []string) {
+	fs := flag.NewFlagSet("auth add", flag.ExitOnError)
+	vf := addVaultFlags(fs)
+	name := fs.String("name", "", "Profile name")
+	backend := fs.String("backend", credentials.BackendLND, "Node implementation, lnd or cln for Core Lightning")
+	tlsCertFile := fs.String("c", "", "TLS Certificate file, the CA certificate of cln-grpc for Core Lightning")
+	adminMacaroon := fs.String("m", "", "Admin Macaroon")
+	lndConnectURI := fs.String("u", "", "lndconnect URI, or - to read it from stdin")
+	clientCert := fs.String("client-cert", "", "Client certificate of cln-grpc")
+	clientKey := fs.String("client-key", "", "Client key of cln-grpc")
+	rpcServerAddress := fs.String("h", "", "RPC hostname:port")
+	network := fs.String("n", "", "Network the node runs on (mainnet, testnet, signet, regtest or simnet)")
+	label := fs.String("l", "", "Display label for the node")
+	proxy := fs.String("proxy", "", "SOCKS5 proxy host:port to reach the node through, such as Tor")
+	rf := addRestrictionFlags(fs)
+	sf := addSplitFlags(fs)
+	fs.Parse(args)
+
+	var profile credentials.Profile
+	var err error
+	switch *backend {
+	case credentials.BackendCLN:
+		if *tlsCertFile == "" || *clientCert == "" || *clientKey == "" {
+			log.Fatal("Core Lightning profiles require -c, -client-cert and -client-key")
+		}
+		if !rf.restrictions().Empty() {
+			log.Fatal("Macaroon restrictions don't apply to Core Lightning profiles")
+		}
+		profile, err = credentials.NewCLNProfile(*name, *tlsCertFile, *clientCert, *clientKey)
+		profile.RPCHost = *rpcServerAddress
+	case credentials.BackendLND:
+		profile, err = newProfile(*name, *tlsCertFile, *adminMacaroon, *lndConnectURI, *rpcServerAddress)
+	default:
+		log.Fatal("Unknown backend " + *backend)
+	}
+	if err != nil {
+		log.Fatal("Unable to read credentials:", err)
+	}
+	profile.Network = *network
+	profile.Label = *label
+	profile.Proxy = *proxy
+
+	if err := profile.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := profile.Attenuate(rf.restrictions()); err != nil {
+		log.Fatal("Unable to restrict macaroon:", err)
+	}
+
+	if _, err := os.Stat(*vf.authFile); errors.Is(err, os.ErrNotExist) {
+		createVault(profile, *vf.authFile, *vf.usePassphrase, sf)
+		return
+	}
+
+	vault, key, err := vf.load()
+	if err != nil {
+		log.Fatal(tui.ErrorMessage(err))
+	}
+
+	if err := vault.Add(profile); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := vault.Save(key, *vf.authFile); err != nil {
+		log.Fatal("Unable to save authentication file:", err)
+	}
+	log.Info("Profile '" + *name + "' added")
+}
+
+// List the node profiles in the auth file
+func authList(args []string) {
+	fs := flag.NewFlagSet("auth list", flag.ExitOnError)
+	vf := addVaultFlags(fs)
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	fs.Parse(args)
+
+	vault, _, err := vf.load()
+	if err != nil {
+		log.Fatal(tui.ErrorMessage(err))
+	}
+
+	if *asJSON {
+		type profileSummary struct {
+			Name    string `json:"name"`
+			Label   string `json:"label,omitempty"`
+			RPCHost string `json:"rpc_host"`
+			Network string `json:"network,omitempty"`
+		}
+
+		summaries := []profileSummary{}
+		for _, name := range vault.Names() {
+			profile, _ := vault.Get(name)
+			summaries = append(summaries, profileSummary{profile.Name, profile.Label, profile.RPCHost, profile.Network})
+		}
+		printJSON(summaries)
+		return
+	}
+
+	w := newTable()
+	fmt.Fprintln(w, "NAME\tLABEL\tHOST\tNETWORK")
+	for _, name := range vault.Names() {
+		profile, _ := vault.Get(name)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", profile.Name, profile.Label, profile.RPCHost, profile.Network)
+	}
+	w.Flush()
+}
+
+// Remove a node profile from the auth file
+func authRemove(args []string) {
+	fs := flag.NewFlagSet("auth remove", flag.ExitOnError)
+	vf := addVaultFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		log.Fatal("Usage: flash auth remove [flags] <name>")
+	}
+
+	vault, key, err := vf.load()
+	if err != nil {
+		log.Fatal(tui.ErrorMessage(err))
+	}
+
+	if err := vault.Remove(fs.Arg(0)); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := vault.Save(key, *vf.authFile); err != nil {
+		log.Fatal("Unable to save authentication file:", err)
+	}
+	log.Info("Profile '" + fs.Arg(0) + "' removed")
+}
+
+// Rename a node profile in the auth file
+func authRename(args []string) {
+	fs := flag.NewFlagSet("auth rename", flag.ExitOnError)
+	vf := addVaultFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		log.Fatal("Usage: flash auth rename [flags] <name> <new name>")
+	}
+
+	vault, key, err := vf.load()
+	if err != nil {
+		log.Fatal(tui.ErrorMessage(err))
+	}
+
+	if err := vault.Rename(fs.Arg(0), fs.Arg(1)); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := vault.Save(key, *vf.authFile); err != nil {
+		log.Fatal("Unable to save authentication file:", err)
+	}
+	log.Info("Profile '" + fs.Arg(0) + "' renamed to '" + fs.Arg(1) + "'")
+}
+
+// Re-encrypt the auth file under a new key
+func authRotate(args []string) {
+	fs := flag.NewFlagSet("auth rotate", flag.ExitOnError)
+	vf := addVaultFlags(fs)
+	newPassphrase := fs.Bool("new-passphrase", false, "Protect the auth file with a new passphrase instead of a generated key")
+	sf := addSplitFlags(fs)
+	fs.Parse(args)
+
+	if *newPassphrase && *sf.shares > 0 {
+		log.Fatal("Only generated encryption keys can be split into shares")
+	}
+
+	// Make sure the old key works before asking for a new passphrase
+	_, oldKey, err := vf.load()
+	if err != nil {
+		log.Fatal(tui.ErrorMessage(err))
+	}
+
+	key := newKey(*newPassphrase)
+
+	var keyText string
+	if !*newPassphrase {
+		if keyText, err = sf.formatKey(key.Keyset); err != nil {
+			log.Fatal("Unable to split encryption key:", err)
+		}
+	}
+
+	if err := credentials.RotateKey(*vf.authFile, oldKey, key); err != nil {
+		log.Fatal(tui.ErrorMessage(err))
+	}
+	if !*vf.noAgent {
+		cacheKey(*vf.authFile, key)
+	}
+
+	if *newPassphrase {
+		log.Info("Authentication file '" + *vf.authFile + "' is now protected by the new passphrase")
+		return
+	}
+
+	log.Info("Authentication file '" + *vf.authFile + "' re-encrypted.\n" +
+		keyText + "\n\nThe old encryption key can no longer be used")
+}
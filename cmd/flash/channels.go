@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/ardevd/flash/internal/tui"
+	"github.com/charmbracelet/log"
+)
+
+// What channels list reports for a single channel
+type channelSummary struct {
+	Alias         string `json:"alias"`
+	PubKey        string `json:"pubkey"`
+	ChannelID     uint64 `json:"channel_id"`
+	ChannelPoint  string `json:"channel_point"`
+	Capacity      int64  `json:"capacity_sat"`
+	LocalBalance  int64  `json:"local_balance_sat"`
+	RemoteBalance int64  `json:"remote_balance_sat"`
+	Active        bool   `json:"active"`
+	Private       bool   `json:"private"`
+	UptimePct     int    `json:"uptime_pct"`
+}
+
+func newChannelSummary(c lnd.Channel) channelSummary {
+	return channelSummary{
+		Alias:         c.Alias,
+		PubKey:        c.Info.PubKeyBytes.String(),
+		ChannelID:     c.Info.ChannelID,
+		ChannelPoint:  c.Info.ChannelPoint,
+		Capacity:      int64(c.Info.Capacity),
+		LocalBalance:  int64(c.Info.LocalBalance),
+		RemoteBalance: int64(c.Info.RemoteBalance),
+		Active:        c.Info.Active,
+		Private:       c.Info.Private,
+		UptimePct:     c.UptimePct(),
+	}
+}
+
+// Handle the channels subcommands
+func runChannelsCommand(args []string) {
+	if len(args) == 0 {
+		printChannelsUsage()
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "list":
+		channelsList(args[1:])
+	default:
+		printChannelsUsage()
+		os.Exit(2)
+	}
+}
+
+func printChannelsUsage() {
+	fmt.Fprint(os.Stderr, `Usage: flash channels <command> [flags]
+
+Commands:
+  list  List the channels of the node
+`)
+}
+
+// List the channels of the node
+func channelsList(args []string) {
+	fs := flag.NewFlagSet("channels list", flag.ExitOnError)
+	nf := addNodeFlags(fs)
+	offline := fs.Bool("offline", false, "Only list offline channels")
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	fs.Parse(args)
+
+	backend, _ := nf.mustConnect()
+	defer backend.Close()
+
+	channels, err := backend.GetChannels(context.Background())
+	if err != nil {
+		log.Fatal(tui.ErrorMessage(err))
+	}
+
+	summaries := []channelSummary{}
+	for _, c := range channels {
+		if !*offline || !c.Info.Active {
+			summaries = append(summaries, newChannelSummary(c))
+		}
+	}
+
+	if *asJSON {
+		printJSON(summaries)
+		return
+	}
+
+	w := newTable()
+	defer w.Flush()
+
+	fmt.Fprintln(w, "ALIAS\tCHANNEL ID\tCAPACITY\tLOCAL\tREMOTE\tACTIVE\tUPTIME")
+	for _, c := range summaries {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%t\t%d%%\n", c.Alias, c.ChannelID, c.Capacity,
+			c.LocalBalance, c.RemoteBalance, c.Active, c.UptimePct)
+	}
+}
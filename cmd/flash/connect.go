@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+
+	"github.com/ardevd/flash/internal/cln"
+	"github.com/ardevd/flash/internal/config"
+	"github.com/ardevd/flash/internal/credentials"
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/ardevd/flash/internal/tui"
+	"github.com/charmbracelet/log"
+	"github.com/lightninglabs/lndclient"
+)
+
+// Flags shared by all commands talking to a node
+type nodeFlags struct {
+	vaultFlags
+	profile    *string
+	rpcHost    *string
+	network    *string
+	proxy      *string
+	configPath *string
+}
+
+func addNodeFlags(fs *flag.FlagSet) nodeFlags {
+	return nodeFlags{
+		vaultFlags: addVaultFlags(fs),
+		profile:    fs.String("profile", "", "Name of the node profile to connect to"),
+		rpcHost:    fs.String("h", "", "RPC hostname:port, overrides the stored value"),
+		network:    fs.String("n", "", "Network the node runs on, overrides the stored value"),
+		proxy:      fs.String("proxy", "", "SOCKS5 proxy host:port such as Tor, overrides the stored value"),
+		configPath: addConfigFlag(fs),
+	}
+}
+
+func addConfigFlag(fs *flag.FlagSet) *string {
+	return fs.String("config", "", "Config file, defaults to $XDG_CONFIG_HOME/flash/config.yaml")
+}
+
+// Load the config file given with -config or the default one
+func (f nodeFlags) config() (*config.Config, error) {
+	if *f.configPath != "" {
+		return config.Load(*f.configPath)
+	}
+
+	return config.LoadDefault()
+}
+
+// Load the config file and the profile to connect to from the auth file,
+// along with the key of the auth file. Without a profile name the user is
+// only asked to pick one when interactive is set.
+func (f nodeFlags) prepare(interactive bool) (*credentials.Profile, config.Settings, credentials.Key, error) {
+	cfg, err := f.config()
+	if err != nil {
+		return nil, config.Settings{}, credentials.Key{}, err
+	}
+
+	vault, key, err := f.load()
+	if err != nil {
+		return nil, config.Settings{}, key, err
+	}
+
+	if *f.profile == "" && len(vault.Profiles) > 1 && !interactive {
+		return nil, config.Settings{}, key, errors.New("authentication file holds several profiles, select one with -profile")
+	}
+
+	profile, err := selectProfile(vault, *f.profile)
+	if err != nil {
+		return nil, config.Settings{}, key, err
+	}
+	settings := cfg.ForProfile(profile.Name)
+
+	// The proxy of the config file applies to profiles without one
+	if profile.Proxy == "" {
+		profile.Proxy = settings.Proxy
+	}
+
+	// Command line arguments override the stored connection details
+	if *f.rpcHost != "" {
+		profile.RPCHost = *f.rpcHost
+	}
+
+	if *f.network != "" {
+		profile.Network = *f.network
+	}
+
+	if *f.proxy != "" {
+		profile.Proxy = *f.proxy
+	}
+
+	return profile, settings, key, nil
+}
+
+// Pick the profile to connect to. The user is asked to choose when the
+// vault holds more than one profile and none was given on the command line.
+func selectProfile(vault *credentials.Vault, name string) (*credentials.Profile, error) {
+	if name != "" {
+		return vault.Get(name)
+	}
+
+	switch len(vault.Profiles) {
+	case 0:
+		return nil, errors.New("authentication file contains no profiles")
+	case 1:
+		return &vault.Profiles[0], nil
+	}
+
+	return tui.SelectProfile(vault.Profiles)
+}
+
+// Connect to the node of the profile. Nodes without a stored certificate use
+// one signed by a public CA. With a proxy, host names are resolved by the
+// proxy and calls get more time to account for Tor latency.
+func newClient(profile *credentials.Profile) (*lndclient.GrpcLndServices, error) {
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+
+	network := profile.Network
+	if network == "" {
+		network = string(lndclient.NetworkMainnet)
+	}
+
+	config := lndclient.LndServicesConfig{
+		LndAddress:        profile.RPCHost,
+		Network:           lndclient.Network(network),
+		CustomMacaroonHex: hex.EncodeToString(profile.Macaroon),
+		TLSData:           string(profile.Certificate),
+		SystemCert:        len(profile.Certificate) == 0,
+	}
+
+	dialer, err := proxyDialer(profile)
+	if err != nil {
+		return nil, err
+	}
+	if dialer != nil {
+		config.Dialer = dialer
+		config.RPCTimeout = lnd.ProxyRPCTimeout
+	}
+
+	return lndclient.NewLndServices(&config)
+}
+
+// Connect to the gRPC interface of the Core Lightning node of the profile
+func newCLNBackend(profile *credentials.Profile, aliases *lnd.AliasCache) (*cln.Backend, error) {
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+
+	config := cln.Config{
+		Address:    profile.RPCHost,
+		CACert:     profile.Certificate,
+		ClientCert: profile.ClientCert,
+		ClientKey:  profile.ClientKey,
+		Aliases:    aliases,
+	}
+
+	dialer, err := proxyDialer(profile)
+	if err != nil {
+		return nil, err
+	}
+	if dialer != nil {
+		config.Dialer = dialer
+		config.RPCTimeout = lnd.ProxyRPCTimeout
+	}
+
+	return cln.Connect(config)
+}
+
+// The dialer for the proxy of the profile, nil without a proxy
+func proxyDialer(profile *credentials.Profile) (lndclient.DialerFunc, error) {
+	if profile.Proxy != "" {
+		return lnd.ProxyDialer(profile.Proxy)
+	}
+
+	if lnd.IsOnion(profile.RPCHost) {
+		return nil, errors.New("onion address " + profile.RPCHost + " can only be reached through a proxy, set one with -proxy")
+	}
+
+	return nil, nil
+}
+
+// Connect to the node of the profile and return the backend for it. Aliases
+// are kept in the alias cache, a new one if it is nil.
+func newBackend(profile *credentials.Profile, aliases *lnd.AliasCache) (lnd.NodeBackend, error) {
+	if profile.IsCLN() {
+		backend, err := newCLNBackend(profile, aliases)
+		if err != nil {
+			return nil, err
+		}
+		return backend, nil
+	}
+
+	client, err := newClient(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	return lnd.NewLndBackend(client, profile.Macaroon, aliases), nil
+}
+
+// Connect to the node for a non-interactive command and load the settings of
+// its profile, exiting with a readable message if that fails
+func (f nodeFlags) mustConnect() (lnd.NodeBackend, config.Settings) {
+	profile, settings, _, err := f.prepare(false)
+	if err != nil {
+		log.Fatal(tui.ErrorMessage(err))
+	}
+
+	backend, err := newBackend(profile, nil)
+	if err != nil {
+		log.Fatal(tui.ErrorMessage(err))
+	}
+
+	return backend, settings
+}
+
+// Indicates whether the flag was given on the command line
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
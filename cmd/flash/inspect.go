@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ardevd/flash/internal/credentials"
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/ardevd/flash/internal/tui"
+	"github.com/charmbracelet/log"
+)
+
+// What inspect reports for a single profile
+type profileInspection struct {
+	Name           string                       `json:"name"`
+	RPCHost        string                       `json:"rpc_host"`
+	Network        string                       `json:"network,omitempty"`
+	Proxy          string                       `json:"proxy,omitempty"`
+	Backend        string                       `json:"backend,omitempty"`
+	Certificate    *credentials.CertificateInfo `json:"certificate,omitempty"`
+	ClientCert     *credentials.CertificateInfo `json:"client_certificate,omitempty"`
+	Macaroon       *credentials.MacaroonInfo    `json:"macaroon,omitempty"`
+	AllowedMethods []string                     `json:"allowed_methods,omitempty"`
+}
+
+// Show the certificates and macaroons of the node profiles in the auth file
+func authInspect(args []string) {
+	fs := flag.NewFlagSet("auth inspect", flag.ExitOnError)
+	vf := addVaultFlags(fs)
+	name := fs.String("name", "", "Only inspect this profile")
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	connect := fs.Bool("connect", false, "Ask the node which RPC methods the macaroon grants access to")
+	fs.Parse(args)
+
+	vault, _, err := vf.load()
+	if err != nil {
+		log.Fatal(tui.ErrorMessage(err))
+	}
+
+	names := vault.Names()
+	if *name != "" {
+		names = []string{*name}
+	}
+
+	var inspections []profileInspection
+	for _, n := range names {
+		profile, err := vault.Get(n)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		inspection, err := inspectProfile(profile, *connect)
+		if err != nil {
+			log.Fatal("Unable to inspect profile " + n + ": " + tui.ErrorMessage(err))
+		}
+		inspections = append(inspections, inspection)
+	}
+
+	if *asJSON {
+		printJSON(inspections)
+		return
+	}
+
+	for i, inspection := range inspections {
+		if i > 0 {
+			fmt.Println()
+		}
+		printInspection(inspection)
+	}
+}
+
+func inspectProfile(profile *credentials.Profile, connect bool) (profileInspection, error) {
+	inspection := profileInspection{
+		Name:    profile.Name,
+		RPCHost: profile.RPCHost,
+		Network: profile.Network,
+		Proxy:   profile.Proxy,
+		Backend: profile.Backend,
+	}
+
+	if len(profile.Certificate) > 0 {
+		certificate, err := credentials.DecodeCertificate(profile.Certificate)
+		if err != nil {
+			return inspection, err
+		}
+		inspection.Certificate = certificate
+	}
+
+	// Core Lightning profiles authenticate with a client certificate, which
+	// grants access to all methods
+	if profile.IsCLN() {
+		certificate, err := credentials.DecodeCertificate(profile.ClientCert)
+		if err != nil {
+			return inspection, err
+		}
+		inspection.ClientCert = certificate
+		return inspection, nil
+	}
+
+	macaroon, err := credentials.DecodeMacaroon(profile.Macaroon)
+	if err != nil {
+		return inspection, err
+	}
+	inspection.Macaroon = macaroon
+
+	if !connect {
+		return inspection, nil
+	}
+
+	client, err := newClient(profile)
+	if err != nil {
+		return inspection, err
+	}
+	defer client.Close()
+
+	methods, err := lnd.GetAllowedMethods(client, context.Background(), profile.Macaroon)
+	if err != nil {
+		return inspection, err
+	}
+	inspection.AllowedMethods = methods
+
+	return inspection, nil
+}
+
+func printInspection(inspection profileInspection) {
+	w := newTable()
+	defer w.Flush()
+
+	fmt.Fprintf(w, "Profile:\t%s\n", inspection.Name)
+	fmt.Fprintf(w, "Host:\t%s\n", inspection.RPCHost)
+	if inspection.Network != "" {
+		fmt.Fprintf(w, "Network:\t%s\n", inspection.Network)
+	}
+	if inspection.Proxy != "" {
+		fmt.Fprintf(w, "Proxy:\t%s\n", inspection.Proxy)
+	}
+	if inspection.Backend != "" {
+		fmt.Fprintf(w, "Backend:\t%s\n", inspection.Backend)
+	}
+
+	if cert := inspection.Certificate; cert != nil {
+		printCertificate(w, "Certificate", cert)
+	} else {
+		fmt.Fprintf(w, "Certificate:\t%s\n", "none, signed by a public CA")
+	}
+
+	if cert := inspection.ClientCert; cert != nil {
+		printCertificate(w, "Client certificate", cert)
+	}
+
+	mac := inspection.Macaroon
+	if mac == nil {
+		return
+	}
+	fmt.Fprintf(w, "Macaroon ID:\t%s\n", mac.ID)
+	if mac.RootKeyID != "" {
+		fmt.Fprintf(w, "Root key ID:\t%s\n", mac.RootKeyID)
+	}
+	fmt.Fprintf(w, "Caveats:\t%s\n", listOrNone(mac.Caveats))
+	fmt.Fprintf(w, "Permissions:\t%s\n", listOrNone(mac.Permissions))
+	if inspection.AllowedMethods != nil {
+		fmt.Fprintf(w, "Allowed methods:\t%s\n", listOrNone(inspection.AllowedMethods))
+	}
+}
+
+func printCertificate(w io.Writer, name string, cert *credentials.CertificateInfo) {
+	expiry := cert.NotAfter.Format(time.RFC3339)
+	if cert.Expired(time.Now()) {
+		expiry += " (expired)"
+	}
+	fmt.Fprintf(w, "%s subject:\t%s\n", name, cert.Subject)
+	fmt.Fprintf(w, "%s expiry:\t%s\n", name, expiry)
+}
+
+// Join the values onto separate lines of the table
+func listOrNone(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+
+	return strings.Join(values, "\n\t")
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ardevd/flash/internal/tui"
+	"github.com/charmbracelet/log"
+)
+
+// What invoice create reports
+type createdInvoice struct {
+	PaymentHash    string `json:"payment_hash"`
+	PaymentRequest string `json:"payment_request"`
+}
+
+// Handle the invoice subcommands
+func runInvoiceCommand(args []string) {
+	if len(args) == 0 {
+		printInvoiceUsage()
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "create":
+		invoiceCreate(args[1:])
+	default:
+		printInvoiceUsage()
+		os.Exit(2)
+	}
+}
+
+func printInvoiceUsage() {
+	fmt.Fprint(os.Stderr, `Usage: flash invoice <command> [flags]
+
+Commands:
+  create  Create a lightning invoice
+`)
+}
+
+// Create a lightning invoice
+func invoiceCreate(args []string) {
+	fs := flag.NewFlagSet("invoice create", flag.ExitOnError)
+	nf := addNodeFlags(fs)
+	amount := fs.Uint64("amount", 0, "Amount in sats")
+	memo := fs.String("memo", "", "Description of the invoice")
+	expiry := fs.Duration("expiry", 0, "Time until the invoice expires, overrides the config file")
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	fs.Parse(args)
+
+	if *amount == 0 {
+		log.Fatal("Usage: flash invoice create -amount <sats> [flags]")
+	}
+
+	backend, settings := nf.mustConnect()
+	defer backend.Close()
+
+	if !isFlagSet(fs, "expiry") {
+		*expiry = settings.InvoiceExpiry
+	}
+
+	if *expiry < time.Second {
+		log.Fatal("Expiry must be at least one second")
+	}
+
+	hash, paymentRequest, err := backend.CreateInvoice(context.Background(), *memo, *amount, int64(expiry.Seconds()))
+	if err != nil {
+		log.Fatal(tui.ErrorMessage(err))
+	}
+
+	invoice := createdInvoice{PaymentHash: hash.String(), PaymentRequest: paymentRequest}
+	if *asJSON {
+		printJSON(invoice)
+		return
+	}
+
+	printFields([][2]string{
+		{"Payment hash", invoice.PaymentHash},
+		{"Payment request", invoice.PaymentRequest},
+	})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ardevd/flash/internal/agent"
+	"github.com/ardevd/flash/internal/credentials"
+	"github.com/charmbracelet/log"
+)
+
+// Flags selecting the key of an auth file
+type keyFlags struct {
+	encKey        *string
+	keyFile       *string
+	keyStdin      *bool
+	usePassphrase *bool
+	useShares     *bool
+	shareFiles    *string
+	noAgent       *bool
+}
+
+func addKeyFlags(fs *flag.FlagSet) keyFlags {
+	return keyFlags{
+		encKey:        fs.String("k", "", "Encryption key. Prefer -key-file, -key-stdin or the "+credentials.KeyEnvVar+" environment variable"),
+		keyFile:       fs.String("key-file", "", "File holding the encryption key, only accessible by the current user"),
+		keyStdin:      fs.Bool("key-stdin", false, "Read the encryption key from stdin"),
+		usePassphrase: fs.Bool("p", false, "Use a passphrase instead of an encryption key"),
+		useShares:     fs.Bool("s", false, "Reconstruct the encryption key from key shares entered one by one"),
+		shareFiles:    fs.String("share-files", "", "Comma separated files holding key shares to reconstruct the encryption key from"),
+		noAgent:       fs.Bool("no-agent", false, "Don't use keys cached by the key agent"),
+	}
+}
+
+// Get the key for the auth file. Keys given on the command line take
+// precedence, followed by a passphrase or key shares if they were asked
+// for. Otherwise a key cached by the agent is used and the environment last.
+func (f keyFlags) key(authFile string) (credentials.Key, error) {
+	switch {
+	case *f.encKey != "":
+		return credentials.Key{Keyset: *f.encKey}, nil
+	case *f.keyFile != "":
+		encKey, err := credentials.ReadKeyFile(*f.keyFile)
+		return credentials.Key{Keyset: encKey}, err
+	case *f.keyStdin:
+		encKey, err := credentials.ReadKey(os.Stdin)
+		return credentials.Key{Keyset: encKey}, err
+	case *f.usePassphrase:
+		passphrase, err := readPassphrase("Passphrase: ")
+		if err != nil {
+			return credentials.Key{}, err
+		}
+		return credentials.Key{Passphrase: passphrase}, nil
+	case *f.useShares || *f.shareFiles != "":
+		encKey, err := f.combineShares()
+		if err != nil {
+			return credentials.Key{}, err
+		}
+		return credentials.Key{Keyset: encKey}, nil
+	}
+
+	if !*f.noAgent {
+		if key, err := agent.GetKey(agent.SocketPath(), absPath(authFile)); err == nil {
+			log.Debug("Using key cached by the agent")
+			return key, nil
+		}
+	}
+
+	if encKey, ok := credentials.KeyFromEnv(); ok {
+		return credentials.Key{Keyset: encKey}, nil
+	}
+
+	return credentials.Key{}, errors.New("encryption key or passphrase required")
+}
+
+// Load the vault from the auth file and cache the key in the agent
+func (f keyFlags) load(authFile string) (*credentials.Vault, credentials.Key, error) {
+	key, err := f.key(authFile)
+	if err != nil {
+		return nil, key, err
+	}
+
+	vault, err := credentials.LoadVault(key, authFile)
+	if err != nil {
+		return nil, key, err
+	}
+	if vault.Outdated {
+		log.Warn("Authentication file uses an outdated format, upgrade it with -migrate")
+	}
+
+	if !*f.noAgent {
+		cacheKey(authFile, key)
+	}
+
+	return vault, key, nil
+}
+
+// Reconstruct the encryption key from the share files, prompting for more
+// shares if they do not reach the threshold
+func (f keyFlags) combineShares() (string, error) {
+	var shares []credentials.KeyShare
+	if *f.shareFiles != "" {
+		for _, path := range strings.Split(*f.shareFiles, ",") {
+			share, err := credentials.ReadKeyShareFile(path)
+			if err != nil {
+				return "", err
+			}
+			shares = append(shares, share)
+		}
+	}
+
+	shares, err := readKeyShares(shares)
+	if err != nil {
+		return "", err
+	}
+
+	return credentials.CombineKeyShares(shares)
+}
+
+// Cache the key of the auth file if an agent is running
+func cacheKey(authFile string, key credentials.Key) {
+	if err := agent.PutKey(agent.SocketPath(), absPath(authFile), key); err == nil {
+		log.Debug("Key cached by the agent")
+	}
+}
+
+// The agent identifies auth files by their absolute path
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+
+	return path
+}
@@ -1,74 +1,153 @@
 package main
 
 import (
-	"context"
-	"encoding/hex"
 	"flag"
+	"fmt"
 
 	"github.com/ardevd/flash/internal/credentials"
 	"github.com/ardevd/flash/internal/tui"
-	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/log"
-	"github.com/lightninglabs/lndclient"
 
 	"os"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "auth":
+			runAuthCommand(os.Args[2:])
+			return
+		case "agent":
+			runAgentCommand(os.Args[2:])
+			return
+		case "tui":
+			runTUICommand(os.Args[2:])
+			return
+		case "node":
+			runNodeCommand(os.Args[2:])
+			return
+		case "channels":
+			runChannelsCommand(os.Args[2:])
+			return
+		case "invoice":
+			runInvoiceCommand(os.Args[2:])
+			return
+		case "pay":
+			runPayCommand(os.Args[2:])
+			return
+		case "sign":
+			runSignCommand(os.Args[2:])
+			return
+		case "verify":
+			runVerifyCommand(os.Args[2:])
+			return
+		}
+	}
+
 	logger := log.NewWithOptions(os.Stderr, log.Options{})
-	styles := tui.GetDefaultStyles()
 	// Arguments
 	tlsCertFile := flag.String("c", "", "TLS Certificate file")
 	adminMacaroon := flag.String("m", "", "Admin Macaroon")
+	lndConnectURI := flag.String("u", "", "lndconnect URI, or - to read it from stdin")
 	authFile := flag.String("a", "", "Authentication file")
-	encKey := flag.String("k", "", "Encryption key")
 	rpcServerAddress := flag.String("h", "", "RPC hostname:port")
+	network := flag.String("n", "", "Network the node runs on (mainnet, testnet, signet, regtest or simnet)")
+	label := flag.String("l", "", "Display label for the node")
+	proxy := flag.String("proxy", "", "SOCKS5 proxy host:port to reach the node through, such as Tor")
+	outputFile := flag.String("o", "auth.bin", "Output path for the authentication file")
+	migrate := flag.Bool("migrate", false, "Upgrade an authentication file to the current format")
+	profileName := flag.String("profile", "", "Name of the node profile to connect to")
+	kf := addKeyFlags(flag.CommandLine)
+	rf := addRestrictionFlags(flag.CommandLine)
+	sf := addSplitFlags(flag.CommandLine)
+	configPath := addConfigFlag(flag.CommandLine)
+	df := addDisplayFlags(flag.CommandLine)
+	runDemoNode := flag.Bool("demo", false, "Run the TUI against a simulated node")
+	offline := flag.Bool("offline", false, "Browse the node data cached on the last run without connecting")
+	flag.Usage = printUsage
 	flag.Parse()
 
-	if *tlsCertFile != "" && *adminMacaroon != "" {
-		encryptionKey := credentials.EncryptCredentials(*tlsCertFile, *adminMacaroon)
-		log.Info("Encrypted credentials file 'auth.bin' saved.\nEncryption key:" +
-			styles.Keyword(encryptionKey) + "\n\nauth.bin with the encryption key can now be used to connect to the node")
+	if (*tlsCertFile != "" && *adminMacaroon != "") || *lndConnectURI != "" {
+		name := *profileName
+		if name == "" {
+			name = credentials.DefaultProfileName
+		}
+
+		profile, err := newProfile(name, *tlsCertFile, *adminMacaroon, *lndConnectURI, *rpcServerAddress)
+		if err != nil {
+			logger.Fatal("Unable to read credentials:", err)
+		}
+		profile.Network = *network
+		profile.Label = *label
+		profile.Proxy = *proxy
+
+		if err := profile.Validate(); err != nil {
+			logger.Fatal(err)
+		}
+
+		if err := profile.Attenuate(rf.restrictions()); err != nil {
+			logger.Fatal("Unable to restrict macaroon:", err)
+		}
+
+		createVault(profile, *outputFile, *kf.usePassphrase, sf)
 		return
 	}
 
-	if *rpcServerAddress == "" {
-		log.Fatal("No RPC hostname specified.")
+	nf := nodeFlags{
+		vaultFlags: vaultFlags{authFile: authFile, keyFlags: kf},
+		profile:    profileName,
+		rpcHost:    rpcServerAddress,
+		network:    network,
+		proxy:      proxy,
+		configPath: configPath,
 	}
 
-	var tlsData []byte
-	var macData []byte
-	if *authFile != "" && *encKey != "" {
-		tlsData, macData = credentials.DecryptCredentials(*encKey, *authFile)
-	} else {
-		logger.Fatal("Auth file and encryption key required for node connection, alternatively generate them first with -a and -c")
+	if *runDemoNode {
+		runDemo(nf, df)
+		return
 	}
 
-	// Create a new gRPC client using the provided credentials.
-	config := lndclient.LndServicesConfig{
-		LndAddress:        *rpcServerAddress,
-		Network:           lndclient.NetworkMainnet,
-		CustomMacaroonHex: hex.EncodeToString(macData),
-		TLSData:           string(tlsData),
+	if *authFile == "" {
+		logger.Fatal("Auth file and encryption key or passphrase required for node connection, alternatively generate them first with -m and -c")
 	}
-	client, err := lndclient.NewLndServices(&config)
 
-	if err != nil {
-		logger.Fatal(err)
+	if *migrate {
+		key, err := kf.key(*authFile)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		if err := credentials.MigrateCredentials(key, *authFile); err != nil {
+			logger.Fatal(tui.ErrorMessage(err))
+		}
+		log.Info("Authentication file '" + *authFile + "' upgraded to the current format")
+		return
 	}
 
-	ctx := context.Background()
+	connectTUI(nf, df, *offline)
+}
 
-	m := tui.InitLoading(client)
-	p := tea.NewProgram(m)
+func printUsage() {
+	fmt.Fprint(os.Stderr, `Usage: flash [flags]
+       flash <command> [flags]
 
-	go func() {
-		nodeData := tui.GetData(client, ctx)
-		p.Send(tui.DataLoaded(nodeData))
-	}()
+Without a command flash creates an authentication file from -c and -m or -u,
+or connects to the node of the authentication file given with -a. -demo starts
+the TUI against a simulated node, -offline shows the node data cached on the
+last run without connecting.
 
-	if _, err := p.Run(); err != nil {
-		logger.Fatal("error running program:", err)
-		os.Exit(1)
-	}
+Commands:
+  tui       Connect to the node and start the TUI
+  node      Show information about the node
+  channels  List the channels of the node
+  invoice   Create lightning invoices
+  pay       Pay a lightning invoice
+  sign      Sign a message with the node's key
+  verify    Verify the signature of a message
+  auth      Manage the node profiles of an authentication file
+  agent     Run the key agent
+
+Flags:
+`)
+	flag.PrintDefaults()
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/ardevd/flash/internal/tui"
+	"github.com/charmbracelet/log"
+)
+
+// What sign reports
+type signedMessage struct {
+	Signature string `json:"signature"`
+}
+
+// What verify reports
+type verifiedMessage struct {
+	Valid  bool   `json:"valid"`
+	PubKey string `json:"pubkey,omitempty"`
+}
+
+// Sign a message with the node's key
+func runSignCommand(args []string) {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	nf := addNodeFlags(fs)
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	fs.Parse(args)
+
+	message, err := messageArg(fs)
+	if err != nil {
+		log.Fatal("Usage: flash sign [flags] <message>, or - to read it from stdin")
+	}
+
+	backend, _ := nf.mustConnect()
+	defer backend.Close()
+
+	signature, err := backend.SignMessage(context.Background(), message)
+	if err != nil {
+		log.Fatal(tui.ErrorMessage(err))
+	}
+
+	if *asJSON {
+		printJSON(signedMessage{Signature: signature})
+		return
+	}
+
+	printFields([][2]string{{"Signature", signature}})
+}
+
+// Verify the signature of a message. Exits with status 1 if it is invalid.
+func runVerifyCommand(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	nf := addNodeFlags(fs)
+	signature := fs.String("signature", "", "Signature of the message")
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	fs.Parse(args)
+
+	message, err := messageArg(fs)
+	if err != nil || *signature == "" {
+		log.Fatal("Usage: flash verify -signature <signature> [flags] <message>, or - to read it from stdin")
+	}
+
+	backend, _ := nf.mustConnect()
+	defer backend.Close()
+
+	valid, pubKey, err := backend.VerifyMessage(context.Background(), message, *signature)
+	if err != nil {
+		log.Fatal(tui.ErrorMessage(err))
+	}
+
+	result := verifiedMessage{Valid: valid}
+	if valid {
+		result.PubKey = pubKey
+	}
+
+	if *asJSON {
+		printJSON(result)
+	} else {
+		fields := [][2]string{{"Valid", strconv.FormatBool(result.Valid)}}
+		if valid {
+			fields = append(fields, [2]string{"Public key", result.PubKey})
+		}
+		printFields(fields)
+	}
+
+	if !valid {
+		os.Exit(1)
+	}
+}
+
+// Get the message from the only argument, reading it from stdin for "-"
+func messageArg(fs *flag.FlagSet) (string, error) {
+	if fs.NArg() != 1 {
+		return "", errors.New("message required")
+	}
+
+	if fs.Arg(0) != "-" {
+		return fs.Arg(0), nil
+	}
+
+	message, err := io.ReadAll(os.Stdin)
+	return string(message), err
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ardevd/flash/internal/tui"
+	"github.com/charmbracelet/log"
+)
+
+// Handle the node subcommands
+func runNodeCommand(args []string) {
+	if len(args) == 0 {
+		printNodeUsage()
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "info":
+		nodeInfo(args[1:])
+	default:
+		printNodeUsage()
+		os.Exit(2)
+	}
+}
+
+func printNodeUsage() {
+	fmt.Fprint(os.Stderr, `Usage: flash node <command> [flags]
+
+Commands:
+  info  Show the alias, version and balances of the node
+`)
+}
+
+// Show the alias, version and balances of the node
+func nodeInfo(args []string) {
+	fs := flag.NewFlagSet("node info", flag.ExitOnError)
+	nf := addNodeFlags(fs)
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	fs.Parse(args)
+
+	backend, _ := nf.mustConnect()
+	defer backend.Close()
+
+	node, err := backend.GetNode(context.Background())
+	if err != nil {
+		log.Fatal(tui.ErrorMessage(err))
+	}
+
+	if *asJSON {
+		printJSON(node)
+		return
+	}
+
+	printFields([][2]string{
+		{"Alias", node.Alias},
+		{"Public key", node.PubKey},
+		{"Implementation", node.Implementation},
+		{"Version", node.Version},
+		{"Network", node.Network},
+		{"Channel balance", node.ChannelBalance.String()},
+		{"Total capacity", node.TotalCapacity.String()},
+		{"On-chain balance", node.OnChainBalance.String()},
+	})
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/log"
+)
+
+// Print the value as indented JSON on stdout
+func printJSON(v any) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Table writer aligning tab separated columns on stdout. Flush it once all
+// rows are written.
+func newTable() *tabwriter.Writer {
+	return tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+}
+
+// Print key value pairs as a two column table
+func printFields(fields [][2]string) {
+	w := newTable()
+	defer w.Flush()
+
+	for _, f := range fields {
+		fmt.Fprintf(w, "%s:\t%s\n", f[0], f[1])
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strconv"
+
+	"github.com/ardevd/flash/internal/tui"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/charmbracelet/log"
+)
+
+// What pay reports for a settled payment
+type settledPayment struct {
+	Preimage string `json:"preimage"`
+	Amount   int64  `json:"amount_sat"`
+	Fee      int64  `json:"fee_sat"`
+}
+
+// Pay a lightning invoice
+func runPayCommand(args []string) {
+	fs := flag.NewFlagSet("pay", flag.ExitOnError)
+	nf := addNodeFlags(fs)
+	maxFee := fs.Int64("max-fee", 0, "Maximum routing fee in sats, overrides the config file")
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		log.Fatal("Usage: flash pay [flags] <invoice>")
+	}
+
+	backend, settings := nf.mustConnect()
+	defer backend.Close()
+
+	if !isFlagSet(fs, "max-fee") {
+		*maxFee = settings.MaxFee
+	}
+
+	result, err := backend.PayInvoice(context.Background(), fs.Arg(0), btcutil.Amount(*maxFee))
+	if err != nil {
+		log.Fatal(tui.ErrorMessage(err))
+	}
+
+	payment := settledPayment{
+		Preimage: result.Preimage.String(),
+		Amount:   int64(result.PaidAmt),
+		Fee:      int64(result.PaidFee),
+	}
+	if *asJSON {
+		printJSON(payment)
+		return
+	}
+
+	printFields([][2]string{
+		{"Preimage", payment.Preimage},
+		{"Amount", strconv.FormatInt(payment.Amount, 10) + " sats"},
+		{"Fee", strconv.FormatInt(payment.Fee, 10) + " sats"},
+	})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ardevd/flash/internal/credentials"
+	"golang.org/x/term"
+)
+
+// Prompt for a passphrase without echoing the input to the terminal
+func readPassphrase(prompt string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(passphrase) == 0 {
+		return nil, errors.New("no passphrase provided")
+	}
+
+	return passphrase, nil
+}
+
+// Prompt for a new passphrase twice and make sure both entries match
+func readNewPassphrase() ([]byte, error) {
+	passphrase, err := readPassphrase("New passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+
+	confirmation, err := readPassphrase("Confirm passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(passphrase, confirmation) {
+		return nil, errors.New("passphrases do not match")
+	}
+
+	return passphrase, nil
+}
+
+// Prompt for key shares until enough are entered to reconstruct the key.
+// Shares read from files beforehand count towards the threshold.
+func readKeyShares(shares []credentials.KeyShare) ([]credentials.KeyShare, error) {
+	for len(shares) == 0 || len(shares) < int(shares[0].Threshold) {
+		prompt := fmt.Sprintf("Key share %d: ", len(shares)+1)
+		if len(shares) > 0 {
+			prompt = fmt.Sprintf("Key share %d of %d: ", len(shares)+1, shares[0].Threshold)
+		}
+
+		input, err := readPassphrase(prompt)
+		if err != nil {
+			return nil, err
+		}
+
+		share, err := credentials.ParseKeyShare(string(input))
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+
+	return shares, nil
+}
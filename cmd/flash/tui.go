@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"time"
+
+	"github.com/ardevd/flash/internal/cache"
+	"github.com/ardevd/flash/internal/config"
+	"github.com/ardevd/flash/internal/credentials"
+	"github.com/ardevd/flash/internal/demo"
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/ardevd/flash/internal/logging"
+	"github.com/ardevd/flash/internal/tui"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// Connect to the node and start the TUI
+func runTUICommand(args []string) {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	nf := addNodeFlags(fs)
+	df := addDisplayFlags(fs)
+	demo := fs.Bool("demo", false, "Run the TUI against a simulated node")
+	offline := fs.Bool("offline", false, "Browse the node data cached on the last run without connecting")
+	fs.Parse(args)
+
+	if *demo {
+		runDemo(nf, df)
+		return
+	}
+
+	connectTUI(nf, df, *offline)
+}
+
+// Flags overriding the display settings of the config file
+type displayFlags struct {
+	theme *string
+	units *string
+}
+
+func addDisplayFlags(fs *flag.FlagSet) displayFlags {
+	return displayFlags{
+		theme: fs.String("theme", "", "Color theme, default or mono"),
+		units: fs.String("units", "", "Display amounts in sats or btc"),
+	}
+}
+
+func (f displayFlags) apply(settings *config.Settings) {
+	if *f.theme != "" {
+		settings.Theme = *f.theme
+	}
+
+	if *f.units != "" {
+		settings.Units = *f.units
+	}
+}
+
+// Connect to the node, load the settings of its profile and run the TUI.
+// Offline the TUI shows the cached node data of the profile instead.
+func connectTUI(nf nodeFlags, df displayFlags, offline bool) {
+	profile, settings, key, err := nf.prepare(true)
+	if err != nil {
+		log.Fatal(tui.ErrorMessage(err))
+	}
+	df.apply(&settings)
+
+	c := openCache(*nf.authFile, key, profile.Name)
+	if offline {
+		if c == nil {
+			log.Fatal("The node data cache is required to run offline")
+		}
+		runOffline(profile, settings, c)
+		return
+	}
+
+	runTUI(profile, settings, c)
+}
+
+// Open the node data cache of the profile. flash works without it, so it is
+// nil if opening it fails.
+func openCache(authFile string, key credentials.Key, profile string) *cache.Cache {
+	path, err := cache.Path(authFile)
+	if err != nil {
+		log.Warn("Node data cache unavailable", "err", err)
+		return nil
+	}
+
+	c, err := cache.Open(path, key, profile)
+	if err != nil {
+		log.Warn("Node data cache unavailable", "path", path, "err", err)
+		return nil
+	}
+
+	return c
+}
+
+// Connect to the node of the profile and run the TUI until the user quits.
+// The loading screen shows the progress of the connection, or the cached node
+// data if there is any.
+func runTUI(profile *credentials.Profile, settings config.Settings, c *cache.Cache) {
+	ctx := context.Background()
+
+	// Aliases are shared between reconnects, and with the next run if there
+	// is a cache
+	aliases := lnd.NewAliasCache()
+	if c != nil {
+		defer c.Close()
+		aliases = c.Aliases()
+	}
+
+	// Store the node data loaded through the connection in the cache
+	connect := func() (lnd.NodeBackend, error) {
+		backend, err := newBackend(profile, aliases)
+		if err != nil || c == nil {
+			return backend, err
+		}
+		return c.Record(backend), nil
+	}
+
+	runProgram(profile.Label, settings, func(p *tea.Program) {
+		if c != nil {
+			showCached(ctx, p, c)
+		}
+
+		p.Send(tui.LoadingStage(connectionStage(profile)))
+		backend, err := connect()
+		if err != nil {
+			log.Error("Unable to connect to the node", "host", profile.RPCHost, "err", err)
+			p.Send(tui.DataLoadFailed{Err: err})
+			return
+		}
+		log.Info("Connected to the node", "host", profile.RPCHost, "profile", profile.Name)
+
+		// Reconnect with the same profile when the connection is lost
+		supervisor := lnd.NewSupervisor(backend, func(context.Context) (lnd.NodeBackend, error) {
+			return connect()
+		})
+		go supervisor.Run(ctx)
+		go func() {
+			state := lnd.Connected
+			for status := range supervisor.Updates() {
+				if status.State != state {
+					logConnection(status)
+					state = status.State
+				}
+				p.Send(tui.ConnectionChanged(status))
+			}
+		}()
+		p.Send(tui.Connected{Backend: supervisor})
+
+		p.Send(tui.LoadingStage("Loading node data"))
+		nodeData, errs := tui.LoadData(supervisor, reportLoading(ctx, p), p.Send)
+
+		p.Send(tui.LoadingStage("Checking macaroon permissions"))
+		nodeData.Permissions = supervisor.GetPermissions(ctx)
+		sendNodeData(p, nodeData, errs)
+
+		go forwardEvents(ctx, supervisor, p)
+	})
+}
+
+// Run the TUI on the cached node data of the profile without connecting
+func runOffline(profile *credentials.Profile, settings config.Settings, c *cache.Cache) {
+	defer c.Close()
+
+	if _, _, err := c.Snapshot(); err != nil {
+		if errors.Is(err, cache.ErrNoSnapshot) {
+			log.Fatal("No node data cached for profile " + profile.Name + ", connect to the node once first")
+		}
+		log.Fatal(err)
+	}
+
+	runProgram(profile.Label, settings, func(p *tea.Program) {
+		showCached(context.Background(), p, c)
+	})
+}
+
+// Log the changes of the connection to the node
+func logConnection(status lnd.ConnectionStatus) {
+	switch status.State {
+	case lnd.Connected:
+		log.Info("Reconnected to the node")
+	case lnd.Reconnecting:
+		log.Warn("Connection to the node lost, reconnecting", "err", status.Err)
+	default:
+		log.Error("Node offline", "err", status.Err)
+	}
+}
+
+// Show the cached node data until the data of the node is loaded. Actions
+// are unavailable while it is shown.
+func showCached(ctx context.Context, p *tea.Program, c *cache.Cache) {
+	nodeData, saved, err := c.Snapshot()
+	if err != nil {
+		return
+	}
+
+	offline := c.Offline()
+	nodeData.Permissions = offline.GetPermissions(ctx)
+	p.Send(tui.Connected{Backend: offline})
+	p.Send(tui.CachedDataLoaded{NodeData: nodeData, Saved: saved})
+}
+
+// Run the TUI against a simulated node, which needs neither a node nor an
+// authentication file. The settings of the config file apply.
+func runDemo(nf nodeFlags, df displayFlags) {
+	cfg, err := nf.config()
+	if err != nil {
+		log.Fatal(tui.ErrorMessage(err))
+	}
+	settings := cfg.ForProfile("demo")
+	df.apply(&settings)
+
+	ctx := context.Background()
+	node := demo.NewNode(time.Now().UnixNano())
+	go node.Run(ctx)
+
+	runProgram("demo", settings, func(p *tea.Program) {
+		p.Send(tui.Connected{Backend: node})
+
+		p.Send(tui.LoadingStage("Loading node data"))
+		nodeData, errs := tui.LoadData(node, ctx, p.Send)
+
+		nodeData.Permissions = node.GetPermissions(ctx)
+		sendNodeData(p, nodeData, errs)
+
+		go forwardEvents(ctx, node, p)
+	})
+}
+
+// Pass the loaded node data to the TUI. If parts of it failed to load, the
+// loading screen offers to retry them.
+func sendNodeData(p *tea.Program, nodeData lnd.NodeData, errs tui.DataErrors) {
+	for part, err := range errs {
+		log.Error("Unable to load node data", "part", part, "err", err)
+	}
+
+	if errs != nil {
+		p.Send(tui.PartialDataLoaded{NodeData: nodeData, Errors: errs})
+		return
+	}
+
+	log.Info("Node data loaded", "channels", len(nodeData.Channels),
+		"pending_channels", len(nodeData.PendingChannels), "payments", len(nodeData.Payments))
+	p.Send(tui.DataLoaded(nodeData))
+}
+
+// Show the progress of the calls made with the context on the loading screen
+func reportLoading(ctx context.Context, p *tea.Program) context.Context {
+	return lnd.WithProgress(ctx, func(progress lnd.Progress) {
+		p.Send(tui.LoadingProgress(progress))
+	})
+}
+
+// Pass the events of the node to the TUI until the stream ends. Without
+// events the dashboard still refreshes periodically.
+func forwardEvents(ctx context.Context, backend lnd.NodeBackend, p *tea.Program) {
+	events, errs, err := backend.SubscribeEvents(ctx)
+	if err != nil {
+		return
+	}
+
+	for {
+		select {
+		case event := <-events:
+			p.Send(tui.NodeEvent(event))
+		case err := <-errs:
+			log.Warn("Node events unavailable, the dashboard only refreshes periodically", "err", err)
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Start the TUI on the loading screen and load the node data in the
+// background until the user quits
+func runProgram(label string, settings config.Settings, load func(p *tea.Program)) {
+	if err := tui.Configure(settings); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output would corrupt the TUI, so the log goes to the log file and the
+	// log console while it runs
+	restoreLog := logToConsole()
+
+	m := tui.InitLoading(nil, label, settings)
+	p := tea.NewProgram(m)
+	go load(p)
+
+	_, err := p.Run()
+	restoreLog()
+	if err != nil {
+		log.Fatal("error running program:", err)
+	}
+}
+
+// Send the log to the log file and the log console and return a function
+// that restores the previous logger. Without a log file the log console
+// still shows the latest entries.
+func logToConsole() func() {
+	previous := log.Default()
+	restore := func() { log.SetDefault(previous) }
+
+	path, err := logging.Path()
+	if err != nil {
+		return logToRing(restore, "err", err)
+	}
+
+	logger, ring, file, err := logging.New(path)
+	if err != nil {
+		return logToRing(restore, "path", path, "err", err)
+	}
+
+	log.SetDefault(logger)
+	tui.ShowLogs(ring)
+	return func() {
+		restore()
+		file.Close()
+	}
+}
+
+// Send the log to the log console only and record why the log file is
+// unavailable
+func logToRing(restore func(), keyvals ...any) func() {
+	logger, ring := logging.NewRingLogger()
+	log.SetDefault(logger)
+	tui.ShowLogs(ring)
+	log.Warn("Log file unavailable, only the log console shows the log", keyvals...)
+
+	return restore
+}
+
+func connectionStage(profile *credentials.Profile) string {
+	if profile.Proxy != "" {
+		return "Connecting to " + profile.RPCHost + " through proxy " + profile.Proxy
+	}
+
+	return "Connecting to " + profile.RPCHost
+}
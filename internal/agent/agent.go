@@ -0,0 +1,305 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ardevd/flash/internal/credentials"
+)
+
+// The agent caches the keys of auth files in memory, so repeated invocations
+// of flash don't need the key re-entered. Clients talk to it over a Unix
+// socket in a directory only accessible by the current user, exchanging one
+// JSON request and response per connection.
+
+// Environment variable overriding the socket path
+const SocketEnvVar = "FLASH_AGENT_SOCKET"
+
+// Default time keys are cached for
+const DefaultTTL = 15 * time.Minute
+
+const (
+	opGet  = "get"
+	opPut  = "put"
+	opStop = "stop"
+
+	dialTimeout = time.Second
+)
+
+type request struct {
+	Op         string `json:"op"`
+	AuthFile   string `json:"auth_file,omitempty"`
+	Keyset     string `json:"keyset,omitempty"`
+	Passphrase []byte `json:"passphrase,omitempty"`
+}
+
+type response struct {
+	Keyset     string `json:"keyset,omitempty"`
+	Passphrase []byte `json:"passphrase,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type entry struct {
+	key     credentials.Key
+	expires time.Time
+}
+
+// Agent holds keys for a limited time
+type Agent struct {
+	ttl  time.Duration
+	now  func() time.Time
+	mu   sync.Mutex
+	keys map[string]entry
+	done chan struct{}
+}
+
+// New creates an agent caching keys for ttl
+func New(ttl time.Duration) *Agent {
+	return &Agent{ttl: ttl, now: time.Now, keys: make(map[string]entry), done: make(chan struct{})}
+}
+
+// SocketPath returns the path of the agent socket
+func SocketPath() string {
+	if path := os.Getenv(SocketEnvVar); path != "" {
+		return path
+	}
+
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	return filepath.Join(dir, "flash-"+strconv.Itoa(os.Getuid()), "agent.sock")
+}
+
+// Listen creates the agent socket. The directory holding it is created
+// with owner-only permissions and a stale socket is replaced.
+func Listen(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	if err := checkDir(dir); err != nil {
+		return nil, err
+	}
+
+	// A socket left by this user is replaced, others are refused
+	if _, err := os.Lstat(path); err == nil {
+		if err := checkOwner(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if conn, err := net.DialTimeout("unix", path, dialTimeout); err == nil {
+		conn.Close()
+		return nil, errors.New("an agent is already running on " + path)
+	}
+	os.Remove(path)
+
+	return net.Listen("unix", path)
+}
+
+// Serve answers requests until the agent is stopped or the listener fails
+func (a *Agent) Serve(l net.Listener) error {
+	go func() {
+		<-a.done
+		l.Close()
+	}()
+
+	go a.expireKeys()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-a.done:
+				return nil
+			default:
+				return err
+			}
+		}
+
+		go a.handle(conn)
+	}
+}
+
+// Stop shuts the agent down and forgets all keys
+func (a *Agent) Stop() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	select {
+	case <-a.done:
+	default:
+		close(a.done)
+	}
+	a.keys = make(map[string]entry)
+}
+
+func (a *Agent) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+
+	var req request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+
+	json.NewEncoder(conn).Encode(a.process(req))
+
+	// Stop after answering so the client knows the request succeeded
+	if req.Op == opStop {
+		a.Stop()
+	}
+}
+
+func (a *Agent) process(req request) response {
+	switch req.Op {
+	case opGet:
+		key, ok := a.get(req.AuthFile)
+		if !ok {
+			return response{Error: "no key cached for " + req.AuthFile}
+		}
+		return response{Keyset: key.Keyset, Passphrase: key.Passphrase}
+	case opPut:
+		a.put(req.AuthFile, credentials.Key{Keyset: req.Keyset, Passphrase: req.Passphrase})
+		return response{}
+	case opStop:
+		return response{}
+	}
+
+	return response{Error: "unknown operation " + req.Op}
+}
+
+func (a *Agent) get(authFile string) (credentials.Key, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	e, ok := a.keys[authFile]
+	if !ok || a.now().After(e.expires) {
+		delete(a.keys, authFile)
+		return credentials.Key{}, false
+	}
+
+	return e.key, true
+}
+
+func (a *Agent) put(authFile string, key credentials.Key) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.keys[authFile] = entry{key: key, expires: a.now().Add(a.ttl)}
+}
+
+// Drop expired keys from memory
+func (a *Agent) expireKeys() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+			a.mu.Lock()
+			for authFile, e := range a.keys {
+				if a.now().After(e.expires) {
+					delete(a.keys, authFile)
+				}
+			}
+			a.mu.Unlock()
+		}
+	}
+}
+
+// GetKey asks the agent for the cached key of the auth file
+func GetKey(socketPath, authFile string) (credentials.Key, error) {
+	resp, err := call(socketPath, request{Op: opGet, AuthFile: authFile})
+	if err != nil {
+		return credentials.Key{}, err
+	}
+
+	return credentials.Key{Keyset: resp.Keyset, Passphrase: resp.Passphrase}, nil
+}
+
+// PutKey caches the key of the auth file in the agent
+func PutKey(socketPath, authFile string, key credentials.Key) error {
+	_, err := call(socketPath, request{Op: opPut, AuthFile: authFile, Keyset: key.Keyset, Passphrase: key.Passphrase})
+	return err
+}
+
+// Stop asks the agent to shut down
+func Stop(socketPath string) error {
+	_, err := call(socketPath, request{Op: opStop})
+	return err
+}
+
+// The directory of the socket must be a real directory of the current user
+// that other users can't access
+func checkDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return errors.New("agent directory " + dir + " is not a directory")
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		return errors.New("agent directory " + dir + " is accessible by other users")
+	}
+
+	return checkOwner(dir)
+}
+
+// Keys are only sent to sockets of the current user in a private directory
+func checkSocket(socketPath string) error {
+	if err := checkDir(filepath.Dir(socketPath)); err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(socketPath)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Type() != os.ModeSocket {
+		return errors.New(socketPath + " is not a socket")
+	}
+
+	return checkOwner(socketPath)
+}
+
+func call(socketPath string, req request) (response, error) {
+	if err := checkSocket(socketPath); err != nil {
+		return response{}, err
+	}
+
+	conn, err := net.DialTimeout("unix", socketPath, dialTimeout)
+	if err != nil {
+		return response{}, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return response{}, err
+	}
+
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return response{}, err
+	}
+
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+
+	return resp, nil
+}
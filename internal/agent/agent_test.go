@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ardevd/flash/internal/credentials"
+	"github.com/stretchr/testify/assert"
+)
+
+func startAgent(t *testing.T, ttl time.Duration) (*Agent, string) {
+	dir, err := os.MkdirTemp("", "flash-agent")
+	if err != nil {
+		assert.FailNow(t, "unable to create socket directory: "+err.Error())
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socketPath := filepath.Join(dir, "agent.sock")
+	l, err := Listen(socketPath)
+	if err != nil {
+		assert.FailNow(t, "unable to listen: "+err.Error())
+	}
+
+	a := New(ttl)
+	go a.Serve(l)
+	t.Cleanup(a.Stop)
+
+	return a, socketPath
+}
+
+func TestAgentCachesKeys(t *testing.T) {
+	_, socketPath := startAgent(t, time.Minute)
+
+	_, err := GetKey(socketPath, "/tmp/auth.bin")
+	assert.Error(t, err)
+
+	assert.NoError(t, PutKey(socketPath, "/tmp/auth.bin", credentials.Key{Keyset: "abcdef"}))
+	assert.NoError(t, PutKey(socketPath, "/tmp/other.bin", credentials.Key{Passphrase: []byte("secret")}))
+
+	key, err := GetKey(socketPath, "/tmp/auth.bin")
+	assert.NoError(t, err)
+	assert.Equal(t, credentials.Key{Keyset: "abcdef"}, key)
+
+	key, err = GetKey(socketPath, "/tmp/other.bin")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret"), key.Passphrase)
+}
+
+func TestAgentForgetsExpiredKeys(t *testing.T) {
+	a, socketPath := startAgent(t, time.Minute)
+	now := time.Now()
+	a.now = func() time.Time { return now }
+
+	assert.NoError(t, PutKey(socketPath, "/tmp/auth.bin", credentials.Key{Keyset: "abcdef"}))
+
+	now = now.Add(2 * time.Minute)
+	_, err := GetKey(socketPath, "/tmp/auth.bin")
+	assert.Error(t, err)
+}
+
+func TestAgentStop(t *testing.T) {
+	_, socketPath := startAgent(t, time.Minute)
+
+	assert.NoError(t, Stop(socketPath))
+
+	assert.Eventually(t, func() bool {
+		_, err := GetKey(socketPath, "/tmp/auth.bin")
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestListenRefusesSharedDirectory(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Chmod(dir, 0o755))
+
+	_, err := Listen(filepath.Join(dir, "agent.sock"))
+	assert.Error(t, err)
+}
+
+func TestClientRefusesForeignSocket(t *testing.T) {
+	_, socketPath := startAgent(t, time.Minute)
+	dir := filepath.Dir(socketPath)
+
+	// A directory other users can enter may hold a socket of another user
+	assert.NoError(t, os.Chmod(dir, 0o755))
+	assert.Error(t, PutKey(socketPath, "/tmp/auth.bin", credentials.Key{Keyset: "abcdef"}))
+	assert.NoError(t, os.Chmod(dir, 0o700))
+
+	if os.Getuid() != 0 {
+		t.Skip("changing the owner of the socket requires root")
+	}
+
+	// The directory and socket were created by another user
+	assert.NoError(t, os.Lchown(dir, 4242, 4242))
+	assert.Error(t, PutKey(socketPath, "/tmp/auth.bin", credentials.Key{Keyset: "abcdef"}))
+	_, err := Listen(socketPath)
+	assert.Error(t, err)
+
+	assert.NoError(t, os.Lchown(dir, os.Getuid(), os.Getgid()))
+	assert.NoError(t, os.Lchown(socketPath, 4242, 4242))
+	assert.Error(t, PutKey(socketPath, "/tmp/auth.bin", credentials.Key{Keyset: "abcdef"}))
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package agent
+
+import "errors"
+
+// The owner of the socket can't be checked, so the agent isn't used
+func checkOwner(path string) error {
+	return errors.New("the key agent is only supported on Unix systems")
+}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package agent
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// Refuse paths owned by other users. Without XDG_RUNTIME_DIR the socket is
+// in the shared temp directory, where another user could create it first to
+// collect the keys sent to it.
+func checkOwner(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return errors.New("unable to check the owner of " + path)
+	}
+
+	if int(stat.Uid) != os.Getuid() {
+		return errors.New(path + " is owned by another user")
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/lightninglabs/lndclient"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/routing/route"
+)
+
+// ErrOffline is returned by the offline backend for calls the cache can't
+// answer
+var ErrOffline = errors.New("not connected to the node")
+
+// Record returns a backend that stores the node data loaded through it in the
+// cache. The cache is best effort, calls don't fail if storing their result
+// does.
+func (c *Cache) Record(backend lnd.NodeBackend) lnd.NodeBackend {
+	return &recorder{NodeBackend: backend, cache: c}
+}
+
+type recorder struct {
+	lnd.NodeBackend
+	cache *Cache
+}
+
+func (r *recorder) GetNode(ctx context.Context) (lnd.Node, error) {
+	node, err := r.NodeBackend.GetNode(ctx)
+	if err == nil {
+		r.cache.put(nodeKey, node)
+	}
+
+	return node, err
+}
+
+func (r *recorder) GetChannels(ctx context.Context) ([]lnd.Channel, error) {
+	channels, err := r.NodeBackend.GetChannels(ctx)
+	if err == nil {
+		r.cache.putChannels(channels)
+		r.cache.saveAliases()
+	}
+
+	return channels, err
+}
+
+func (r *recorder) GetPendingChannels(ctx context.Context) ([]lnd.PendingChannel, error) {
+	channels, err := r.NodeBackend.GetPendingChannels(ctx)
+	if err == nil {
+		r.cache.put(pendingChannelsKey, channels)
+	}
+
+	return channels, err
+}
+
+func (r *recorder) GetPayments(ctx context.Context, max uint64) ([]lnd.Payment, error) {
+	payments, err := r.NodeBackend.GetPayments(ctx, max)
+	if err == nil {
+		r.cache.put(paymentsKey, payments)
+	}
+
+	return payments, err
+}
+
+func (r *recorder) GetChannelEdge(ctx context.Context, channelID uint64) (*lndclient.ChannelEdge, error) {
+	edge, err := r.NodeBackend.GetChannelEdge(ctx, channelID)
+	if err == nil {
+		r.cache.putChannelEdge(edge)
+	}
+
+	return edge, err
+}
+
+// Offline returns a backend serving the cached node data. Everything that
+// needs the node fails with ErrOffline and no action is allowed.
+func (c *Cache) Offline() lnd.NodeBackend {
+	return &offline{cache: c}
+}
+
+type offline struct {
+	cache *Cache
+}
+
+var _ lnd.NodeBackend = (*offline)(nil)
+
+func offlineError(op string) error {
+	return &lnd.RPCError{Op: op, Err: ErrOffline}
+}
+
+func (o *offline) GetNode(ctx context.Context) (lnd.Node, error) {
+	var node lnd.Node
+	_, err := o.cache.get(nodeKey, &node)
+	return node, err
+}
+
+func (o *offline) GetNodeAlias(ctx context.Context, pubKey route.Vertex) string {
+	return o.cache.aliases.Get(ctx, pubKey, o.lookupAlias)
+}
+
+// Peers of cached channels have their alias stored with the channel
+func (o *offline) lookupAlias(ctx context.Context, pubKey route.Vertex) (string, error) {
+	channels, err := o.GetChannels(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	for _, channel := range channels {
+		if channel.Info.PubKeyBytes == pubKey {
+			return channel.Alias, nil
+		}
+	}
+
+	return "", ErrOffline
+}
+
+func (o *offline) GetPermissions(ctx context.Context) lnd.Permissions {
+	return lnd.DenyAll("flash is offline and shows cached data")
+}
+
+func (o *offline) GetChannels(ctx context.Context) ([]lnd.Channel, error) {
+	var channels []lnd.Channel
+	_, err := o.cache.get(channelsKey, &channels)
+	return channels, err
+}
+
+func (o *offline) GetPendingChannels(ctx context.Context) ([]lnd.PendingChannel, error) {
+	var channels []lnd.PendingChannel
+	_, err := o.cache.get(pendingChannelsKey, &channels)
+	return channels, err
+}
+
+func (o *offline) GetChannelEdge(ctx context.Context, channelID uint64) (*lndclient.ChannelEdge, error) {
+	edge, err := o.cache.getChannelEdge(channelID)
+	if errors.Is(err, ErrNoSnapshot) {
+		return nil, offlineError("get channel info")
+	}
+
+	return edge, err
+}
+
+func (o *offline) UpdateChannelPolicy(ctx context.Context, channelPoint string, policy lndclient.PolicyUpdateRequest) error {
+	return offlineError("update channel policy")
+}
+
+func (o *offline) CloseChannel(ctx context.Context, channelPoint string, force bool, targetBlocks int32) (
+	<-chan lndclient.CloseChannelUpdate, <-chan error, error) {
+
+	return nil, nil, offlineError("close channel")
+}
+
+func (o *offline) GetPayments(ctx context.Context, max uint64) ([]lnd.Payment, error) {
+	var payments []lnd.Payment
+	_, err := o.cache.get(paymentsKey, &payments)
+	if uint64(len(payments)) > max {
+		payments = payments[:max]
+	}
+
+	return payments, err
+}
+
+func (o *offline) DecodeInvoice(ctx context.Context, invoice string) (*lndclient.PaymentRequest, error) {
+	return nil, offlineError("decode invoice")
+}
+
+func (o *offline) CreateInvoice(ctx context.Context, memo string, satsAmount uint64, expiry int64) (lntypes.Hash, string, error) {
+	return lntypes.Hash{}, "", offlineError("create invoice")
+}
+
+func (o *offline) PayInvoice(ctx context.Context, invoice string, maxFee btcutil.Amount) (lndclient.PaymentResult, error) {
+	return lndclient.PaymentResult{}, offlineError("pay invoice")
+}
+
+func (o *offline) SubscribeInvoices(ctx context.Context) (<-chan *lndclient.Invoice, <-chan error, error) {
+	return nil, nil, offlineError("subscribe invoices")
+}
+
+func (o *offline) SubscribeEvents(ctx context.Context) (<-chan lnd.NodeEvent, <-chan error, error) {
+	return nil, nil, offlineError("subscribe events")
+}
+
+func (o *offline) SignMessage(ctx context.Context, message string) (string, error) {
+	return "", offlineError("sign message")
+}
+
+func (o *offline) VerifyMessage(ctx context.Context, message, signature string) (bool, string, error) {
+	return false, "", offlineError("verify message")
+}
+
+// The cache is closed by its owner
+func (o *offline) Close() {}
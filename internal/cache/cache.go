@@ -0,0 +1,300 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ardevd/flash/internal/credentials"
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/google/tink/go/tink"
+	"github.com/lightninglabs/lndclient"
+	"github.com/lightningnetwork/lnd/routing/route"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrNoSnapshot is returned when no node data was cached for the profile
+var ErrNoSnapshot = errors.New("no cached node data")
+
+var (
+	// Bucket of the sealed data key
+	metaBucket = []byte("meta")
+	dataKeyKey = []byte("data_key")
+)
+
+// Keys of the cached parts of the node data
+const (
+	nodeKey            = "node"
+	channelsKey        = "channels"
+	pendingChannelsKey = "pending_channels"
+	paymentsKey        = "payments"
+	aliasesKey         = "aliases"
+)
+
+// Cache keeps the node data last loaded from a node on disk, so flash can
+// show it right away on the next start and without a connection. Of the
+// graph it only holds what the TUI looked up, the aliases of peers and the
+// policies of channels. Each profile of the auth file has its own bucket.
+// The values are encrypted with a data key, which is sealed with the key of
+// the auth file.
+type Cache struct {
+	db      *bolt.DB
+	aead    tink.AEAD
+	bucket  []byte
+	aliases *lnd.AliasCache
+}
+
+// A cached value and the time it was loaded from the node
+type record struct {
+	Saved time.Time       `json:"saved"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Path of the cache of the auth file in the cache directory of the user
+func Path(authFile string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	abs, err := filepath.Abs(authFile)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(dir, "flash", hex.EncodeToString(sum[:8])+".db"), nil
+}
+
+// Open the cache at the path for the profile. Caches sealed with another
+// key, e.g. since the key of the auth file was rotated, are cleared.
+// Opening fails if another instance of flash holds the cache.
+func Open(path string, key credentials.Key, profile string) (*Cache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := openDataKey(db, key)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	c := &Cache{db: db, aead: aead, bucket: []byte("profile/" + profile), aliases: lnd.NewAliasCache()}
+	c.restoreAliases()
+	return c, nil
+}
+
+// Open the data key of the cache, or start over with a new one if there is
+// none or it was sealed with another key. Other failures leave the cache
+// alone.
+func openDataKey(db *bolt.DB, key credentials.Key) (tink.AEAD, error) {
+	var sealed []byte
+	db.View(func(tx *bolt.Tx) error {
+		if meta := tx.Bucket(metaBucket); meta != nil {
+			sealed = bytes.Clone(meta.Get(dataKeyKey))
+		}
+		return nil
+	})
+
+	if sealed != nil {
+		aead, err := credentials.OpenDataKey(key, sealed)
+		if err == nil {
+			return aead, nil
+		}
+		if !errors.Is(err, credentials.ErrWrongKey) {
+			return nil, err
+		}
+	}
+
+	aead, sealed, err := credentials.NewDataKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		// Nothing stored can be decrypted without the old key
+		var buckets [][]byte
+		tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			buckets = append(buckets, bytes.Clone(name))
+			return nil
+		})
+		for _, name := range buckets {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+
+		meta, err := tx.CreateBucket(metaBucket)
+		if err != nil {
+			return err
+		}
+		return meta.Put(dataKeyKey, sealed)
+	})
+
+	return aead, err
+}
+
+// Close the cache and store the aliases looked up in the meantime
+func (c *Cache) Close() error {
+	c.saveAliases()
+	return c.db.Close()
+}
+
+// Aliases returns the alias cache restored from disk. Backends sharing it
+// have the aliases of the peers right away.
+func (c *Cache) Aliases() *lnd.AliasCache {
+	return c.aliases
+}
+
+// Snapshot returns the cached node data and the time of its oldest part
+func (c *Cache) Snapshot() (lnd.NodeData, time.Time, error) {
+	var nodeData lnd.NodeData
+	saved, err := c.get(nodeKey, &nodeData.NodeInfo)
+	if err != nil {
+		return nodeData, saved, err
+	}
+
+	parts := []struct {
+		key   string
+		value any
+	}{
+		{channelsKey, &nodeData.Channels},
+		{pendingChannelsKey, &nodeData.PendingChannels},
+		{paymentsKey, &nodeData.Payments},
+	}
+	for _, part := range parts {
+		partSaved, err := c.get(part.key, part.value)
+		if errors.Is(err, ErrNoSnapshot) {
+			continue
+		}
+		if err != nil {
+			return nodeData, saved, err
+		}
+
+		if partSaved.Before(saved) {
+			saved = partSaved
+		}
+	}
+
+	return nodeData, saved, nil
+}
+
+// Encrypt the value and store it under the key in the bucket of the profile
+func (c *Cache) put(key string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	data, err = json.Marshal(record{Saved: time.Now(), Value: data})
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := c.aead.Encrypt(data, c.associatedData(key))
+	if err != nil {
+		return err
+	}
+
+	return c.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(c.bucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(key), ciphertext)
+	})
+}
+
+// Decrypt the value stored under the key into value and return the time it
+// was stored
+func (c *Cache) get(key string, value any) (time.Time, error) {
+	var ciphertext []byte
+	c.db.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket(c.bucket); bucket != nil {
+			ciphertext = bytes.Clone(bucket.Get([]byte(key)))
+		}
+		return nil
+	})
+	if ciphertext == nil {
+		return time.Time{}, ErrNoSnapshot
+	}
+
+	data, err := c.aead.Decrypt(ciphertext, c.associatedData(key))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var r record
+	if err := json.Unmarshal(data, &r); err != nil {
+		return time.Time{}, err
+	}
+
+	return r.Saved, json.Unmarshal(r.Value, value)
+}
+
+// Values are bound to their profile and key, so they can't be swapped
+func (c *Cache) associatedData(key string) []byte {
+	return append(bytes.Clone(c.bucket), "/"+key...)
+}
+
+func edgeKey(channelID uint64) string {
+	return "edge/" + strconv.FormatUint(channelID, 10)
+}
+
+func (c *Cache) putChannels(channels []lnd.Channel) error {
+	// Close addresses are interfaces that can't be decoded again
+	stored := make([]lnd.Channel, len(channels))
+	for i, channel := range channels {
+		stored[i] = channel
+		stored[i].Info.CloseAddr = nil
+	}
+
+	return c.put(channelsKey, stored)
+}
+
+func (c *Cache) putChannelEdge(edge *lndclient.ChannelEdge) error {
+	return c.put(edgeKey(edge.ChannelID), edge)
+}
+
+func (c *Cache) getChannelEdge(channelID uint64) (*lndclient.ChannelEdge, error) {
+	edge := &lndclient.ChannelEdge{}
+	_, err := c.get(edgeKey(channelID), edge)
+	return edge, err
+}
+
+// Aliases are stored by the hex encoded public key of the node
+func (c *Cache) saveAliases() error {
+	aliases := make(map[string]lnd.CachedAlias)
+	for pubKey, cached := range c.aliases.Entries() {
+		aliases[pubKey.String()] = cached
+	}
+
+	return c.put(aliasesKey, aliases)
+}
+
+func (c *Cache) restoreAliases() {
+	var aliases map[string]lnd.CachedAlias
+	if _, err := c.get(aliasesKey, &aliases); err != nil {
+		return
+	}
+
+	entries := make(map[route.Vertex]lnd.CachedAlias, len(aliases))
+	for pubKey, cached := range aliases {
+		if vertex, err := route.NewVertexFromStr(pubKey); err == nil {
+			entries[vertex] = cached
+		}
+	}
+	c.aliases.Restore(entries)
+}
@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ardevd/flash/internal/credentials"
+	"github.com/ardevd/flash/internal/demo"
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestRecordAndBrowseOffline(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.db")
+	key := newKey(t)
+
+	c, err := Open(path, key, "alpha")
+	require.NoError(t, err)
+	_, _, err = c.Snapshot()
+	assert.ErrorIs(t, err, ErrNoSnapshot)
+
+	node := demo.NewNode(7)
+	backend := c.Record(node)
+	info, err := backend.GetNode(ctx)
+	require.NoError(t, err)
+	channels, err := backend.GetChannels(ctx)
+	require.NoError(t, err)
+	pending, err := backend.GetPendingChannels(ctx)
+	require.NoError(t, err)
+	payments, err := backend.GetPayments(ctx, 10)
+	require.NoError(t, err)
+	edge, err := backend.GetChannelEdge(ctx, channels[0].Info.ChannelID)
+	require.NoError(t, err)
+
+	var stranger route.Vertex
+	c.Aliases().Get(ctx, stranger, func(context.Context, route.Vertex) (string, error) {
+		return "stranger", nil
+	})
+	require.NoError(t, c.Close())
+
+	c, err = Open(path, key, "alpha")
+	require.NoError(t, err)
+	defer c.Close()
+
+	nodeData, _, err := c.Snapshot()
+	require.NoError(t, err)
+	assert.Equal(t, info, nodeData.NodeInfo)
+	assert.Equal(t, channels, nodeData.Channels)
+	assert.Equal(t, pending, nodeData.PendingChannels)
+	assert.Len(t, nodeData.Payments, len(payments))
+	assert.Equal(t, payments[0].Payment.Hash, nodeData.Payments[0].Payment.Hash)
+
+	offline := c.Offline()
+	cachedEdge, err := offline.GetChannelEdge(ctx, edge.ChannelID)
+	require.NoError(t, err)
+	assert.Equal(t, edge.Node1Policy.FeeRateMilliMsat, cachedEdge.Node1Policy.FeeRateMilliMsat)
+	assert.Equal(t, channels[0].Alias, offline.GetNodeAlias(ctx, channels[0].Info.PubKeyBytes))
+	assert.Equal(t, "stranger", offline.GetNodeAlias(ctx, stranger))
+	assert.False(t, offline.GetPermissions(ctx).Allowed(lnd.SendPayment))
+
+	_, _, err = offline.CreateInvoice(ctx, "", 1000, 3600)
+	assert.ErrorIs(t, err, ErrOffline)
+	_, err = offline.GetChannelEdge(ctx, 1)
+	assert.ErrorIs(t, err, ErrOffline)
+}
+
+func TestOpenWithOtherKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	c, err := Open(path, newKey(t), "alpha")
+	require.NoError(t, err)
+	require.NoError(t, c.put(nodeKey, lnd.Node{Alias: "alpha"}))
+	require.NoError(t, c.Close())
+
+	// The cache of a rotated key can't be decrypted and starts over
+	c, err = Open(path, newKey(t), "alpha")
+	require.NoError(t, err)
+	defer c.Close()
+	_, _, err = c.Snapshot()
+	assert.ErrorIs(t, err, ErrNoSnapshot)
+}
+
+func TestOpenKeepsCorruptedCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	key := newKey(t)
+
+	c, err := Open(path, key, "alpha")
+	require.NoError(t, err)
+	require.NoError(t, c.put(nodeKey, lnd.Node{Alias: "alpha"}))
+	require.NoError(t, c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucket).Put(dataKeyKey, []byte("garbage"))
+	}))
+	require.NoError(t, c.Close())
+
+	// Only a cache sealed with another key is cleared
+	_, err = Open(path, key, "alpha")
+	assert.Error(t, err)
+
+	db, err := bolt.Open(path, 0600, nil)
+	require.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.View(func(tx *bolt.Tx) error {
+		assert.NotNil(t, tx.Bucket([]byte("profile/alpha")))
+		return nil
+	}))
+}
+
+// Generate a new encryption key
+func newKey(t *testing.T) credentials.Key {
+	keyset, err := credentials.GenerateKey()
+	require.NoError(t, err)
+
+	return credentials.Key{Keyset: keyset}
+}
@@ -0,0 +1,205 @@
+package cln
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	// Port of the cln-grpc plugin unless configured otherwise
+	defaultRPCPort = "9736"
+
+	// Host name cln-grpc issues its server certificate for
+	serverName = "cln"
+
+	defaultRPCTimeout = 30 * time.Second
+)
+
+var _ lnd.NodeBackend = (*Backend)(nil)
+
+// Config holds the connection details of a Core Lightning node
+type Config struct {
+	// Address of the cln-grpc interface, host:port
+	Address string
+	// PEM encoded CA certificate, client certificate and client key
+	// generated by cln-grpc
+	CACert     []byte
+	ClientCert []byte
+	ClientKey  []byte
+	// Dialer used instead of a direct connection, e.g. through a proxy
+	Dialer func(ctx context.Context, address string) (net.Conn, error)
+	// Timeout for checking the connection, defaults to 30s
+	RPCTimeout time.Duration
+	// Aliases shared with other connections, a new cache if nil
+	Aliases *lnd.AliasCache
+}
+
+// Backend talks to Core Lightning through its gRPC interface
+type Backend struct {
+	conn    *grpc.ClientConn
+	aliases *lnd.AliasCache
+}
+
+// Connect to the node with mutual TLS and check that it responds
+func Connect(config Config) (*Backend, error) {
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM(config.CACert) {
+		return nil, errors.New("invalid CA certificate")
+	}
+
+	clientCert, err := tls.X509KeyPair(config.ClientCert, config.ClientKey)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		RootCAs:      caPool,
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	address := config.Address
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, defaultRPCPort)
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(codec{})),
+	}
+	if config.Dialer != nil {
+		opts = append(opts, grpc.WithContextDialer(config.Dialer))
+	}
+
+	conn, err := grpc.Dial(address, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := config.RPCTimeout
+	if timeout == 0 {
+		timeout = defaultRPCTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	aliases := config.Aliases
+	if aliases == nil {
+		aliases = lnd.NewAliasCache()
+	}
+
+	backend := &Backend{conn: conn, aliases: aliases}
+	if err := backend.call(ctx, "get info", "Getinfo", &getinfoRequest{}, &getinfoResponse{}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return backend, nil
+}
+
+// Call the method of the cln.Node service, wrapping the error in an
+// lnd.RPCError describing the operation
+func (b *Backend) call(ctx context.Context, op, method string, request, response any) error {
+	err := b.conn.Invoke(ctx, "/cln.Node/"+method, request, response)
+	if err != nil {
+		return &lnd.RPCError{Op: op, Err: err}
+	}
+
+	return nil
+}
+
+func (b *Backend) GetNode(ctx context.Context) (lnd.Node, error) {
+	info := &getinfoResponse{}
+	if err := b.call(ctx, "get info", "Getinfo", &getinfoRequest{}, info); err != nil {
+		return lnd.Node{}, err
+	}
+
+	funds := &listfundsResponse{}
+	if err := b.call(ctx, "list funds", "ListFunds", &listfundsRequest{}, funds); err != nil {
+		return lnd.Node{}, err
+	}
+
+	node := lnd.Node{
+		Implementation: lnd.ImplementationCLN,
+		Alias:          info.Alias,
+		PubKey:         hex.EncodeToString(info.ID),
+		Version:        strings.TrimPrefix(info.Version, "v"),
+		Network:        info.Network,
+	}
+
+	// Core Lightning calls mainnet bitcoin
+	if node.Network == "bitcoin" {
+		node.Network = "mainnet"
+	}
+
+	for _, channel := range funds.Channels {
+		if channel.State == stateChanneldNormal {
+			node.ChannelBalance += toSats(channel.OurAmountMsat)
+			node.TotalCapacity += toSats(channel.AmountMsat)
+		}
+	}
+
+	for _, output := range funds.Outputs {
+		if output.Status == outputConfirmed {
+			node.OnChainBalance += toSats(output.AmountMsat)
+		}
+	}
+
+	return node, nil
+}
+
+func (b *Backend) GetNodeAlias(ctx context.Context, pubKey route.Vertex) string {
+	return b.aliases.Get(ctx, pubKey, b.lookupAlias)
+}
+
+func (b *Backend) lookupAlias(ctx context.Context, pubKey route.Vertex) (string, error) {
+	nodes := &listnodesResponse{}
+	if err := b.call(ctx, "list nodes", "ListNodes", &listnodesRequest{ID: pubKey[:]}, nodes); err != nil {
+		return "", err
+	}
+
+	if len(nodes.Nodes) == 0 {
+		return "", nil
+	}
+
+	return nodes.Nodes[0].Alias, nil
+}
+
+// GetPermissions allows all actions, the client certificate of cln-grpc
+// grants full access to the node
+func (b *Backend) GetPermissions(ctx context.Context) lnd.Permissions {
+	return lnd.Permissions{}
+}
+
+func (b *Backend) Close() {
+	b.conn.Close()
+}
+
+func toSats(a *amount) btcutil.Amount {
+	if a == nil {
+		return 0
+	}
+
+	return btcutil.Amount(a.Msat / 1000)
+}
+
+func toMsat(a *amount) uint64 {
+	if a == nil {
+		return 0
+	}
+
+	return a.Msat
+}
@@ -0,0 +1,385 @@
+package cln
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/lightninglabs/lndclient"
+	"github.com/lightningnetwork/lnd/invoices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// Handles a call to the fake node. decode reads the request into a message.
+type handler func(decode func(request any) error) (any, error)
+
+// Reply with the response regardless of the request
+func respond(response any) handler {
+	return func(decode func(request any) error) (any, error) {
+		return response, nil
+	}
+}
+
+// Certificates and keys in PEM encoding
+type testPKI struct {
+	caCert, serverCert, serverKey, clientCert, clientKey []byte
+}
+
+// Issue a certificate signed by the parent, self-signed without one
+func issue(t *testing.T, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (
+	*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return cert, key,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// Certificates like the ones cln-grpc generates
+func newTestPKI(t *testing.T) testPKI {
+	var pki testPKI
+	ca, caKey, caPEM, _ := issue(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "cln Root CA"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+	pki.caCert = caPEM
+
+	_, _, pki.serverCert, pki.serverKey = issue(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "cln grpc Server"},
+		DNSNames:     []string{"cln", "localhost"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+
+	_, _, pki.clientCert, pki.clientKey = issue(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "cln grpc Client"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	return pki
+}
+
+// Start a cln-grpc server requiring client certificates and serving the
+// methods of the cln.Node service with the handlers. Getinfo is always
+// served.
+func startFakeNode(t *testing.T, pki testPKI, handlers map[string]handler) string {
+	serverCert, err := tls.X509KeyPair(pki.serverCert, pki.serverKey)
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(pki.caCert)
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+
+	if _, ok := handlers["Getinfo"]; !ok {
+		handlers["Getinfo"] = respond(&getinfoResponse{Alias: "cln"})
+	}
+
+	server := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.ForceServerCodec(codec{}),
+		grpc.UnknownServiceHandler(func(srv any, stream grpc.ServerStream) error {
+			method, _ := grpc.MethodFromServerStream(stream)
+			h, ok := handlers[method[len("/cln.Node/"):]]
+			if !ok {
+				return status.Error(codes.Unimplemented, method)
+			}
+
+			response, err := h(stream.RecvMsg)
+			if err != nil {
+				return err
+			}
+			return stream.SendMsg(response)
+		}),
+	)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	return listener.Addr().String()
+}
+
+func connectFakeNode(t *testing.T, handlers map[string]handler) *Backend {
+	pki := newTestPKI(t)
+	address := startFakeNode(t, pki, handlers)
+
+	backend, err := Connect(Config{Address: address, CACert: pki.caCert, ClientCert: pki.clientCert, ClientKey: pki.clientKey})
+	require.NoError(t, err)
+	t.Cleanup(backend.Close)
+
+	return backend
+}
+
+func TestConnectRequiresClientCertificate(t *testing.T) {
+	pki := newTestPKI(t)
+	address := startFakeNode(t, pki, map[string]handler{})
+
+	// A client certificate issued by another CA is rejected
+	other := newTestPKI(t)
+	_, err := Connect(Config{Address: address, CACert: pki.caCert, ClientCert: other.clientCert, ClientKey: other.clientKey,
+		RPCTimeout: time.Second})
+	assert.Error(t, err)
+}
+
+func TestGetNode(t *testing.T) {
+	backend := connectFakeNode(t, map[string]handler{
+		"Getinfo": respond(&getinfoResponse{ID: []byte{0x02, 0xab}, Alias: "carol", Version: "v24.02.1", Network: "bitcoin"}),
+		"ListFunds": respond(&listfundsResponse{
+			Outputs: []*listfundsOutput{
+				{AmountMsat: &amount{Msat: 50_000_000}, Status: outputConfirmed},
+				{AmountMsat: &amount{Msat: 10_000_000}},
+			},
+			Channels: []*listfundsChannel{
+				{OurAmountMsat: &amount{Msat: 300_000_000}, AmountMsat: &amount{Msat: 1_000_000_000}, State: stateChanneldNormal},
+				{OurAmountMsat: &amount{Msat: 200_000_000}, AmountMsat: &amount{Msat: 500_000_000}, State: stateOnchain},
+			},
+		}),
+	})
+
+	node, err := backend.GetNode(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "carol", node.Alias)
+	assert.Equal(t, "02ab", node.PubKey)
+	assert.Equal(t, "24.02.1", node.Version)
+	assert.Equal(t, "mainnet", node.Network)
+	assert.Equal(t, btcutil.Amount(300_000), node.ChannelBalance)
+	assert.Equal(t, btcutil.Amount(1_000_000), node.TotalCapacity)
+	assert.Equal(t, btcutil.Amount(50_000), node.OnChainBalance)
+}
+
+func TestChannels(t *testing.T) {
+	peer, _ := hex.DecodeString("03" + "11223344556677889900aabbccddeeff11223344556677889900aabbccddeeff")
+	txid := []byte{0xde, 0xad, 0xbe, 0xef}
+
+	var setChannel setchannelRequest
+	backend := connectFakeNode(t, map[string]handler{
+		"ListPeerChannels": respond(&listpeerchannelsResponse{Channels: []*peerChannel{
+			{PeerID: peer, PeerConnected: true, State: stateChanneldNormal, ShortChannelID: "812345x1024x1",
+				FundingTxid: txid, FundingOutnum: 1, ToUsMsat: &amount{Msat: 400_000_000}, TotalMsat: &amount{Msat: 1_000_000_000}},
+			{PeerID: peer, State: stateChanneldAwaitingLockin, TotalMsat: &amount{Msat: 2_000_000_000}},
+			{PeerID: peer, State: stateAwaitingUnilateral, ToUsMsat: &amount{Msat: 70_000_000}},
+		}}),
+		"ListNodes": respond(&listnodesResponse{Nodes: []*listnodesNode{{NodeID: peer, Alias: "dave"}}}),
+		"SetChannel": func(decode func(any) error) (any, error) {
+			return &setchannelResponse{}, decode(&setChannel)
+		},
+	})
+	ctx := context.Background()
+
+	channels, err := backend.GetChannels(ctx)
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+	assert.Equal(t, "dave", channels[0].Alias)
+	assert.Equal(t, "deadbeef:1", channels[0].Info.ChannelPoint)
+	assert.Equal(t, "812345x1024x1", formatShortChannelID(channels[0].Info.ChannelID))
+	assert.Equal(t, btcutil.Amount(400_000), channels[0].Info.LocalBalance)
+	assert.Equal(t, btcutil.Amount(600_000), channels[0].Info.RemoteBalance)
+	assert.True(t, channels[0].Info.Active)
+
+	pending, err := backend.GetPendingChannels(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "Opening", pending[0].Description())
+	assert.Equal(t, btcutil.Amount(70_000), pending[1].LimboBalance)
+
+	err = backend.UpdateChannelPolicy(ctx, "deadbeef:1", lndclient.PolicyUpdateRequest{BaseFeeMsat: 1000, FeeRate: 0.000250})
+	require.NoError(t, err)
+	assert.Equal(t, "812345x1024x1", setChannel.ID)
+	assert.Equal(t, uint64(1000), setChannel.Feebase.Msat)
+	assert.Equal(t, uint32(250), setChannel.Feeppm)
+	assert.Nil(t, setChannel.Htlcmin)
+
+	assert.Error(t, backend.UpdateChannelPolicy(ctx, "deadbeef:0", lndclient.PolicyUpdateRequest{}))
+}
+
+func TestPayments(t *testing.T) {
+	hash := make([]byte, 32)
+	hash[0] = 1
+	preimage := make([]byte, 32)
+
+	var invoice invoiceRequest
+	var pay payRequest
+	backend := connectFakeNode(t, map[string]handler{
+		"Invoice": func(decode func(any) error) (any, error) {
+			return &invoiceResponse{Bolt11: "lnbc1", PaymentHash: hash}, decode(&invoice)
+		},
+		"Pay": func(decode func(any) error) (any, error) {
+			if err := decode(&pay); err != nil {
+				return nil, err
+			}
+			if pay.Bolt11 == "lnbcfail" {
+				return nil, status.Error(codes.Unknown, "Ran out of routes to try")
+			}
+			return &payResponse{PaymentPreimage: preimage, AmountMsat: &amount{Msat: 21_000_000},
+				AmountSentMsat: &amount{Msat: 21_005_000}}, nil
+		},
+		"ListPays": respond(&listpaysResponse{Pays: []*listpaysPay{
+			{PaymentHash: hash, Status: payComplete, CreatedAt: 1, AmountMsat: &amount{Msat: 1000}},
+			{PaymentHash: hash, Status: payComplete, CreatedAt: 3, AmountMsat: &amount{Msat: 3000}},
+			{PaymentHash: hash, CreatedAt: 4},
+			{PaymentHash: hash, Status: payComplete, CreatedAt: 2, AmountMsat: &amount{Msat: 2000}},
+		}}),
+	})
+	ctx := context.Background()
+
+	paymentHash, paymentRequest, err := backend.CreateInvoice(ctx, "coffee", 21, 3600)
+	require.NoError(t, err)
+	assert.Equal(t, "lnbc1", paymentRequest)
+	assert.Equal(t, hash, paymentHash[:])
+	assert.Equal(t, "coffee", invoice.Description)
+	assert.Equal(t, uint64(21_000), invoice.AmountMsat.Amount.Msat)
+	assert.Equal(t, uint64(3600), invoice.Expiry)
+	assert.NotEmpty(t, invoice.Label)
+
+	result, err := backend.PayInvoice(ctx, "lightning:lnbc1", 10)
+	require.NoError(t, err)
+	assert.Equal(t, "lnbc1", pay.Bolt11)
+	assert.Equal(t, uint64(10_000), pay.Maxfee.Msat)
+	assert.Equal(t, btcutil.Amount(21_000), result.PaidAmt)
+	assert.Equal(t, btcutil.Amount(5), result.PaidFee)
+
+	_, err = backend.PayInvoice(ctx, "lnbcfail", 10)
+	assert.ErrorContains(t, err, "pay invoice: ")
+
+	payments, err := backend.GetPayments(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, payments, 2)
+	assert.Equal(t, "3 sats", payments[0].Title())
+	assert.Equal(t, "2 sats", payments[1].Title())
+	assert.Equal(t, "SUCCEEDED", payments[0].Description())
+}
+
+func TestSubscribeInvoices(t *testing.T) {
+	// Two invoices were paid before the subscription, the third one after
+	paid := []*waitanyinvoiceResponse{
+		{Bolt11: "lnbc1", PayIndex: 1},
+		{Bolt11: "lnbc2", PayIndex: 2},
+		{Bolt11: "lnbc3", PayIndex: 3, AmountReceivedMsat: &amount{Msat: 5000}},
+	}
+
+	// Of 250 invoice updates, the second payment was update 120 and the
+	// later ones expired invoices
+	updates := make([]*listinvoicesInvoice, 250)
+	for i := range updates {
+		updates[i] = &listinvoicesInvoice{UpdatedIndex: uint64(i + 1)}
+	}
+	updates[9].PayIndex, updates[119].PayIndex = 1, 2
+	pages := atomic.Int32{}
+
+	backend := connectFakeNode(t, map[string]handler{
+		"Wait": func(decode func(any) error) (any, error) {
+			var request waitRequest
+			if err := decode(&request); err != nil {
+				return nil, err
+			}
+			if request.Subsystem != subsystemInvoices || request.Indexname != indexUpdated {
+				return nil, status.Error(codes.InvalidArgument, "unexpected index")
+			}
+			return &waitResponse{Updated: uint64(len(updates))}, nil
+		},
+		"ListInvoices": func(decode func(any) error) (any, error) {
+			var request listinvoicesRequest
+			if err := decode(&request); err != nil {
+				return nil, err
+			}
+			if request.Index != indexUpdated || request.Start == 0 || request.Limit > invoicePage {
+				return nil, status.Errorf(codes.InvalidArgument, "unexpected request %+v", request)
+			}
+			pages.Add(1)
+
+			end := min(request.Start-1+uint64(request.Limit), uint64(len(updates)))
+			return &listinvoicesResponse{Invoices: updates[request.Start-1 : end]}, nil
+		},
+		"WaitAnyInvoice": func(decode func(any) error) (any, error) {
+			var request waitanyinvoiceRequest
+			if err := decode(&request); err != nil {
+				return nil, err
+			}
+
+			if request.LastpayIndex != 2 {
+				return nil, status.Errorf(codes.InvalidArgument, "unexpected pay index %d", request.LastpayIndex)
+			}
+			return paid[request.LastpayIndex], nil
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	invoiceUpdates, _, err := backend.SubscribeInvoices(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), pages.Load())
+
+	select {
+	case invoice := <-invoiceUpdates:
+		assert.Equal(t, "lnbc3", invoice.PaymentRequest)
+		assert.Equal(t, invoices.ContractSettled, invoice.State)
+		assert.Equal(t, uint64(5000), uint64(invoice.AmountPaid))
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "no invoice update")
+	}
+}
+
+func TestMessages(t *testing.T) {
+	backend := connectFakeNode(t, map[string]handler{
+		"SignMessage": respond(&signmessageResponse{Zbase: "d6tqaeuonjhi"}),
+		"CheckMessage": func(decode func(any) error) (any, error) {
+			var request checkmessageRequest
+			if err := decode(&request); err != nil {
+				return nil, err
+			}
+			return &checkmessageResponse{Verified: request.Zbase == "d6tqaeuonjhi", Pubkey: []byte{0x02}}, nil
+		},
+	})
+	ctx := context.Background()
+
+	signature, err := backend.SignMessage(ctx, "hello")
+	require.NoError(t, err)
+
+	valid, pubKey, err := backend.VerifyMessage(ctx, "hello", signature)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, "02", pubKey)
+}
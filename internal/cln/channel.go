@@ -0,0 +1,255 @@
+package cln
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/lightninglabs/lndclient"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing/route"
+)
+
+func (b *Backend) listPeerChannels(ctx context.Context) ([]*peerChannel, error) {
+	response := &listpeerchannelsResponse{}
+	err := b.call(ctx, "list channels", "ListPeerChannels", &listpeerchannelsRequest{}, response)
+	return response.Channels, err
+}
+
+// GetChannels lists the open channels of the node along with the aliases of
+// the peers
+func (b *Backend) GetChannels(ctx context.Context) ([]lnd.Channel, error) {
+	peerChannels, err := b.listPeerChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var channels []lnd.Channel
+	var pubKeys []route.Vertex
+	for _, c := range peerChannels {
+		if c.State != stateChanneldNormal && c.State != stateChanneldAwaitingSplice {
+			continue
+		}
+
+		pubKey, err := route.NewVertexFromBytes(c.PeerID)
+		if err != nil {
+			return nil, err
+		}
+
+		capacity := toSats(c.TotalMsat)
+		localBalance := toSats(c.ToUsMsat)
+		info := lndclient.ChannelInfo{
+			ChannelPoint:  fundingOutpoint(c),
+			Active:        c.PeerConnected,
+			ChannelID:     parseShortChannelID(c.ShortChannelID),
+			PubKeyBytes:   pubKey,
+			Capacity:      capacity,
+			LocalBalance:  localBalance,
+			RemoteBalance: capacity - localBalance,
+			Initiator:     c.Opener == sideLocal,
+			Private:       c.Private,
+		}
+		channels = append(channels, lnd.Channel{Info: info})
+		pubKeys = append(pubKeys, pubKey)
+	}
+
+	for i, alias := range b.aliases.Resolve(ctx, "channels", pubKeys, b.lookupAlias) {
+		channels[i].Alias = alias
+	}
+
+	return channels, nil
+}
+
+// GetPendingChannels lists channels being opened or closed. Channels of
+// mutual and unilateral closes share their states once the closing
+// transaction is seen, only those waiting for a unilateral close are
+// reported as force closes.
+func (b *Backend) GetPendingChannels(ctx context.Context) ([]lnd.PendingChannel, error) {
+	peerChannels, err := b.listPeerChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pendingChannels []lnd.PendingChannel
+	var pubKeys []route.Vertex
+	for _, c := range peerChannels {
+		pending := lnd.PendingChannel{
+			Capacity:     toSats(c.TotalMsat),
+			LocalBalance: toSats(c.ToUsMsat),
+		}
+
+		switch c.State {
+		case stateOpeningd, stateChanneldAwaitingLockin, stateDualopendOpenInit, stateDualopendAwaitingLockin:
+			pending.Type = lnd.PendingOpen
+		case stateChanneldShuttingDown, stateClosingdSigexchange, stateClosingdComplete,
+			stateFundingSpendSeen, stateOnchain:
+			pending.Type = lnd.CooperativeClosure
+		case stateAwaitingUnilateral:
+			pending.Type = lnd.ForceClosure
+			pending.LimboBalance = pending.LocalBalance
+		default:
+			continue
+		}
+
+		// Peers with a malformed ID keep an empty alias
+		pubKey, _ := route.NewVertexFromBytes(c.PeerID)
+		pendingChannels = append(pendingChannels, pending)
+		pubKeys = append(pubKeys, pubKey)
+	}
+
+	for i, alias := range b.aliases.Resolve(ctx, "pending channels", pubKeys, b.lookupAlias) {
+		pendingChannels[i].Alias = alias
+	}
+
+	return pendingChannels, nil
+}
+
+// GetChannelEdge returns the channel as announced in the gossip, Node1 being
+// the node with the lower public key
+func (b *Backend) GetChannelEdge(ctx context.Context, channelID uint64) (*lndclient.ChannelEdge, error) {
+	request := &listchannelsRequest{ShortChannelID: formatShortChannelID(channelID)}
+	response := &listchannelsResponse{}
+	if err := b.call(ctx, "get channel info", "ListChannels", request, response); err != nil {
+		return nil, err
+	}
+
+	if len(response.Channels) == 0 {
+		return nil, &lnd.RPCError{Op: "get channel info", Err: errors.New("channel not found in gossip")}
+	}
+
+	edge := &lndclient.ChannelEdge{ChannelID: channelID}
+	for _, c := range response.Channels {
+		source, err := route.NewVertexFromBytes(c.Source)
+		if err != nil {
+			return nil, err
+		}
+
+		policy := &lndclient.RoutingPolicy{
+			TimeLockDelta:    c.Delay,
+			MinHtlcMsat:      int64(toMsat(c.HtlcMinimumMsat)),
+			MaxHtlcMsat:      toMsat(c.HtlcMaximumMsat),
+			FeeBaseMsat:      int64(c.BaseFeeMsat),
+			FeeRateMilliMsat: int64(c.FeePerMillionth),
+			Disabled:         !c.Active,
+			LastUpdate:       time.Unix(int64(c.LastUpdate), 0),
+		}
+
+		edge.Capacity = toSats(c.AmountMsat)
+		if c.Direction == 0 {
+			edge.Node1, edge.Node1Policy = source, policy
+		} else {
+			edge.Node2, edge.Node2Policy = source, policy
+		}
+	}
+
+	return edge, nil
+}
+
+// UpdateChannelPolicy sets the fees and HTLC limits of the channel. Core
+// Lightning configures the time lock delta for all channels, so it is not
+// changed.
+func (b *Backend) UpdateChannelPolicy(ctx context.Context, channelPoint string, policy lndclient.PolicyUpdateRequest) error {
+	id, err := b.channelID(ctx, channelPoint)
+	if err != nil {
+		return err
+	}
+
+	request := &setchannelRequest{
+		ID:      id,
+		Feebase: &amount{Msat: uint64(policy.BaseFeeMsat)},
+		Feeppm:  uint32(math.Round(policy.FeeRate * 1e6)),
+	}
+	if policy.MinHtlcMsatSpecified {
+		request.Htlcmin = &amount{Msat: policy.MinHtlcMsat}
+	}
+	if policy.MaxHtlcMsat > 0 {
+		request.Htlcmax = &amount{Msat: policy.MaxHtlcMsat}
+	}
+
+	return b.call(ctx, "update channel policy", "SetChannel", request, &setchannelResponse{})
+}
+
+// CloseChannel closes the channel in the background. A force close gives the
+// peer a second to agree to a mutual close before closing unilaterally.
+// Core Lightning picks the fee of mutual closes itself, so targetBlocks is
+// not used. The updates end once the closing transaction was broadcast.
+func (b *Backend) CloseChannel(ctx context.Context, channelPoint string, force bool, targetBlocks int32) (
+	<-chan lndclient.CloseChannelUpdate, <-chan error, error) {
+
+	id, err := b.channelID(ctx, channelPoint)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	request := &closeRequest{ID: id}
+	if force {
+		request.Unilateraltimeout = 1
+	}
+
+	updates := make(chan lndclient.CloseChannelUpdate, 1)
+	errs := make(chan error, 1)
+	go func() {
+		response := &closeResponse{}
+		if err := b.call(ctx, "close channel", "Close", request, response); err != nil {
+			errs <- err
+			return
+		}
+
+		// Transaction IDs are sent in display order
+		txid, err := chainhash.NewHashFromStr(hex.EncodeToString(response.Txid))
+		if err != nil {
+			errs <- err
+			return
+		}
+		updates <- &lndclient.PendingCloseUpdate{CloseTx: *txid}
+		close(updates)
+	}()
+
+	return updates, errs, nil
+}
+
+// Find the ID Core Lightning knows the channel with the funding outpoint by
+func (b *Backend) channelID(ctx context.Context, channelPoint string) (string, error) {
+	peerChannels, err := b.listPeerChannels(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	for _, c := range peerChannels {
+		if fundingOutpoint(c) != channelPoint {
+			continue
+		}
+
+		if c.ShortChannelID != "" {
+			return c.ShortChannelID, nil
+		}
+		return hex.EncodeToString(c.ChannelID), nil
+	}
+
+	return "", errors.New("no channel with channel point " + channelPoint)
+}
+
+// The funding outpoint formatted like lnd's channel points
+func fundingOutpoint(c *peerChannel) string {
+	return fmt.Sprintf("%x:%d", c.FundingTxid, c.FundingOutnum)
+}
+
+// Parse a short channel ID such as 812345x1024x1, zero if it is malformed
+func parseShortChannelID(s string) uint64 {
+	var scid lnwire.ShortChannelID
+	if _, err := fmt.Sscanf(s, "%dx%dx%d", &scid.BlockHeight, &scid.TxIndex, &scid.TxPosition); err != nil {
+		return 0
+	}
+
+	return scid.ToUint64()
+}
+
+func formatShortChannelID(channelID uint64) string {
+	scid := lnwire.NewShortChanIDFromInt(channelID)
+	return fmt.Sprintf("%dx%dx%d", scid.BlockHeight, scid.TxIndex, scid.TxPosition)
+}
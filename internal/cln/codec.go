@@ -0,0 +1,234 @@
+package cln
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// codec encodes the cln-grpc messages declared in messages.go. Instead of
+// generated code, struct fields carry their protobuf field number in a pb tag
+// and the wire type follows from the Go type:
+//
+//	string, []byte, *struct, []*struct, []string  length delimited
+//	bool, uint32, uint64, int32 (enums)           varint
+//	float64                                       fixed64
+//
+// Zero values are not sent, unknown fields are skipped when decoding. Fields
+// with presence, such as an optional zero timeout, are pointers to scalars.
+// Fields of other types fail to encode and decode.
+type codec struct{}
+
+func (codec) Name() string {
+	return "proto"
+}
+
+func (codec) Marshal(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot marshal %T", v)
+	}
+
+	return appendMessage(nil, rv.Elem())
+}
+
+func (codec) Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot unmarshal into %T", v)
+	}
+
+	return unmarshalMessage(data, rv.Elem())
+}
+
+// Field number of the struct field, zero for fields without a pb tag
+func fieldNumber(f reflect.StructField) protowire.Number {
+	n, err := strconv.Atoi(f.Tag.Get("pb"))
+	if err != nil {
+		return 0
+	}
+
+	return protowire.Number(n)
+}
+
+func appendMessage(b []byte, v reflect.Value) ([]byte, error) {
+	var err error
+	for i := 0; i < v.NumField(); i++ {
+		num := fieldNumber(v.Type().Field(i))
+		if num == 0 {
+			continue
+		}
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+			for j := 0; j < field.Len() && err == nil; j++ {
+				b, err = appendField(b, num, field.Index(j))
+			}
+		} else if !field.IsZero() {
+			b, err = appendField(b, num, field)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s field %d: %w", v.Type().Name(), num, err)
+		}
+	}
+
+	return b, nil
+}
+
+func appendField(b []byte, num protowire.Number, v reflect.Value) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.String:
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendString(b, v.String()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			break
+		}
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, v.Bytes()), nil
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil, errors.New("nil element of repeated field")
+		}
+		if v.Elem().Kind() != reflect.Struct {
+			return appendField(b, num, v.Elem())
+		}
+		msg, err := appendMessage(nil, v.Elem())
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, msg), nil
+	case reflect.Bool:
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, protowire.EncodeBool(v.Bool())), nil
+	case reflect.Uint32, reflect.Uint64:
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, v.Uint()), nil
+	case reflect.Int32:
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, uint64(v.Int())), nil
+	case reflect.Float64:
+		b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+		return protowire.AppendFixed64(b, math.Float64bits(v.Float())), nil
+	}
+
+	return nil, fmt.Errorf("unsupported field type %s", v.Type())
+}
+
+func unmarshalMessage(b []byte, v reflect.Value) error {
+	fields := make(map[protowire.Number]int)
+	for i := 0; i < v.NumField(); i++ {
+		if num := fieldNumber(v.Type().Field(i)); num != 0 {
+			fields[num] = i
+		}
+	}
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		i, known := fields[num]
+		if !known {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+
+		n, err := unmarshalField(b, typ, v.Field(i))
+		if err != nil {
+			return fmt.Errorf("%s field %d: %w", v.Type().Name(), num, err)
+		}
+		b = b[n:]
+	}
+
+	return nil
+}
+
+// Decode the value into the field and return the number of bytes consumed
+func unmarshalField(b []byte, typ protowire.Type, field reflect.Value) (int, error) {
+	kind := field.Kind()
+	if kind == reflect.Pointer && field.Type().Elem().Kind() != reflect.Struct {
+		value := reflect.New(field.Type().Elem())
+		n, err := unmarshalField(b, typ, value.Elem())
+		if err == nil {
+			field.Set(value)
+		}
+		return n, err
+	}
+
+	errWireType := fmt.Errorf("unexpected wire type %d for %s", typ, field.Type())
+
+	switch typ {
+	case protowire.VarintType:
+		x, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+
+		switch kind {
+		case reflect.Bool:
+			field.SetBool(protowire.DecodeBool(x))
+		case reflect.Uint32, reflect.Uint64:
+			field.SetUint(x)
+		case reflect.Int32:
+			field.SetInt(int64(int32(x)))
+		default:
+			return 0, errWireType
+		}
+		return n, nil
+
+	case protowire.Fixed64Type:
+		x, n := protowire.ConsumeFixed64(b)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+
+		if kind != reflect.Float64 {
+			return 0, errWireType
+		}
+		field.SetFloat(math.Float64frombits(x))
+		return n, nil
+
+	case protowire.BytesType:
+		data, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+
+		switch {
+		case kind == reflect.String:
+			field.SetString(string(data))
+		case kind == reflect.Slice && field.Type().Elem().Kind() == reflect.Uint8:
+			field.SetBytes(append([]byte(nil), data...))
+		case kind == reflect.Slice:
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if _, err := unmarshalField(b, typ, elem); err != nil {
+				return 0, err
+			}
+			field.Set(reflect.Append(field, elem))
+		case kind == reflect.Pointer:
+			msg := reflect.New(field.Type().Elem())
+			if err := unmarshalMessage(data, msg.Elem()); err != nil {
+				return 0, err
+			}
+			field.Set(msg)
+		default:
+			return 0, errWireType
+		}
+		return n, nil
+	}
+
+	return 0, errWireType
+}
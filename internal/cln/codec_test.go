@@ -0,0 +1,359 @@
+package cln
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Declare a field of node.proto. Messages and enums are referred to by name.
+func field(name string, number int32, typ any) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+
+	switch typ := typ.(type) {
+	case descriptorpb.FieldDescriptorProto_Type:
+		f.Type = typ.Enum()
+	case enumName:
+		f.Type = descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum()
+		f.TypeName = proto.String(".cln." + string(typ))
+	case string:
+		f.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+		f.TypeName = proto.String(".cln." + typ)
+	}
+
+	return f
+}
+
+type enumName string
+
+func repeated(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+	f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	return f
+}
+
+func message(name string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	return &descriptorpb.DescriptorProto{Name: proto.String(name), Field: fields}
+}
+
+func enum(name string, values ...string) *descriptorpb.EnumDescriptorProto {
+	e := &descriptorpb.EnumDescriptorProto{Name: proto.String(name)}
+	for i, value := range values {
+		e.Value = append(e.Value, &descriptorpb.EnumValueDescriptorProto{
+			Name:   proto.String(value),
+			Number: proto.Int32(int32(i)),
+		})
+	}
+
+	return e
+}
+
+const (
+	typeString = descriptorpb.FieldDescriptorProto_TYPE_STRING
+	typeBytes  = descriptorpb.FieldDescriptorProto_TYPE_BYTES
+	typeBool   = descriptorpb.FieldDescriptorProto_TYPE_BOOL
+	typeUint32 = descriptorpb.FieldDescriptorProto_TYPE_UINT32
+	typeUint64 = descriptorpb.FieldDescriptorProto_TYPE_UINT64
+)
+
+// Descriptors of the messages the backend uses, named as in cln-grpc's
+// node.proto. They are declared with the field numbers of messages.go rather
+// than read from node.proto, so they only check that the codec encodes the
+// field types like the protobuf runtime, not that the numbers are right.
+func referenceDescriptors(t *testing.T) protoreflect.FileDescriptor {
+	amountOrAny := message("AmountOrAny",
+		field("amount", 1, "Amount"),
+		field("any", 2, typeBool))
+	amountOrAny.OneofDecl = []*descriptorpb.OneofDescriptorProto{{Name: proto.String("value")}}
+	for _, f := range amountOrAny.Field {
+		f.OneofIndex = proto.Int32(0)
+	}
+
+	// Enums declared within their message, their values would clash otherwise
+	waitRequest := message("WaitRequest",
+		field("subsystem", 1, enumName("WaitRequest.WaitSubsystem")),
+		field("indexname", 2, enumName("WaitRequest.WaitIndexname")),
+		field("nextvalue", 3, typeUint64))
+	waitRequest.EnumType = []*descriptorpb.EnumDescriptorProto{
+		enum("WaitSubsystem", "INVOICES", "FORWARDS", "SENDPAYS"),
+		enum("WaitIndexname", "CREATED", "UPDATED", "DELETED"),
+	}
+	listinvoicesRequest := message("ListinvoicesRequest",
+		field("index", 5, enumName("ListinvoicesRequest.ListinvoicesIndex")),
+		field("start", 6, typeUint64),
+		field("limit", 7, typeUint32))
+	listinvoicesRequest.EnumType = []*descriptorpb.EnumDescriptorProto{
+		enum("ListinvoicesIndex", "CREATED", "UPDATED"),
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("node.proto"),
+		Package: proto.String("cln"),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{
+			enum("ChannelState", "Openingd", "ChanneldAwaitingLockin", "ChanneldNormal",
+				"ChanneldShuttingDown", "ClosingdSigexchange", "ClosingdComplete", "AwaitingUnilateral",
+				"FundingSpendSeen", "Onchain", "DualopendOpenInit", "DualopendAwaitingLockin",
+				"ChanneldAwaitingSplice"),
+			enum("ChannelSide", "LOCAL", "REMOTE"),
+			enum("ListfundsOutputsStatus", "UNCONFIRMED", "CONFIRMED", "SPENT", "IMMATURE"),
+			enum("ListpaysPaysStatus", "PENDING", "FAILED", "COMPLETE"),
+		},
+		MessageType: []*descriptorpb.DescriptorProto{
+			message("Amount", field("msat", 1, typeUint64)),
+			amountOrAny,
+			message("GetinfoResponse",
+				field("id", 1, typeBytes),
+				field("alias", 2, typeString),
+				field("version", 8, typeString),
+				field("network", 12, typeString)),
+			message("ListfundsOutputs",
+				field("amount_msat", 3, "Amount"),
+				field("status", 7, enumName("ListfundsOutputsStatus"))),
+			message("ListfundsChannels",
+				field("our_amount_msat", 2, "Amount"),
+				field("amount_msat", 3, "Amount"),
+				field("state", 7, enumName("ChannelState"))),
+			message("ListfundsResponse",
+				repeated(field("outputs", 1, "ListfundsOutputs")),
+				repeated(field("channels", 2, "ListfundsChannels"))),
+			message("ListpeerchannelsChannels",
+				field("peer_id", 1, typeBytes),
+				field("peer_connected", 2, typeBool),
+				field("state", 3, enumName("ChannelState")),
+				field("short_channel_id", 8, typeString),
+				field("channel_id", 9, typeBytes),
+				field("funding_txid", 10, typeBytes),
+				field("funding_outnum", 11, typeUint32),
+				field("private", 18, typeBool),
+				field("opener", 19, enumName("ChannelSide")),
+				field("to_us_msat", 23, "Amount"),
+				field("total_msat", 26, "Amount")),
+			message("ListpeerchannelsResponse",
+				repeated(field("channels", 1, "ListpeerchannelsChannels"))),
+			message("ListchannelsChannels",
+				field("source", 1, typeBytes),
+				field("destination", 2, typeBytes),
+				field("short_channel_id", 3, typeString),
+				field("amount_msat", 5, "Amount"),
+				field("active", 8, typeBool),
+				field("last_update", 9, typeUint32),
+				field("base_fee_millisatoshi", 10, typeUint32),
+				field("fee_per_millionth", 11, typeUint32),
+				field("delay", 12, typeUint32),
+				field("htlc_minimum_msat", 13, "Amount"),
+				field("htlc_maximum_msat", 14, "Amount"),
+				field("direction", 16, typeUint32)),
+			message("ListchannelsResponse",
+				repeated(field("channels", 1, "ListchannelsChannels"))),
+			message("SetchannelRequest",
+				field("id", 1, typeString),
+				field("feebase", 2, "Amount"),
+				field("feeppm", 3, typeUint32),
+				field("htlcmin", 4, "Amount"),
+				field("htlcmax", 5, "Amount")),
+			message("CloseRequest",
+				field("id", 1, typeString),
+				field("unilateraltimeout", 2, typeUint32)),
+			message("ListpaysPays",
+				field("payment_hash", 1, typeBytes),
+				field("status", 2, enumName("ListpaysPaysStatus")),
+				field("created_at", 4, typeUint64),
+				field("bolt11", 6, typeString),
+				field("amount_msat", 8, "Amount"),
+				field("amount_sent_msat", 9, "Amount"),
+				field("preimage", 13, typeBytes)),
+			message("ListpaysResponse",
+				repeated(field("pays", 1, "ListpaysPays"))),
+			message("InvoiceRequest",
+				field("description", 2, typeString),
+				field("label", 3, typeString),
+				field("expiry", 7, typeUint64),
+				field("amount_msat", 10, "AmountOrAny")),
+			message("PayRequest",
+				field("bolt11", 1, typeString),
+				field("maxfee", 11, "Amount")),
+			message("WaitanyinvoiceResponse",
+				field("label", 1, typeString),
+				field("description", 2, typeString),
+				field("payment_hash", 3, typeBytes),
+				field("amount_msat", 6, "Amount"),
+				field("bolt11", 7, typeString),
+				field("pay_index", 9, typeUint64),
+				field("amount_received_msat", 10, "Amount"),
+				field("paid_at", 11, typeUint64),
+				field("payment_preimage", 12, typeBytes)),
+			waitRequest,
+			message("WaitResponse",
+				field("updated", 3, typeUint64)),
+			listinvoicesRequest,
+			message("ListinvoicesInvoices",
+				field("pay_index", 9, typeUint64),
+				field("updated_index", 17, typeUint64)),
+			message("ListinvoicesResponse",
+				repeated(field("invoices", 1, "ListinvoicesInvoices"))),
+			message("CheckmessageResponse",
+				field("verified", 1, typeBool),
+				field("pubkey", 2, typeBytes)),
+		},
+	}
+
+	fd, err := protodesc.NewFile(file, nil)
+	require.NoError(t, err)
+	return fd
+}
+
+func TestCodecMatchesProtobufEncoding(t *testing.T) {
+	fd := referenceDescriptors(t)
+
+	tests := []struct {
+		message string
+		// The message in the JSON mapping of protobuf, bytes in base64
+		json string
+		msg  any
+	}{
+		{"GetinfoResponse",
+			`{"id": "AgM=", "alias": "cln", "version": "v24.02", "network": "bitcoin"}`,
+			&getinfoResponse{ID: []byte{2, 3}, Alias: "cln", Version: "v24.02", Network: "bitcoin"}},
+		{"ListfundsResponse",
+			`{"outputs": [{"amount_msat": {"msat": 5000000}, "status": "CONFIRMED"}, {"status": "SPENT"}],
+			  "channels": [{"our_amount_msat": {"msat": 1000}, "amount_msat": {"msat": 300000000}, "state": "ChanneldNormal"}]}`,
+			&listfundsResponse{
+				Outputs: []*listfundsOutput{
+					{AmountMsat: &amount{Msat: 5_000_000}, Status: outputConfirmed},
+					{Status: 2},
+				},
+				Channels: []*listfundsChannel{
+					{OurAmountMsat: &amount{Msat: 1000}, AmountMsat: &amount{Msat: 300_000_000}, State: stateChanneldNormal},
+				},
+			}},
+		{"ListpeerchannelsResponse",
+			`{"channels": [{"peer_id": "Ag==", "peer_connected": true, "state": "ChanneldNormal",
+			  "short_channel_id": "103x1x0", "channel_id": "AQ==", "funding_txid": "Aw==", "funding_outnum": 1,
+			  "private": true, "opener": "REMOTE", "to_us_msat": {"msat": 5000}, "total_msat": {"msat": 10000}},
+			  {"state": "Onchain"}]}`,
+			&listpeerchannelsResponse{Channels: []*peerChannel{
+				{PeerID: []byte{2}, PeerConnected: true, State: stateChanneldNormal, ShortChannelID: "103x1x0",
+					ChannelID: []byte{1}, FundingTxid: []byte{3}, FundingOutnum: 1, Private: true, Opener: 1,
+					ToUsMsat: &amount{Msat: 5000}, TotalMsat: &amount{Msat: 10_000}},
+				{State: stateOnchain},
+			}}},
+		{"ListchannelsResponse",
+			`{"channels": [{"source": "Ag==", "destination": "Aw==", "short_channel_id": "103x1x0",
+			  "amount_msat": {"msat": 10000}, "active": true, "last_update": 1700000000, "base_fee_millisatoshi": 1000,
+			  "fee_per_millionth": 10, "delay": 80, "htlc_minimum_msat": {"msat": 1},
+			  "htlc_maximum_msat": {"msat": 9900}, "direction": 1}]}`,
+			&listchannelsResponse{Channels: []*gossipChannel{{
+				Source: []byte{2}, Destination: []byte{3}, ShortChannelID: "103x1x0", AmountMsat: &amount{Msat: 10_000},
+				Active: true, LastUpdate: 1_700_000_000, BaseFeeMsat: 1000, FeePerMillionth: 10, Delay: 80,
+				HtlcMinimumMsat: &amount{Msat: 1}, HtlcMaximumMsat: &amount{Msat: 9900}, Direction: 1,
+			}}}},
+		{"SetchannelRequest",
+			`{"id": "103x1x0", "feebase": {"msat": 1000}, "feeppm": 10, "htlcmin": {"msat": 1}, "htlcmax": {"msat": 9900}}`,
+			&setchannelRequest{ID: "103x1x0", Feebase: &amount{Msat: 1000}, Feeppm: 10,
+				Htlcmin: &amount{Msat: 1}, Htlcmax: &amount{Msat: 9900}}},
+		{"CloseRequest",
+			`{"id": "103x1x0", "unilateraltimeout": 1}`,
+			&closeRequest{ID: "103x1x0", Unilateraltimeout: 1}},
+		{"ListpaysResponse",
+			`{"pays": [{"payment_hash": "BA==", "status": "COMPLETE", "created_at": 1700000000, "bolt11": "lnbc1",
+			  "amount_msat": {"msat": 3000}, "amount_sent_msat": {"msat": 3001}, "preimage": "BQ=="}]}`,
+			&listpaysResponse{Pays: []*listpaysPay{{
+				PaymentHash: []byte{4}, Status: payComplete, CreatedAt: 1_700_000_000, Bolt11: "lnbc1",
+				AmountMsat: &amount{Msat: 3000}, AmountSentMsat: &amount{Msat: 3001}, Preimage: []byte{5},
+			}}}},
+		{"InvoiceRequest",
+			`{"description": "coffee", "label": "flash-01", "expiry": 3600, "amount_msat": {"amount": {"msat": 21000}}}`,
+			&invoiceRequest{Description: "coffee", Label: "flash-01", Expiry: 3600,
+				AmountMsat: &amountOrAny{Amount: &amount{Msat: 21_000}}}},
+		{"InvoiceRequest",
+			`{"label": "flash-02", "amount_msat": {"any": true}}`,
+			&invoiceRequest{Label: "flash-02", AmountMsat: &amountOrAny{Any: true}}},
+		{"PayRequest",
+			`{"bolt11": "lnbc1", "maxfee": {"msat": 20000}}`,
+			&payRequest{Bolt11: "lnbc1", Maxfee: &amount{Msat: 20_000}}},
+		{"WaitanyinvoiceResponse",
+			`{"label": "flash-01", "description": "coffee", "payment_hash": "BA==", "amount_msat": {"msat": 21000},
+			  "bolt11": "lnbc1", "pay_index": 3, "amount_received_msat": {"msat": 21000}, "paid_at": 1700000000,
+			  "payment_preimage": "BQ=="}`,
+			&waitanyinvoiceResponse{Label: "flash-01", Description: "coffee", PaymentHash: []byte{4},
+				AmountMsat: &amount{Msat: 21_000}, Bolt11: "lnbc1", PayIndex: 3,
+				AmountReceivedMsat: &amount{Msat: 21_000}, PaidAt: 1_700_000_000, PaymentPreimage: []byte{5}}},
+		{"WaitRequest",
+			`{"subsystem": "INVOICES", "indexname": "UPDATED", "nextvalue": 0}`,
+			&waitRequest{Subsystem: subsystemInvoices, Indexname: indexUpdated}},
+		{"WaitResponse",
+			`{"updated": 250}`,
+			&waitResponse{Updated: 250}},
+		{"ListinvoicesRequest",
+			`{"index": "UPDATED", "start": 151, "limit": 100}`,
+			&listinvoicesRequest{Index: indexUpdated, Start: 151, Limit: 100}},
+		{"ListinvoicesResponse",
+			`{"invoices": [{"pay_index": 2, "updated_index": 120}, {}, {"pay_index": 1}]}`,
+			&listinvoicesResponse{Invoices: []*listinvoicesInvoice{{PayIndex: 2, UpdatedIndex: 120}, {}, {PayIndex: 1}}}},
+		{"CheckmessageResponse",
+			`{"verified": true, "pubkey": "Ag=="}`,
+			&checkmessageResponse{Verified: true, Pubkey: []byte{2}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.message, func(t *testing.T) {
+			md := fd.Messages().ByName(protoreflect.Name(test.message))
+			require.NotNil(t, md)
+			reference := dynamicpb.NewMessage(md)
+			require.NoError(t, protojson.Unmarshal([]byte(test.json), reference))
+			golden, err := proto.MarshalOptions{Deterministic: true}.Marshal(reference)
+			require.NoError(t, err)
+
+			data, err := codec{}.Marshal(test.msg)
+			require.NoError(t, err)
+			assert.Equal(t, golden, data)
+
+			decoded := reflect.New(reflect.TypeOf(test.msg).Elem()).Interface()
+			require.NoError(t, codec{}.Unmarshal(golden, decoded))
+			assert.Equal(t, test.msg, decoded)
+		})
+	}
+}
+
+func TestCodec(t *testing.T) {
+	// Fields with presence send their zero value
+	type request struct {
+		Index   uint64  `pb:"1"`
+		Timeout *uint64 `pb:"2"`
+	}
+	timeout := uint64(0)
+
+	data, err := codec{}.Marshal(&request{Timeout: &timeout})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x10, 0x00}, data)
+
+	var decoded request
+	require.NoError(t, codec{}.Unmarshal(data, &decoded))
+	assert.Equal(t, uint64(0), *decoded.Timeout)
+
+	// Unknown fields are skipped, fields of the wrong type rejected
+	var info getinfoResponse
+	require.NoError(t, codec{}.Unmarshal([]byte{0x18, 0x05, 0x12, 0x01, 'a'}, &info))
+	assert.Equal(t, "a", info.Alias)
+	assert.Error(t, codec{}.Unmarshal([]byte{0x10, 0x05}, &info))
+
+	// Types without a wire type fail to encode
+	_, err = codec{}.Marshal(&struct {
+		Index int64 `pb:"1"`
+	}{Index: 1})
+	assert.Error(t, err)
+	_, err = codec{}.Marshal(&listpaysResponse{Pays: []*listpaysPay{nil}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,220 @@
+package cln
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/lightninglabs/lndclient"
+	"github.com/lightningnetwork/lnd/invoices"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing/route"
+)
+
+// CreateInvoice creates an invoice under a random label, Core Lightning
+// requires every invoice to have a unique one. An amount of zero creates an
+// invoice for any amount.
+func (b *Backend) CreateInvoice(ctx context.Context, memo string, satsAmount uint64, expiry int64) (lntypes.Hash, string, error) {
+	label := make([]byte, 16)
+	if _, err := rand.Read(label); err != nil {
+		return lntypes.Hash{}, "", err
+	}
+
+	request := &invoiceRequest{
+		Description: memo,
+		Label:       "flash-" + hex.EncodeToString(label),
+		Expiry:      uint64(expiry),
+		AmountMsat:  &amountOrAny{Any: true},
+	}
+	if satsAmount > 0 {
+		request.AmountMsat = &amountOrAny{Amount: &amount{Msat: satsAmount * 1000}}
+	}
+
+	response := &invoiceResponse{}
+	if err := b.call(ctx, "add invoice", "Invoice", request, response); err != nil {
+		return lntypes.Hash{}, "", err
+	}
+
+	hash, err := lntypes.MakeHash(response.PaymentHash)
+	return hash, response.Bolt11, err
+}
+
+func (b *Backend) PayInvoice(ctx context.Context, invoice string, maxFee btcutil.Amount) (lndclient.PaymentResult, error) {
+	request := &payRequest{
+		Bolt11: lnd.SantizeBoltInvoice(invoice),
+		Maxfee: &amount{Msat: uint64(maxFee) * 1000},
+	}
+
+	response := &payResponse{}
+	if err := b.call(ctx, "pay invoice", "Pay", request, response); err != nil {
+		return lndclient.PaymentResult{Err: err}, err
+	}
+
+	preimage, err := lntypes.MakePreimage(response.PaymentPreimage)
+	if err != nil {
+		return lndclient.PaymentResult{Err: err}, err
+	}
+
+	return lndclient.PaymentResult{
+		Preimage: preimage,
+		PaidAmt:  toSats(response.AmountMsat),
+		PaidFee:  btcutil.Amount((toMsat(response.AmountSentMsat) - toMsat(response.AmountMsat)) / 1000),
+	}, nil
+}
+
+func (b *Backend) DecodeInvoice(ctx context.Context, invoice string) (*lndclient.PaymentRequest, error) {
+	response := &decodepayResponse{}
+	request := &decodepayRequest{Bolt11: lnd.SantizeBoltInvoice(invoice)}
+	if err := b.call(ctx, "decode invoice", "DecodePay", request, response); err != nil {
+		return nil, err
+	}
+
+	destination, err := route.NewVertexFromBytes(response.Payee)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := lntypes.MakeHash(response.PaymentHash)
+	if err != nil {
+		return nil, err
+	}
+
+	createdAt := time.Unix(int64(response.CreatedAt), 0)
+	paymentRequest := &lndclient.PaymentRequest{
+		Destination: destination,
+		Hash:        hash,
+		Value:       lnwire.MilliSatoshi(toMsat(response.AmountMsat)),
+		Timestamp:   createdAt,
+		Expiry:      createdAt.Add(time.Duration(response.Expiry) * time.Second),
+		Description: response.Description,
+	}
+	copy(paymentRequest.PaymentAddress[:], response.PaymentSecret)
+
+	return paymentRequest, nil
+}
+
+// SubscribeInvoices reports invoices as they are paid. Core Lightning has no
+// stream of invoice updates, so it waits for one payment after another,
+// starting after the invoices paid before the subscription.
+func (b *Backend) SubscribeInvoices(ctx context.Context) (<-chan *lndclient.Invoice, <-chan error, error) {
+	lastPayIndex, err := b.lastPayIndex(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	invoiceUpdates := make(chan *lndclient.Invoice)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			paid := &waitanyinvoiceResponse{}
+			request := &waitanyinvoiceRequest{LastpayIndex: lastPayIndex}
+			if err := b.call(ctx, "subscribe invoices", "WaitAnyInvoice", request, paid); err != nil {
+				if ctx.Err() == nil {
+					errs <- err
+				}
+				return
+			}
+			lastPayIndex = paid.PayIndex
+
+			select {
+			case invoiceUpdates <- paidInvoice(paid):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return invoiceUpdates, errs, nil
+}
+
+// SubscribeEvents reports paid invoices as balance changes. cln-grpc has no
+// streams of channel and HTLC events, the dashboard picks those up when it
+// refreshes.
+func (b *Backend) SubscribeEvents(ctx context.Context) (<-chan lnd.NodeEvent, <-chan error, error) {
+	invoiceUpdates, errs, err := b.SubscribeInvoices(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := make(chan lnd.NodeEvent)
+	go func() {
+		for {
+			select {
+			case <-invoiceUpdates:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case events <- lnd.NodeEvent{Kind: lnd.BalanceChanged}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, errs, nil
+}
+
+// Invoices read at a time while looking for the last paid one
+const invoicePage = 100
+
+// Find the pay index of the last paid invoice. Invoices get a new updated
+// index when they are paid, so it is the paid invoice updated last. The
+// invoices are read in pages from the last update backwards.
+func (b *Backend) lastPayIndex(ctx context.Context) (uint64, error) {
+	// Waiting for the next value 0 returns the current index right away
+	wait := &waitResponse{}
+	request := &waitRequest{Subsystem: subsystemInvoices, Indexname: indexUpdated}
+	if err := b.call(ctx, "list invoices", "Wait", request, wait); err != nil {
+		return 0, err
+	}
+
+	for end := wait.Updated; end > 0; {
+		start := uint64(1)
+		if end > invoicePage {
+			start = end - invoicePage + 1
+		}
+
+		response := &listinvoicesResponse{}
+		request := &listinvoicesRequest{Index: indexUpdated, Start: start, Limit: uint32(end - start + 1)}
+		if err := b.call(ctx, "list invoices", "ListInvoices", request, response); err != nil {
+			return 0, err
+		}
+
+		var index uint64
+		for _, invoice := range response.Invoices {
+			index = max(index, invoice.PayIndex)
+		}
+		if index > 0 {
+			return index, nil
+		}
+
+		end = start - 1
+	}
+
+	return 0, nil
+}
+
+func paidInvoice(paid *waitanyinvoiceResponse) *lndclient.Invoice {
+	invoice := &lndclient.Invoice{
+		Memo:           paid.Description,
+		PaymentRequest: paid.Bolt11,
+		Amount:         lnwire.MilliSatoshi(toMsat(paid.AmountMsat)),
+		AmountPaid:     lnwire.MilliSatoshi(toMsat(paid.AmountReceivedMsat)),
+		SettleDate:     time.Unix(int64(paid.PaidAt), 0),
+		State:          invoices.ContractSettled,
+		SettleIndex:    paid.PayIndex,
+	}
+	copy(invoice.Hash[:], paid.PaymentHash)
+
+	if preimage, err := lntypes.MakePreimage(paid.PaymentPreimage); err == nil {
+		invoice.Preimage = &preimage
+	}
+
+	return invoice
+}
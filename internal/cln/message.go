@@ -0,0 +1,25 @@
+package cln
+
+import (
+	"context"
+	"encoding/hex"
+)
+
+// SignMessage signs the message with the node's key
+func (b *Backend) SignMessage(ctx context.Context, message string) (string, error) {
+	response := &signmessageResponse{}
+	err := b.call(ctx, "sign message", "SignMessage", &signmessageRequest{Message: message}, response)
+	return response.Zbase, err
+}
+
+// VerifyMessage checks the signature of the message and returns the public
+// key of the signing node
+func (b *Backend) VerifyMessage(ctx context.Context, message, signature string) (bool, string, error) {
+	response := &checkmessageResponse{}
+	request := &checkmessageRequest{Message: message, Zbase: signature}
+	if err := b.call(ctx, "verify message", "CheckMessage", request, response); err != nil {
+		return false, "", err
+	}
+
+	return response.Verified, hex.EncodeToString(response.Pubkey), nil
+}
@@ -0,0 +1,260 @@
+package cln
+
+// Messages of the cln.Node service of cln-grpc, declared with the field
+// numbers of cln-grpc's node.proto. Only the fields flash uses are listed.
+
+type amount struct {
+	Msat uint64 `pb:"1"`
+}
+
+// Either an amount or any amount, a oneof in node.proto
+type amountOrAny struct {
+	Amount *amount `pb:"1"`
+	Any    bool    `pb:"2"`
+}
+
+// Values of the ChannelState enum
+const (
+	stateOpeningd                int32 = 0
+	stateChanneldAwaitingLockin  int32 = 1
+	stateChanneldNormal          int32 = 2
+	stateChanneldShuttingDown    int32 = 3
+	stateClosingdSigexchange     int32 = 4
+	stateClosingdComplete        int32 = 5
+	stateAwaitingUnilateral      int32 = 6
+	stateFundingSpendSeen        int32 = 7
+	stateOnchain                 int32 = 8
+	stateDualopendOpenInit       int32 = 9
+	stateDualopendAwaitingLockin int32 = 10
+	stateChanneldAwaitingSplice  int32 = 11
+)
+
+// Value of the ChannelSide enum for the local node
+const sideLocal int32 = 0
+
+type getinfoRequest struct{}
+
+type getinfoResponse struct {
+	ID      []byte `pb:"1"`
+	Alias   string `pb:"2"`
+	Version string `pb:"8"`
+	Network string `pb:"12"`
+}
+
+type listfundsRequest struct{}
+
+// Value of the ListfundsOutputsStatus enum for confirmed outputs
+const outputConfirmed int32 = 1
+
+type listfundsOutput struct {
+	AmountMsat *amount `pb:"3"`
+	Status     int32   `pb:"7"`
+}
+
+type listfundsChannel struct {
+	OurAmountMsat *amount `pb:"2"`
+	AmountMsat    *amount `pb:"3"`
+	State         int32   `pb:"7"`
+}
+
+type listfundsResponse struct {
+	Outputs  []*listfundsOutput  `pb:"1"`
+	Channels []*listfundsChannel `pb:"2"`
+}
+
+type listpeerchannelsRequest struct{}
+
+type peerChannel struct {
+	PeerID         []byte  `pb:"1"`
+	PeerConnected  bool    `pb:"2"`
+	State          int32   `pb:"3"`
+	ShortChannelID string  `pb:"8"`
+	ChannelID      []byte  `pb:"9"`
+	FundingTxid    []byte  `pb:"10"`
+	FundingOutnum  uint32  `pb:"11"`
+	Private        bool    `pb:"18"`
+	Opener         int32   `pb:"19"`
+	ToUsMsat       *amount `pb:"23"`
+	TotalMsat      *amount `pb:"26"`
+}
+
+type listpeerchannelsResponse struct {
+	Channels []*peerChannel `pb:"1"`
+}
+
+type listchannelsRequest struct {
+	ShortChannelID string `pb:"1"`
+}
+
+type gossipChannel struct {
+	Source          []byte  `pb:"1"`
+	Destination     []byte  `pb:"2"`
+	ShortChannelID  string  `pb:"3"`
+	AmountMsat      *amount `pb:"5"`
+	Active          bool    `pb:"8"`
+	LastUpdate      uint32  `pb:"9"`
+	BaseFeeMsat     uint32  `pb:"10"`
+	FeePerMillionth uint32  `pb:"11"`
+	Delay           uint32  `pb:"12"`
+	HtlcMinimumMsat *amount `pb:"13"`
+	HtlcMaximumMsat *amount `pb:"14"`
+	Direction       uint32  `pb:"16"`
+}
+
+type listchannelsResponse struct {
+	Channels []*gossipChannel `pb:"1"`
+}
+
+type listnodesRequest struct {
+	ID []byte `pb:"1"`
+}
+
+type listnodesNode struct {
+	NodeID []byte `pb:"1"`
+	Alias  string `pb:"3"`
+}
+
+type listnodesResponse struct {
+	Nodes []*listnodesNode `pb:"1"`
+}
+
+type setchannelRequest struct {
+	ID      string  `pb:"1"`
+	Feebase *amount `pb:"2"`
+	Feeppm  uint32  `pb:"3"`
+	Htlcmin *amount `pb:"4"`
+	Htlcmax *amount `pb:"5"`
+}
+
+type setchannelResponse struct{}
+
+type closeRequest struct {
+	ID                string `pb:"1"`
+	Unilateraltimeout uint32 `pb:"2"`
+}
+
+type closeResponse struct {
+	Txid []byte `pb:"3"`
+}
+
+type listpaysRequest struct{}
+
+// Value of the ListpaysPaysStatus enum for completed payments
+const payComplete int32 = 2
+
+type listpaysPay struct {
+	PaymentHash    []byte  `pb:"1"`
+	Status         int32   `pb:"2"`
+	CreatedAt      uint64  `pb:"4"`
+	Bolt11         string  `pb:"6"`
+	AmountMsat     *amount `pb:"8"`
+	AmountSentMsat *amount `pb:"9"`
+	Preimage       []byte  `pb:"13"`
+}
+
+type listpaysResponse struct {
+	Pays []*listpaysPay `pb:"1"`
+}
+
+type decodepayRequest struct {
+	Bolt11 string `pb:"1"`
+}
+
+type decodepayResponse struct {
+	CreatedAt     uint64  `pb:"2"`
+	Expiry        uint64  `pb:"3"`
+	Payee         []byte  `pb:"4"`
+	AmountMsat    *amount `pb:"5"`
+	PaymentHash   []byte  `pb:"6"`
+	Description   string  `pb:"8"`
+	PaymentSecret []byte  `pb:"11"`
+}
+
+type invoiceRequest struct {
+	Description string       `pb:"2"`
+	Label       string       `pb:"3"`
+	Expiry      uint64       `pb:"7"`
+	AmountMsat  *amountOrAny `pb:"10"`
+}
+
+type invoiceResponse struct {
+	Bolt11      string `pb:"1"`
+	PaymentHash []byte `pb:"2"`
+}
+
+type payRequest struct {
+	Bolt11 string  `pb:"1"`
+	Maxfee *amount `pb:"11"`
+}
+
+type payResponse struct {
+	PaymentPreimage []byte  `pb:"1"`
+	AmountMsat      *amount `pb:"6"`
+	AmountSentMsat  *amount `pb:"7"`
+}
+
+type waitanyinvoiceRequest struct {
+	LastpayIndex uint64 `pb:"1"`
+}
+
+type waitanyinvoiceResponse struct {
+	Label              string  `pb:"1"`
+	Description        string  `pb:"2"`
+	PaymentHash        []byte  `pb:"3"`
+	AmountMsat         *amount `pb:"6"`
+	Bolt11             string  `pb:"7"`
+	PayIndex           uint64  `pb:"9"`
+	AmountReceivedMsat *amount `pb:"10"`
+	PaidAt             uint64  `pb:"11"`
+	PaymentPreimage    []byte  `pb:"12"`
+}
+
+// Values of the ListinvoicesIndex and WaitIndexname enums for the index
+// that goes up whenever an invoice changes
+const indexUpdated int32 = 1
+
+// Value of the WaitSubsystem enum for invoices
+const subsystemInvoices int32 = 0
+
+type waitRequest struct {
+	Subsystem int32  `pb:"1"`
+	Indexname int32  `pb:"2"`
+	Nextvalue uint64 `pb:"3"`
+}
+
+type waitResponse struct {
+	Updated uint64 `pb:"3"`
+}
+
+type listinvoicesRequest struct {
+	Index int32  `pb:"5"`
+	Start uint64 `pb:"6"`
+	Limit uint32 `pb:"7"`
+}
+
+type listinvoicesInvoice struct {
+	PayIndex     uint64 `pb:"9"`
+	UpdatedIndex uint64 `pb:"17"`
+}
+
+type listinvoicesResponse struct {
+	Invoices []*listinvoicesInvoice `pb:"1"`
+}
+
+type signmessageRequest struct {
+	Message string `pb:"1"`
+}
+
+type signmessageResponse struct {
+	Zbase string `pb:"3"`
+}
+
+type checkmessageRequest struct {
+	Message string `pb:"1"`
+	Zbase   string `pb:"2"`
+}
+
+type checkmessageResponse struct {
+	Verified bool   `pb:"1"`
+	Pubkey   []byte `pb:"2"`
+}
@@ -0,0 +1,52 @@
+package cln
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/lightninglabs/lndclient"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// GetPayments returns the latest completed payments, newest first
+func (b *Backend) GetPayments(ctx context.Context, max uint64) ([]lnd.Payment, error) {
+	response := &listpaysResponse{}
+	if err := b.call(ctx, "list payments", "ListPays", &listpaysRequest{}, response); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(response.Pays, func(i, j int) bool {
+		return response.Pays[i].CreatedAt > response.Pays[j].CreatedAt
+	})
+
+	var payments []lnd.Payment
+	for _, pay := range response.Pays {
+		if uint64(len(payments)) == max {
+			break
+		}
+
+		if pay.Status != payComplete {
+			continue
+		}
+
+		amount := lnwire.MilliSatoshi(toMsat(pay.AmountMsat))
+		payment := lndclient.Payment{
+			PaymentRequest: pay.Bolt11,
+			Amount:         amount,
+			Fee:            lnwire.MilliSatoshi(toMsat(pay.AmountSentMsat)) - amount,
+			Status:         &lndclient.PaymentStatus{State: lnrpc.Payment_SUCCEEDED},
+		}
+		copy(payment.Hash[:], pay.PaymentHash)
+
+		if preimage, err := lntypes.MakePreimage(pay.Preimage); err == nil {
+			payment.Preimage = &preimage
+		}
+
+		payments = append(payments, lnd.Payment{Payment: payment})
+	}
+
+	return payments, nil
+}
@@ -0,0 +1,241 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Settings can be given globally and for each node profile. Unset values
+// fall back to the global settings and then to the defaults. A zero
+// invoice_amount or max_fee and an empty proxy override them when given
+// explicitly, other zero values count as unset.
+type Settings struct {
+	// Amount in sats the invoice form starts with
+	InvoiceAmount uint64 `yaml:"invoice_amount"`
+
+	// Time until created invoices expire
+	InvoiceExpiry time.Duration `yaml:"invoice_expiry"`
+
+	// Maximum routing fee in sats for payments
+	MaxFee int64 `yaml:"max_fee"`
+
+	// Confirmation target in blocks for cooperative channel closes
+	CloseTargetBlocks int32 `yaml:"close_target_blocks"`
+
+	// Unit amounts are displayed in, sats or btc
+	Units string `yaml:"units"`
+
+	// Name of the color theme
+	Theme string `yaml:"theme"`
+
+	// Keys bound to an action, replacing its default keys
+	Keys map[string][]string `yaml:"keys"`
+
+	// SOCKS5 proxy host:port to connect to the node through, such as Tor
+	Proxy string `yaml:"proxy"`
+
+	// Interval the TUI reloads the node data in
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+
+	// Keys given in the config file
+	set map[string]bool
+}
+
+// Config is the content of the config file
+type Config struct {
+	Settings `yaml:",inline"`
+
+	// Settings for the node profile of the same name
+	Profiles map[string]Settings `yaml:"profiles"`
+}
+
+// Units amounts can be displayed in
+const (
+	UnitSats = "sats"
+	UnitBTC  = "btc"
+)
+
+// Defaults returns the settings used when the config file sets nothing
+func Defaults() Settings {
+	return Settings{
+		InvoiceAmount:     100,
+		InvoiceExpiry:     time.Hour,
+		MaxFee:            10,
+		CloseTargetBlocks: 10,
+		Units:             UnitBTC,
+		Theme:             "default",
+		RefreshInterval:   30 * time.Second,
+	}
+}
+
+// DefaultPath returns the path of the config file in the XDG config directory
+func DefaultPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "flash", "config.yaml"), nil
+}
+
+// Load reads the config file at path. Unknown settings are rejected so
+// typos don't go unnoticed.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && err != io.EOF {
+		return nil, errors.New("invalid config file " + path + ": " + err.Error())
+	}
+
+	// Record which settings are given, so explicit zeros override
+	var present struct {
+		Settings map[string]yaml.Node            `yaml:",inline"`
+		Profiles map[string]map[string]yaml.Node `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(data, &present); err != nil {
+		return nil, errors.New("invalid config file " + path + ": " + err.Error())
+	}
+	c.Settings.set = keys(present.Settings)
+	for name, s := range c.Profiles {
+		s.set = keys(present.Profiles[name])
+		c.Profiles[name] = s
+	}
+
+	if err := c.Settings.validate(); err != nil {
+		return nil, errors.New("invalid config file " + path + ": " + err.Error())
+	}
+
+	for name, s := range c.Profiles {
+		if err := s.validate(); err != nil {
+			return nil, errors.New("invalid config file " + path + ", profile " + name + ": " + err.Error())
+		}
+	}
+
+	return c, nil
+}
+
+func keys(m map[string]yaml.Node) map[string]bool {
+	set := make(map[string]bool, len(m))
+	for key := range m {
+		set[key] = true
+	}
+
+	return set
+}
+
+// LoadDefault reads the config file at the default path. A missing file
+// is the same as an empty one.
+func LoadDefault() (*Config, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return &Config{}, nil
+	}
+
+	c, err := Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+
+	return c, err
+}
+
+// ForProfile returns the settings for the node profile
+func (c *Config) ForProfile(name string) Settings {
+	return Defaults().merge(c.Settings).merge(c.Profiles[name])
+}
+
+// Override the settings with the values set in o
+func (s Settings) merge(o Settings) Settings {
+	if o.InvoiceAmount != 0 || o.set["invoice_amount"] {
+		s.InvoiceAmount = o.InvoiceAmount
+	}
+	if o.InvoiceExpiry != 0 {
+		s.InvoiceExpiry = o.InvoiceExpiry
+	}
+	if o.MaxFee != 0 || o.set["max_fee"] {
+		s.MaxFee = o.MaxFee
+	}
+	if o.CloseTargetBlocks != 0 {
+		s.CloseTargetBlocks = o.CloseTargetBlocks
+	}
+	if o.Units != "" {
+		s.Units = o.Units
+	}
+	if o.Theme != "" {
+		s.Theme = o.Theme
+	}
+	if o.Proxy != "" || o.set["proxy"] {
+		s.Proxy = o.Proxy
+	}
+	if o.RefreshInterval != 0 {
+		s.RefreshInterval = o.RefreshInterval
+	}
+
+	if len(o.Keys) > 0 {
+		keys := make(map[string][]string, len(s.Keys)+len(o.Keys))
+		for action, k := range s.Keys {
+			keys[action] = k
+		}
+		for action, k := range o.Keys {
+			keys[action] = k
+		}
+		s.Keys = keys
+	}
+
+	return s
+}
+
+func (s Settings) validate() error {
+	if s.InvoiceExpiry < 0 || (s.InvoiceExpiry > 0 && s.InvoiceExpiry < time.Second) {
+		return errors.New("invoice_expiry must be at least one second")
+	}
+
+	if s.RefreshInterval < 0 || (s.RefreshInterval > 0 && s.RefreshInterval < time.Second) {
+		return errors.New("refresh_interval must be at least one second")
+	}
+
+	if s.MaxFee < 0 {
+		return errors.New("max_fee must not be negative")
+	}
+
+	if s.CloseTargetBlocks < 0 {
+		return errors.New("close_target_blocks must not be negative")
+	}
+
+	switch s.Units {
+	case "", UnitSats, UnitBTC:
+	default:
+		return errors.New("units must be " + UnitSats + " or " + UnitBTC)
+	}
+
+	if s.Proxy != "" {
+		if _, _, err := net.SplitHostPort(s.Proxy); err != nil {
+			return errors.New("proxy must be given as host:port")
+		}
+	}
+
+	for action, k := range s.Keys {
+		if len(k) == 0 {
+			return errors.New("no keys given for " + action)
+		}
+	}
+
+	return nil
+}
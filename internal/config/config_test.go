@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		assert.FailNow(t, "unable to write config file: "+err.Error())
+	}
+
+	return path
+}
+
+func TestLoadProfileSettings(t *testing.T) {
+	path := writeConfig(t, `
+max_fee: 20
+invoice_expiry: 30m
+refresh_interval: 1m
+units: sats
+keys:
+  close: [x]
+profiles:
+  mynode:
+    max_fee: 50
+    theme: mono
+    refresh_interval: 10s
+    proxy: 127.0.0.1:9050
+    keys:
+      quit: [ctrl+q]
+`)
+
+	c, err := Load(path)
+	if err != nil {
+		assert.FailNow(t, "unable to load config: "+err.Error())
+	}
+
+	s := c.ForProfile("mynode")
+	assert.Equal(t, int64(50), s.MaxFee)
+	assert.Equal(t, 30*time.Minute, s.InvoiceExpiry)
+	assert.Equal(t, UnitSats, s.Units)
+	assert.Equal(t, "mono", s.Theme)
+	assert.Equal(t, "127.0.0.1:9050", s.Proxy)
+	assert.Equal(t, 10*time.Second, s.RefreshInterval)
+	assert.Equal(t, map[string][]string{"close": {"x"}, "quit": {"ctrl+q"}}, s.Keys)
+
+	// Other profiles only get the global settings
+	s = c.ForProfile("other")
+	assert.Equal(t, int64(20), s.MaxFee)
+	assert.Equal(t, "default", s.Theme)
+	assert.Equal(t, time.Minute, s.RefreshInterval)
+	assert.Equal(t, Defaults().InvoiceAmount, s.InvoiceAmount)
+}
+
+func TestLoadExplicitZeroSettings(t *testing.T) {
+	path := writeConfig(t, `
+max_fee: 50
+invoice_amount: 1000
+proxy: 127.0.0.1:9050
+close_target_blocks: 6
+profiles:
+  free:
+    max_fee: 0
+    invoice_amount: 0
+    proxy: ""
+    close_target_blocks: 0
+`)
+
+	c, err := Load(path)
+	if err != nil {
+		assert.FailNow(t, "unable to load config: "+err.Error())
+	}
+
+	s := c.ForProfile("free")
+	assert.Equal(t, int64(0), s.MaxFee)
+	assert.Equal(t, uint64(0), s.InvoiceAmount)
+	assert.Equal(t, "", s.Proxy)
+	// Zero isn't a valid target, so it counts as unset
+	assert.Equal(t, int32(6), s.CloseTargetBlocks)
+
+	s = c.ForProfile("other")
+	assert.Equal(t, int64(50), s.MaxFee)
+	assert.Equal(t, "127.0.0.1:9050", s.Proxy)
+}
+
+func TestLoadRejectsInvalidSettings(t *testing.T) {
+	for _, content := range []string{
+		"max_fees: 10",
+		"units: euro",
+		"profiles:\n  mynode:\n    max_fee: -1",
+		"keys:\n  close: []",
+		"proxy: 127.0.0.1",
+		"refresh_interval: 100ms",
+	} {
+		_, err := Load(writeConfig(t, content))
+		assert.Error(t, err, content)
+	}
+}
+
+func TestLoadDefaultWithoutFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	c, err := LoadDefault()
+	assert.NoError(t, err)
+	assert.Equal(t, Defaults(), c.ForProfile("default"))
+}
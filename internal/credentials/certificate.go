@@ -0,0 +1,43 @@
+package credentials
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"time"
+)
+
+// CertificateInfo describes the TLS certificate stored in a profile
+type CertificateInfo struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	DNSNames  []string  `json:"dns_names,omitempty"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+// Expired indicates whether the certificate is no longer valid at the given time
+func (c CertificateInfo) Expired(now time.Time) bool {
+	return now.After(c.NotAfter)
+}
+
+// DecodeCertificate parses the first certificate of the PEM encoded data
+func DecodeCertificate(certPEM []byte) (*CertificateInfo, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CertificateInfo{
+		Subject:   cert.Subject.String(),
+		Issuer:    cert.Issuer.String(),
+		DNSNames:  cert.DNSNames,
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+	}, nil
+}
@@ -0,0 +1,49 @@
+package credentials
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeCertificate(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		assert.FailNow(t, "unable to generate key: "+err.Error())
+	}
+
+	notAfter := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{Organization: []string{"lnd autogenerated cert"}, CommonName: "node"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		assert.FailNow(t, "unable to create certificate: "+err.Error())
+	}
+
+	info, err := DecodeCertificate(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}))
+	if err != nil {
+		assert.FailNow(t, "unable to decode certificate: "+err.Error())
+	}
+
+	assert.Equal(t, "CN=node,O=lnd autogenerated cert", info.Subject)
+	assert.Equal(t, []string{"localhost"}, info.DNSNames)
+	assert.Equal(t, notAfter, info.NotAfter)
+	assert.False(t, info.Expired(time.Now()))
+	assert.True(t, info.Expired(notAfter.Add(time.Second)))
+
+	_, err = DecodeCertificate([]byte("not a certificate"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,309 @@
+package credentials
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/google/tink/go/keyset"
+)
+
+// Auth files are stored in the following layout:
+//
+//	magic | version | key type | kdf parameters | credentials header | ciphertext
+//
+// The kdf parameters are only present for passphrase protected files and the
+// credentials header only in version 1 files, where the plaintext is the
+// certificate followed by the macaroon. From version 2 on the plaintext is a
+// JSON encoded Vault. Everything in front of the ciphertext is passed to the
+// AEAD as associated data, so any modification of the header makes
+// decryption fail.
+
+var containerMagic = []byte("FLSH")
+
+const (
+	containerVersion = 2
+
+	// Version 1 files hold a single certificate and macaroon
+	containerVersionCredentials = 1
+
+	// magic, version and key type
+	containerPrefixSize = 6
+)
+
+// keyType indicates how the encryption key of an auth file is obtained
+type keyType uint8
+
+const (
+	// keyTypeKeyset is a generated Tink keyset passed around as a hex string
+	keyTypeKeyset keyType = iota + 1
+
+	// keyTypePassphrase is a keyset derived from a passphrase
+	keyTypePassphrase
+)
+
+// Key is the secret used to unlock an auth file. Either Keyset or Passphrase is set.
+type Key struct {
+	// Hex encoded Tink keyset as returned by GenerateKey
+	Keyset string
+
+	// Passphrase the encryption key is derived from
+	Passphrase []byte
+}
+
+func (k Key) keyType() keyType {
+	if k.Passphrase != nil {
+		return keyTypePassphrase
+	}
+
+	return keyTypeKeyset
+}
+
+// Get the keyset handle for the key. The kdf parameters are only used for passphrase keys.
+func (k Key) handle(params *kdfParams) (*keyset.Handle, error) {
+	if k.keyType() == keyTypePassphrase {
+		if params == nil {
+			return nil, errors.New("missing key derivation parameters")
+		}
+		return params.deriveKeyset(k.Passphrase)
+	}
+
+	return parseEncodedKeyset(k.Keyset)
+}
+
+// containerHeader is the authenticated header in front of the encrypted data
+type containerHeader struct {
+	Version     uint8
+	KeyType     keyType
+	Kdf         *kdfParams
+	Credentials *credentialsHeader
+}
+
+// Size returns the size of the header in bytes
+func (h *containerHeader) Size() int {
+	size := containerPrefixSize
+	if h.Kdf != nil {
+		size += h.Kdf.Size()
+	}
+	if h.Credentials != nil {
+		size += h.Credentials.Size()
+	}
+	return size
+}
+
+// serialize encodes the header into a byte slice
+func (h *containerHeader) serialize() []byte {
+	headerBytes := make([]byte, 0, h.Size())
+	headerBytes = append(headerBytes, containerMagic...)
+	headerBytes = append(headerBytes, h.Version, byte(h.KeyType))
+	if h.Kdf != nil {
+		headerBytes = append(headerBytes, h.Kdf.serialize()...)
+	}
+	if h.Credentials != nil {
+		headerBytes = append(headerBytes, h.Credentials.serialize()...)
+	}
+	return headerBytes
+}
+
+// deserializeContainerHeader decodes the header at the start of the byte slice
+func deserializeContainerHeader(data []byte) (*containerHeader, error) {
+	if len(data) < containerPrefixSize || !bytes.HasPrefix(data, containerMagic) {
+		return nil, fmt.Errorf("%w: not a flash authentication file", ErrCorruptedFile)
+	}
+
+	h := &containerHeader{
+		Version: data[len(containerMagic)],
+		KeyType: keyType(data[len(containerMagic)+1]),
+	}
+	if h.Version != containerVersion && h.Version != containerVersionCredentials {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrCorruptedFile, h.Version)
+	}
+
+	offset := containerPrefixSize
+	switch h.KeyType {
+	case keyTypeKeyset:
+	case keyTypePassphrase:
+		params := kdfParams{}
+		if len(data) < offset+params.Size() {
+			return nil, ErrTruncatedHeader
+		}
+
+		parsedParams, err := deserializeKdfParams(data[offset : offset+params.Size()])
+		if err != nil {
+			return nil, err
+		}
+		h.Kdf = parsedParams
+		offset += params.Size()
+	default:
+		return nil, fmt.Errorf("%w: unknown key type %d", ErrCorruptedFile, h.KeyType)
+	}
+
+	if h.Version != containerVersionCredentials {
+		return h, nil
+	}
+
+	credentials := credentialsHeader{}
+	if len(data) < offset+credentials.Size() {
+		return nil, ErrTruncatedHeader
+	}
+
+	parsedCredentials, err := deserializeHeader(data[offset : offset+credentials.Size()])
+	if err != nil {
+		return nil, err
+	}
+	h.Credentials = parsedCredentials
+
+	return h, nil
+}
+
+// sealContainer encrypts the plaintext behind the header, filling in the key
+// type and key derivation parameters
+func sealContainer(key Key, header *containerHeader, plaintext []byte) ([]byte, error) {
+	header.KeyType = key.keyType()
+	if header.KeyType == keyTypePassphrase {
+		params, err := newKdfParams()
+		if err != nil {
+			return nil, err
+		}
+		header.Kdf = params
+	}
+
+	kh, err := key.handle(header.Kdf)
+	if err != nil {
+		return nil, err
+	}
+
+	headerBytes := header.serialize()
+	ciphertext, err := encryptWithHandle(kh, plaintext, headerBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(headerBytes, ciphertext...), nil
+}
+
+// openContainer decrypts a versioned auth file and returns its header and plaintext
+func openContainer(key Key, data []byte) (*containerHeader, []byte, error) {
+	header, err := deserializeContainerHeader(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if header.KeyType != key.keyType() {
+		if header.KeyType == keyTypePassphrase {
+			return nil, nil, fmt.Errorf("%w: authentication file is protected by a passphrase", ErrWrongKey)
+		}
+		return nil, nil, fmt.Errorf("%w: authentication file is protected by an encryption key", ErrWrongKey)
+	}
+
+	kh, err := key.handle(header.Kdf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	headerSize := header.Size()
+	plaintext, err := decryptWithHandle(kh, data[headerSize:], data[:headerSize])
+	if err != nil {
+		return nil, nil, ErrWrongKey
+	}
+
+	return header, plaintext, nil
+}
+
+// sealVault encrypts the vault into the current auth file format
+func sealVault(key Key, v *Vault) ([]byte, error) {
+	plaintext, err := marshalVault(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return sealContainer(key, &containerHeader{Version: containerVersion}, plaintext)
+}
+
+// openVault decrypts an auth file in the current or any earlier format
+func openVault(key Key, data []byte) (*Vault, error) {
+	if isLegacyFormat(data) {
+		certData, macData, err := openLegacy(key, data)
+		if err != nil {
+			return nil, err
+		}
+		return newSingleProfileVault(certData, macData), nil
+	}
+
+	header, plaintext, err := openContainer(key, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.Version == containerVersionCredentials {
+		certData, macData, err := splitCredentials(header.Credentials, plaintext)
+		if err != nil {
+			return nil, err
+		}
+		return newSingleProfileVault(certData, macData), nil
+	}
+
+	return unmarshalVault(plaintext)
+}
+
+// isOutdatedFormat indicates whether the data was written in an earlier auth file format
+func isOutdatedFormat(data []byte) bool {
+	return isLegacyFormat(data) || len(data) <= len(containerMagic) || data[len(containerMagic)] != containerVersion
+}
+
+// isLegacyFormat indicates whether the data predates the versioned auth file format
+func isLegacyFormat(data []byte) bool {
+	return !bytes.HasPrefix(data, containerMagic)
+}
+
+// openLegacy decrypts an auth file written before the format was versioned.
+// These files start with the kdf parameters when a passphrase was used,
+// followed by the unauthenticated credentials header.
+func openLegacy(key Key, data []byte) ([]byte, []byte, error) {
+	var params *kdfParams
+	if key.keyType() == keyTypePassphrase {
+		size := (&kdfParams{}).Size()
+		if len(data) < size {
+			return nil, nil, ErrTruncatedHeader
+		}
+
+		parsedParams, err := deserializeKdfParams(data[:size])
+		if err != nil {
+			return nil, nil, err
+		}
+		params = parsedParams
+		data = data[size:]
+	}
+
+	credentials := credentialsHeader{}
+	if len(data) < credentials.Size() {
+		return nil, nil, ErrTruncatedHeader
+	}
+
+	header, err := deserializeHeader(data[:credentials.Size()])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kh, err := key.handle(params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plaintext, err := decryptWithHandle(kh, data[credentials.Size():], nil)
+	if err != nil {
+		return nil, nil, ErrWrongKey
+	}
+
+	return splitCredentials(header, plaintext)
+}
+
+// splitCredentials splits the plaintext into certificate and macaroon according to the header
+func splitCredentials(header *credentialsHeader, plaintext []byte) ([]byte, []byte, error) {
+	if header.CertLength < 0 || header.MacaroonLength < 0 ||
+		header.CertLength+header.MacaroonLength != len(plaintext) {
+		return nil, nil, fmt.Errorf("%w: credentials header does not match the decrypted data", ErrCorruptedFile)
+	}
+
+	return plaintext[:header.CertLength], plaintext[header.CertLength:], nil
+}
@@ -3,9 +3,9 @@ package credentials
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"os"
-
-	"github.com/charmbracelet/log"
+	"path/filepath"
 )
 
 // credentialsHeader contains the file sizes for the cleartext certificate and Macaroon file
@@ -38,7 +38,7 @@ func (h *credentialsHeader) serialize() []byte {
 // deserializeHeader decodes the byte slice into a Header
 func deserializeHeader(data []byte) (*credentialsHeader, error) {
 	if len(data) != 8 {
-		return nil, errors.New("invalid header size")
+		return nil, fmt.Errorf("%w: invalid credentials header size", ErrCorruptedFile)
 	}
 
 	certLength := int(binary.BigEndian.Uint32(data[:4]))
@@ -49,73 +49,130 @@ func deserializeHeader(data []byte) (*credentialsHeader, error) {
 	}, nil
 }
 
-func encryptData(certBytes, macBytes []byte, key string) []byte {
-	// Create a header with file lengths
-	header := newHeader(len(certBytes), len(macBytes))
-	headerBytes := header.serialize()
+func encryptData(certBytes, macBytes []byte, key string) ([]byte, error) {
+	return sealVault(Key{Keyset: key}, newSingleProfileVault(certBytes, macBytes))
+}
 
-	concatenatedData := append(certBytes, macBytes...)
+// Encrypt provided TLS certificate file and macaroon and write the result to outputPath
+func EncryptCredentials(certificatePath, macaroonPath, outputPath string) (string, error) {
+	// Read files
+	certData, err := os.ReadFile(certificatePath)
+	if err != nil {
+		return "", fmt.Errorf("unable to read TLS certificate: %w", err)
+	}
 
-	encryptedData, err := Encrypt(key, concatenatedData)
+	macData, err := os.ReadFile(macaroonPath)
+	if err != nil {
+		return "", fmt.Errorf("unable to read macaroon: %w", err)
+	}
+
+	generatedKey, err := GenerateKey()
+	if err != nil {
+		return "", err
+	}
+	encryptedDataWithHeader, err := encryptData(certData, macData, generatedKey)
 	if err != nil {
-		log.Fatal("Error encrypting key material:", err)
+		return "", err
 	}
 
-	return append(headerBytes, encryptedData...)
+	// Write encrypted data with header to a file
+	if err := writeAuthFile(outputPath, encryptedDataWithHeader); err != nil {
+		return "", err
+	}
 
+	return generatedKey, nil
 }
 
-// Encrypt provided TLS certificate file and macaroon and write the result to disk
-func EncryptCredentials(certificatePath, macaroonPath string) string {
-	// Read files
-	certData, err := os.ReadFile(certificatePath)
+func decryptData(ciphertextData []byte, key string) ([]byte, []byte, error) {
+	return openCredentials(Key{Keyset: key}, ciphertextData)
+}
+
+// openCredentials decrypts an auth file and returns the credentials of its first profile
+func openCredentials(key Key, data []byte) ([]byte, []byte, error) {
+	v, err := openVault(key, data)
 	if err != nil {
-		log.Fatal("Error reading TLS Certificate:", err)
+		return nil, nil, err
 	}
 
-	macData, err := os.ReadFile(macaroonPath)
-	if err != nil {
-		log.Fatal("Error reading macaroon file:", err)
+	if len(v.Profiles) == 0 {
+		return nil, nil, errors.New("authentication file contains no profiles")
 	}
 
-	generatedKey := GenerateKey()
-	encryptedDataWithHeader := encryptData(certData, macData, generatedKey)
+	return v.Profiles[0].Certificate, v.Profiles[0].Macaroon, nil
+}
 
-	// Write encrypted data with header to a file
-	err = os.WriteFile("auth.bin", encryptedDataWithHeader, 0644)
+// Decrypt provided auth file with the specified key.
+func DecryptCredentials(encryptionKey, authFilePath string) ([]byte, []byte, error) {
+	encryptedData, err := os.ReadFile(authFilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certData, macData, err := decryptData(encryptedData, encryptionKey)
 	if err != nil {
-		log.Fatal("Error writing to file:", err)
+		return nil, nil, &FileError{Path: authFilePath, Err: err}
 	}
 
-	return generatedKey
+	return certData, macData, nil
 }
 
-func decryptData(ciphertextData []byte, key string) ([]byte, []byte) {
-	// Extract header and encrypted content
-	headerBytes := ciphertextData[:8] // Assuming the header size is 8 bytes
-	encryptedContent := ciphertextData[8:]
+// Rewrite an auth file written by an earlier version in the current format,
+// protected by the same key.
+func MigrateCredentials(key Key, authFilePath string) error {
+	encryptedData, err := os.ReadFile(authFilePath)
+	if err != nil {
+		return err
+	}
 
-	header, err := deserializeHeader(headerBytes)
+	if !isOutdatedFormat(encryptedData) {
+		return errors.New("authentication file is already in the current format")
+	}
+
+	v, err := openVault(key, encryptedData)
 	if err != nil {
-		log.Fatal("Error parsing encryption header:", err)
+		return &FileError{Path: authFilePath, Err: err}
 	}
 
-	decryptedData, err := Decrypt(key, encryptedContent)
+	return v.Save(key, authFilePath)
+}
+
+// Re-encrypt the auth file under a new key. The file is left untouched
+// unless it can be decrypted with the old key.
+func RotateKey(authFilePath string, oldKey, newKey Key) error {
+	v, err := LoadVault(oldKey, authFilePath)
 	if err != nil {
-		log.Fatal("Unable to decrypt authentication data:", err)
+		return err
 	}
-	certData := decryptedData[:header.CertLength]
-	macaroonData := decryptedData[header.CertLength:]
 
-	return certData, macaroonData
+	return v.Save(newKey, authFilePath)
 }
 
-// Decrypt provided auth file with the specified key.
-func DecryptCredentials(encryptionKey, authFilePath string) ([]byte, []byte) {
-	encryptedData, err := os.ReadFile(authFilePath)
+// writeAuthFile atomically replaces the file at path with data, readable only by the owner.
+func writeAuthFile(path string, data []byte) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
 	if err != nil {
-		log.Fatal("Unable to read authentication file:", err)
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := tmpFile.Chmod(0600); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
 	}
 
-	return decryptData(encryptedData, encryptionKey)
+	return os.Rename(tmpFile.Name(), path)
 }
@@ -1,7 +1,10 @@
 package credentials
 
 import (
+	"bytes"
 	"crypto/rand"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -20,21 +23,194 @@ func TestCredentialsEncryption(t *testing.T) {
 		assert.FailNow(t, "error generating random macaroon data")
 	}
 
-	key := GenerateKey()
+	key := generateKey(t)
 
-	encryptedData := encryptData(certData, macData, key)
+	encryptedData, err := encryptData(certData, macData, key)
+	if err != nil {
+		assert.FailNow(t, "unable to encrypt credentials: "+err.Error())
+	}
+
+	header, err := deserializeContainerHeader(encryptedData)
+	if err != nil {
+		assert.FailNow(t, "unable to parse header: "+err.Error())
+	}
+
+	assert.Equal(t, uint8(containerVersion), header.Version)
+	assert.Equal(t, keyTypeKeyset, header.KeyType)
+
+	assert.False(t, bytes.Contains(encryptedData, certData))
+	assert.False(t, bytes.Contains(encryptedData, macData))
+
+	decryptedCertData, decryptedMacData, err := decryptData(encryptedData, key)
+	assert.NoError(t, err)
+
+	assert.Equal(t, certData, decryptedCertData)
+	assert.Equal(t, macData, decryptedMacData)
+}
+
+func TestCredentialsEncryptionWithPassphrase(t *testing.T) {
+	certData := make([]byte, 128)
+	macData := make([]byte, 540)
+	_, err := rand.Read(certData)
+	if err != nil {
+		assert.FailNow(t, "error generating random cert data")
+	}
+	_, err = rand.Read(macData)
+	if err != nil {
+		assert.FailNow(t, "error generating random macaroon data")
+	}
+
+	key := Key{Passphrase: []byte("correct horse battery staple")}
+
+	encryptedData, err := sealVault(key, newSingleProfileVault(certData, macData))
+	if err != nil {
+		assert.FailNow(t, "unable to encrypt data: "+err.Error())
+	}
+
+	decryptedCertData, decryptedMacData, err := openCredentials(key, encryptedData)
+	if err != nil {
+		assert.FailNow(t, "unable to decrypt data: "+err.Error())
+	}
 
-	headerBytes := encryptedData[:8]
-	header, _ := deserializeHeader(headerBytes)
+	assert.Equal(t, certData, decryptedCertData)
+	assert.Equal(t, macData, decryptedMacData)
 
-	assert.Equal(t, 128, header.CertLength)
-	assert.Equal(t, 540, header.MacaroonLength)
+	_, _, err = openCredentials(Key{Passphrase: []byte("wrong passphrase")}, encryptedData)
+	assert.Error(t, err)
+}
 
-	assert.NotEqual(t, encryptedData[8:header.CertLength], certData)
-	assert.NotEqual(t, encryptedData[8+header.CertLength:], macData)
+func TestCredentialsHeaderIsAuthenticated(t *testing.T) {
+	key := Key{Keyset: generateKey(t)}
+	certData := []byte("certificate")
+	macData := []byte("macaroon")
 
-	decryptedCertData, decryptedMacData := decryptData(encryptedData, key)
+	// Version 1 files carry the credential lengths in the header
+	header := &containerHeader{Version: containerVersionCredentials, Credentials: newHeader(len(certData), len(macData))}
+	encryptedData, err := sealContainer(key, header, append(append([]byte{}, certData...), macData...))
+	if err != nil {
+		assert.FailNow(t, "unable to encrypt data: "+err.Error())
+	}
 
+	decryptedCertData, decryptedMacData, err := openCredentials(key, encryptedData)
+	if err != nil {
+		assert.FailNow(t, "unable to decrypt data: "+err.Error())
+	}
 	assert.Equal(t, certData, decryptedCertData)
 	assert.Equal(t, macData, decryptedMacData)
+
+	// Move a byte from the certificate to the macaroon
+	headerSize := header.Size()
+	encryptedData[headerSize-5]--
+	encryptedData[headerSize-1]++
+
+	_, _, err = openCredentials(key, encryptedData)
+	assert.Error(t, err)
+
+	_, _, err = openCredentials(key, encryptedData[:containerPrefixSize+2])
+	assert.Error(t, err)
+}
+
+func TestMigrateLegacyCredentials(t *testing.T) {
+	key := Key{Keyset: generateKey(t)}
+	certData := []byte("certificate")
+	macData := []byte("macaroon")
+
+	// Legacy files consist of the credentials header followed by the ciphertext
+	ciphertext, err := Encrypt(key.Keyset, append(append([]byte{}, certData...), macData...))
+	if err != nil {
+		assert.FailNow(t, "unable to encrypt data: "+err.Error())
+	}
+	legacyData := append(newHeader(len(certData), len(macData)).serialize(), ciphertext...)
+
+	authFilePath := filepath.Join(t.TempDir(), "auth.bin")
+	if err := os.WriteFile(authFilePath, legacyData, 0600); err != nil {
+		assert.FailNow(t, "unable to write auth file: "+err.Error())
+	}
+
+	v, err := LoadVault(key, authFilePath)
+	assert.NoError(t, err)
+	assert.True(t, v.Outdated)
+
+	assert.NoError(t, MigrateCredentials(key, authFilePath))
+	assert.Error(t, MigrateCredentials(key, authFilePath))
+
+	v, err = LoadVault(key, authFilePath)
+	assert.NoError(t, err)
+	assert.False(t, v.Outdated)
+
+	migratedData, err := os.ReadFile(authFilePath)
+	if err != nil {
+		assert.FailNow(t, "unable to read auth file: "+err.Error())
+	}
+	assert.False(t, isLegacyFormat(migratedData))
+
+	decryptedCertData, decryptedMacData, err := DecryptCredentials(key.Keyset, authFilePath)
+	assert.NoError(t, err)
+	assert.Equal(t, certData, decryptedCertData)
+	assert.Equal(t, macData, decryptedMacData)
+}
+
+func TestRotateKey(t *testing.T) {
+	oldKey := Key{Keyset: generateKey(t)}
+	newKey := Key{Passphrase: []byte("new passphrase")}
+	authFilePath := filepath.Join(t.TempDir(), "auth.bin")
+
+	v := newSingleProfileVault([]byte("certificate"), []byte("macaroon"))
+	if err := v.Save(oldKey, authFilePath); err != nil {
+		assert.FailNow(t, "unable to save vault: "+err.Error())
+	}
+
+	originalData, err := os.ReadFile(authFilePath)
+	if err != nil {
+		assert.FailNow(t, "unable to read auth file: "+err.Error())
+	}
+
+	// Rotating with the wrong key must not touch the file
+	assert.Error(t, RotateKey(authFilePath, Key{Keyset: generateKey(t)}, newKey))
+	unchangedData, _ := os.ReadFile(authFilePath)
+	assert.Equal(t, originalData, unchangedData)
+
+	assert.NoError(t, RotateKey(authFilePath, oldKey, newKey))
+
+	_, err = LoadVault(oldKey, authFilePath)
+	assert.Error(t, err)
+
+	rotated, err := LoadVault(newKey, authFilePath)
+	if err != nil {
+		assert.FailNow(t, "unable to load rotated vault: "+err.Error())
+	}
+	assert.Equal(t, v, rotated)
+}
+
+func TestDecryptCredentialsErrors(t *testing.T) {
+	key := Key{Keyset: generateKey(t)}
+	passphraseKey := Key{Passphrase: []byte("passphrase")}
+	dir := t.TempDir()
+
+	keysetFile := filepath.Join(dir, "keyset.bin")
+	passphraseFile := filepath.Join(dir, "passphrase.bin")
+	v := newSingleProfileVault([]byte("certificate"), []byte("macaroon"))
+	assert.NoError(t, v.Save(key, keysetFile))
+	assert.NoError(t, v.Save(passphraseKey, passphraseFile))
+
+	_, _, err := DecryptCredentials(generateKey(t), keysetFile)
+	assert.ErrorIs(t, err, ErrWrongKey)
+
+	var fileErr *FileError
+	if assert.ErrorAs(t, err, &fileErr) {
+		assert.Equal(t, keysetFile, fileErr.Path)
+	}
+
+	_, _, err = DecryptCredentials(key.Keyset, passphraseFile)
+	assert.ErrorIs(t, err, ErrWrongKey)
+
+	_, _, err = DecryptCredentials("not a key", keysetFile)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	passphraseData, _ := os.ReadFile(passphraseFile)
+	_, err = openVault(passphraseKey, passphraseData[:containerPrefixSize+2])
+	assert.ErrorIs(t, err, ErrTruncatedHeader)
+
+	_, err = openVault(key, append(append([]byte{}, containerMagic...), 9, byte(keyTypeKeyset)))
+	assert.ErrorIs(t, err, ErrCorruptedFile)
 }
@@ -0,0 +1,54 @@
+package credentials
+
+import (
+	"bytes"
+
+	"github.com/google/tink/go/aead"
+	"github.com/google/tink/go/insecurecleartextkeyset"
+	"github.com/google/tink/go/keyset"
+	"github.com/google/tink/go/tink"
+)
+
+// NewDataKey generates a key for data flash stores besides the auth file,
+// such as the node data cache. The key is returned along with a copy sealed
+// with the key of the auth file, which is stored with the data and opened
+// with OpenDataKey. Passphrase keys are only derived once that way.
+func NewDataKey(key Key) (tink.AEAD, []byte, error) {
+	kh, err := keyset.NewHandle(aead.AES256GCMKeyTemplate())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := insecurecleartextkeyset.Write(kh, keyset.NewBinaryWriter(buf)); err != nil {
+		return nil, nil, err
+	}
+
+	sealed, err := sealContainer(key, &containerHeader{Version: containerVersion}, buf.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	a, err := aead.New(kh)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return a, sealed, nil
+}
+
+// OpenDataKey decrypts a data key sealed by NewDataKey. It fails with
+// ErrWrongKey if the key of the auth file changed since.
+func OpenDataKey(key Key, sealed []byte) (tink.AEAD, error) {
+	_, plaintext, err := openContainer(key, sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	kh, err := insecurecleartextkeyset.Read(keyset.NewBinaryReader(bytes.NewReader(plaintext)))
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	return aead.New(kh)
+}
@@ -4,68 +4,85 @@ import (
 	"bytes"
 	"encoding/hex"
 
-	"github.com/charmbracelet/log"
-
 	"github.com/google/tink/go/aead"
 	"github.com/google/tink/go/insecurecleartextkeyset"
 	"github.com/google/tink/go/keyset"
 )
 
 // Generate a AES256GCM keyset handle.
-func GenerateKey() string {
+func GenerateKey() (string, error) {
 	kh, err := keyset.NewHandle(aead.AES256GCMKeyTemplate())
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 
-
 	// Create a writer to store the keyset
 	buf := new(bytes.Buffer)
 	writer := keyset.NewBinaryWriter(buf)
 
 	err = insecurecleartextkeyset.Write(kh, writer)
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 
-	// Encode the serialized keyset from the buffer to a base64 string
-	base64EncodedKey := hex.EncodeToString(buf.Bytes())
-
-	log.Info("Key succesfully generated")
-	return base64EncodedKey
+	// Encode the serialized keyset from the buffer to a hex string
+	return hex.EncodeToString(buf.Bytes()), nil
 }
 
-func parseEncodedKeyset(encodedKeyset string) *keyset.Handle{
+func parseEncodedKeyset(encodedKeyset string) (*keyset.Handle, error) {
 	encodedKeysetBytes, err := hex.DecodeString(encodedKeyset)
 	if err != nil {
-		log.Fatal(err)
+		return nil, ErrInvalidKey
 	}
 
 	reader := keyset.NewBinaryReader(bytes.NewReader(encodedKeysetBytes))
 	kh, err := insecurecleartextkeyset.Read(reader)
 	if err != nil {
-		log.Fatal(err)
+		return nil, ErrInvalidKey
 	}
 
-	return kh
+	return kh, nil
 }
 
 // Encrypt data
 func Encrypt(encodedKeyset string, data []byte) ([]byte, error) {
-	kh := parseEncodedKeyset(encodedKeyset)
-	a, err := aead.New(kh)
+	kh, err := parseEncodedKeyset(encodedKeyset)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	return a.Encrypt(data, nil)
+
+	return encryptWithHandle(kh, data, nil)
 }
 
 // Decrypt a ciphertext with a provided keyset.
 func Decrypt(encodedKeyset string, ciphertext []byte) ([]byte, error) {
-	kh := parseEncodedKeyset(encodedKeyset)
+	kh, err := parseEncodedKeyset(encodedKeyset)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := decryptWithHandle(kh, ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+
+	return plaintext, nil
+}
+
+// Encrypt data with a keyset handle, authenticating the associated data
+func encryptWithHandle(kh *keyset.Handle, data, associatedData []byte) ([]byte, error) {
+	a, err := aead.New(kh)
+	if err != nil {
+		return nil, err
+	}
+	return a.Encrypt(data, associatedData)
+}
+
+// Decrypt a ciphertext with a keyset handle, verifying the associated data
+func decryptWithHandle(kh *keyset.Handle, ciphertext, associatedData []byte) ([]byte, error) {
 	a, err := aead.New(kh)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	return a.Decrypt(ciphertext, nil)
+	return a.Decrypt(ciphertext, associatedData)
 }
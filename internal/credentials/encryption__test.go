@@ -5,14 +5,24 @@ import (
 	"testing"
 )
 
+// Generate a keyset, failing the test if that isn't possible
+func generateKey(t *testing.T) string {
+	keyset, err := GenerateKey()
+	if err != nil {
+		assert.FailNow(t, "unable to generate key: "+err.Error())
+	}
+
+	return keyset
+}
+
 func TestKeyGeneration_assertKeyReturned(t *testing.T) {
-	keyset := GenerateKey()
+	keyset := generateKey(t)
 
 	assert.True(t, len(keyset) > 10)
 }
 
 func TestEncryption(t *testing.T) {
-	keyset := GenerateKey()
+	keyset := generateKey(t)
 	cleartext := make([]byte, 128)
 
 	// Encrypt data
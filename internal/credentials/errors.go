@@ -0,0 +1,32 @@
+package credentials
+
+import "errors"
+
+var (
+	// ErrWrongKey is returned when an auth file can't be decrypted with the
+	// given key. Files modified after encryption fail the same way.
+	ErrWrongKey = errors.New("wrong encryption key or passphrase")
+
+	// ErrCorruptedFile is returned when an auth file is damaged or not an auth file at all
+	ErrCorruptedFile = errors.New("authentication file is corrupted")
+
+	// ErrTruncatedHeader is returned when an auth file ends within its header
+	ErrTruncatedHeader = errors.New("truncated authentication file header")
+
+	// ErrInvalidKey is returned when an encryption key can't be parsed
+	ErrInvalidKey = errors.New("invalid encryption key")
+)
+
+// FileError records the auth file an error occurred with
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
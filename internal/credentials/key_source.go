@@ -0,0 +1,82 @@
+package credentials
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// Environment variable holding the encryption key
+const KeyEnvVar = "FLASH_KEY"
+
+// KeyFromEnv returns the encryption key set in the environment, if any
+func KeyFromEnv() (string, bool) {
+	encKey := strings.TrimSpace(os.Getenv(KeyEnvVar))
+	return encKey, encKey != ""
+}
+
+// ReadKeyFile reads the encryption key from a file. Files readable by other
+// users are refused, like ssh does for private keys.
+func ReadKeyFile(path string) (string, error) {
+	if err := checkPrivate(path, "key file"); err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return ReadKey(f)
+}
+
+// ReadKeyShareFile reads a key share from a file. Like key files, share files
+// readable by other users are refused.
+func ReadKeyShareFile(path string) (KeyShare, error) {
+	if err := checkPrivate(path, "key share file"); err != nil {
+		return KeyShare{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return KeyShare{}, err
+	}
+
+	share, err := ParseKeyShare(string(data))
+	if err != nil {
+		return KeyShare{}, errors.New("invalid key share in " + path + ": " + err.Error())
+	}
+
+	return share, nil
+}
+
+func checkPrivate(path, what string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if info.Mode().Perm()&0o077 != 0 {
+		return errors.New(what + " " + path + " is accessible by other users, restrict it with chmod 600")
+	}
+
+	return nil
+}
+
+// ReadKey reads the encryption key from the first line of r
+func ReadKey(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	encKey := strings.TrimSpace(line)
+	if encKey == "" {
+		return "", errors.New("no encryption key provided")
+	}
+
+	return encKey, nil
+}
@@ -0,0 +1,63 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("abcdef\n"), 0o600); err != nil {
+		assert.FailNow(t, "unable to write key file: "+err.Error())
+	}
+
+	encKey, err := ReadKeyFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "abcdef", encKey)
+
+	// Key files readable by the group or others are refused
+	for _, mode := range []os.FileMode{0o640, 0o604} {
+		assert.NoError(t, os.Chmod(path, mode))
+		_, err = ReadKeyFile(path)
+		assert.Error(t, err)
+	}
+}
+
+func TestReadKeyShareFile(t *testing.T) {
+	shares, err := SplitKey(generateKey(t), 3, 2)
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "share")
+	assert.NoError(t, os.WriteFile(path, []byte(shares[0].Hex()+"\n"), 0o600))
+	share, err := ReadKeyShareFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, shares[0].Index, share.Index)
+
+	assert.NoError(t, os.Chmod(path, 0o644))
+	_, err = ReadKeyShareFile(path)
+	assert.Error(t, err)
+}
+
+func TestReadKey(t *testing.T) {
+	encKey, err := ReadKey(strings.NewReader("  abcdef  \nignored"))
+	assert.NoError(t, err)
+	assert.Equal(t, "abcdef", encKey)
+
+	_, err = ReadKey(strings.NewReader("\n"))
+	assert.Error(t, err)
+}
+
+func TestKeyFromEnv(t *testing.T) {
+	t.Setenv(KeyEnvVar, "abcdef")
+	encKey, ok := KeyFromEnv()
+	assert.True(t, ok)
+	assert.Equal(t, "abcdef", encKey)
+
+	t.Setenv(KeyEnvVar, "")
+	_, ok = KeyFromEnv()
+	assert.False(t, ok)
+}
@@ -0,0 +1,64 @@
+package credentials
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Port used when an lndconnect URI doesn't specify one
+const defaultRPCPort = "10009"
+
+// Parse an lndconnect URI into a profile holding the RPC host, TLS certificate
+// and macaroon. The certificate is left empty when the URI doesn't contain one,
+// which is the case for nodes using a certificate signed by a public CA.
+//
+// The URI format is lndconnect://host:port?cert=<base64url DER>&macaroon=<base64url>
+func ParseLndConnectURI(uri string) (Profile, error) {
+	parsedURI, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil {
+		return Profile{}, err
+	}
+
+	if parsedURI.Scheme != "lndconnect" {
+		return Profile{}, errors.New("not an lndconnect URI")
+	}
+
+	host := parsedURI.Host
+	if host == "" {
+		return Profile{}, errors.New("lndconnect URI contains no host")
+	}
+
+	if parsedURI.Port() == "" {
+		host = net.JoinHostPort(parsedURI.Hostname(), defaultRPCPort)
+	}
+
+	query := parsedURI.Query()
+	if query.Get("macaroon") == "" {
+		return Profile{}, errors.New("lndconnect URI contains no macaroon")
+	}
+
+	macData, err := decodeBase64URL(query.Get("macaroon"))
+	if err != nil {
+		return Profile{}, errors.New("invalid macaroon in lndconnect URI")
+	}
+
+	var certData []byte
+	if query.Get("cert") != "" {
+		certDER, err := decodeBase64URL(query.Get("cert"))
+		if err != nil {
+			return Profile{}, errors.New("invalid certificate in lndconnect URI")
+		}
+		certData = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	}
+
+	return Profile{Certificate: certData, Macaroon: macData, RPCHost: host}, nil
+}
+
+// Decode base64url data with or without padding
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,56 @@
+package credentials
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLndConnectURI(t *testing.T) {
+	certDER := []byte{0x30, 0x82, 0x02, 0x0a, 0xfb, 0xff}
+	macData := []byte{0x02, 0x01, 0x03, 0x6c, 0x6e, 0x64, 0xfe}
+
+	uri := "lndconnect://node.example.com:10019?cert=" + base64.RawURLEncoding.EncodeToString(certDER) +
+		"&macaroon=" + base64.URLEncoding.EncodeToString(macData)
+
+	profile, err := ParseLndConnectURI(uri)
+	if err != nil {
+		assert.FailNow(t, "unable to parse URI: "+err.Error())
+	}
+
+	assert.Equal(t, "node.example.com:10019", profile.RPCHost)
+	assert.Equal(t, macData, profile.Macaroon)
+
+	block, _ := pem.Decode(profile.Certificate)
+	if block == nil {
+		assert.FailNow(t, "certificate is not PEM encoded")
+	}
+	assert.Equal(t, "CERTIFICATE", block.Type)
+	assert.Equal(t, certDER, block.Bytes)
+}
+
+func TestParseLndConnectURIWithoutCert(t *testing.T) {
+	profile, err := ParseLndConnectURI("lndconnect://node.example.com?macaroon=AgEDbG5k")
+	if err != nil {
+		assert.FailNow(t, "unable to parse URI: "+err.Error())
+	}
+
+	assert.Equal(t, "node.example.com:10009", profile.RPCHost)
+	assert.Empty(t, profile.Certificate)
+	assert.NotEmpty(t, profile.Macaroon)
+}
+
+func TestParseLndConnectURIInvalid(t *testing.T) {
+	for _, uri := range []string{
+		"https://node.example.com:10009?macaroon=AgEDbG5k",
+		"lndconnect://node.example.com:10009",
+		"lndconnect://?macaroon=AgEDbG5k",
+		"lndconnect://node.example.com:10009?macaroon=%%%",
+		"lndconnect://node.example.com:10009?macaroon=AgEDbG5k&cert=***",
+	} {
+		_, err := ParseLndConnectURI(uri)
+		assert.Error(t, err, uri)
+	}
+}
@@ -0,0 +1,161 @@
+package credentials
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/macaroons"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/macaroon.v2"
+)
+
+// lnd prefixes the protobuf encoded macaroon identifier with the bakery version
+const lndMacaroonIDVersion = 3
+
+// MacaroonInfo describes the contents of a macaroon
+type MacaroonInfo struct {
+	Version     uint16   `json:"version"`
+	Location    string   `json:"location"`
+	ID          string   `json:"id"`
+	RootKeyID   string   `json:"root_key_id,omitempty"`
+	Caveats     []string `json:"caveats"`
+	Permissions []string `json:"permissions"`
+}
+
+// MacaroonRestrictions are the first party caveats added to a macaroon before
+// it is stored in an auth file
+type MacaroonRestrictions struct {
+	// Lifetime of the macaroon, no expiry when zero
+	Timeout time.Duration
+
+	// IP address the macaroon may be used from
+	IPAddress string
+
+	// Custom caveat in the form "name" or "name condition"
+	CustomCaveat string
+}
+
+// Empty indicates whether no restrictions are set
+func (r MacaroonRestrictions) Empty() bool {
+	return r.Timeout == 0 && r.IPAddress == "" && r.CustomCaveat == ""
+}
+
+func (r MacaroonRestrictions) constraints() ([]macaroons.Constraint, error) {
+	var constraints []macaroons.Constraint
+
+	if r.Timeout < 0 {
+		return nil, errors.New("macaroon timeout must be positive")
+	}
+
+	if r.Timeout > 0 {
+		seconds := int64(r.Timeout / time.Second)
+		if seconds == 0 {
+			return nil, errors.New("macaroon timeout must be at least one second")
+		}
+		constraints = append(constraints, macaroons.TimeoutConstraint(seconds))
+	}
+
+	if r.IPAddress != "" {
+		constraints = append(constraints, macaroons.IPLockConstraint(r.IPAddress))
+	}
+
+	if r.CustomCaveat != "" {
+		name, condition, _ := strings.Cut(strings.TrimSpace(r.CustomCaveat), " ")
+		if name == "" {
+			return nil, errors.New("custom caveat name required")
+		}
+		constraints = append(constraints, macaroons.CustomConstraint(name, strings.TrimSpace(condition)))
+	}
+
+	return constraints, nil
+}
+
+// AttenuateMacaroon adds the restrictions to the binary encoded macaroon as
+// first party caveats. Caveats can only be added, so the result is never more
+// powerful than the original macaroon.
+func AttenuateMacaroon(macBytes []byte, restrictions MacaroonRestrictions) ([]byte, error) {
+	constraints, err := restrictions.constraints()
+	if err != nil {
+		return nil, err
+	}
+
+	mac, err := unmarshalMacaroon(macBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	constrained, err := macaroons.AddConstraints(mac, constraints...)
+	if err != nil {
+		return nil, err
+	}
+
+	return constrained.MarshalBinary()
+}
+
+// Attenuate restricts the macaroon stored in the profile
+func (p *Profile) Attenuate(restrictions MacaroonRestrictions) error {
+	if restrictions.Empty() {
+		return nil
+	}
+
+	macBytes, err := AttenuateMacaroon(p.Macaroon, restrictions)
+	if err != nil {
+		return err
+	}
+
+	p.Macaroon = macBytes
+	return nil
+}
+
+// DecodeMacaroon decodes the binary encoded macaroon. The permissions are read
+// from the identifier lnd baked into the macaroon and are empty for macaroons
+// not issued by lnd.
+func DecodeMacaroon(macBytes []byte) (*MacaroonInfo, error) {
+	mac, err := unmarshalMacaroon(macBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	rawID := mac.Id()
+	info := &MacaroonInfo{
+		Version:     uint16(mac.Version()),
+		Location:    mac.Location(),
+		ID:          hex.EncodeToString(rawID),
+		Caveats:     []string{},
+		Permissions: []string{},
+	}
+
+	for _, caveat := range mac.Caveats() {
+		info.Caveats = append(info.Caveats, string(caveat.Id))
+	}
+
+	if len(rawID) == 0 || rawID[0] != lndMacaroonIDVersion {
+		return info, nil
+	}
+
+	decodedID := &lnrpc.MacaroonId{}
+	if err := proto.Unmarshal(rawID[1:], decodedID); err != nil {
+		return nil, errors.New("unable to decode macaroon identifier: " + err.Error())
+	}
+
+	info.RootKeyID = string(decodedID.StorageId)
+	for _, op := range decodedID.Ops {
+		for _, action := range op.Actions {
+			info.Permissions = append(info.Permissions, op.Entity+":"+action)
+		}
+	}
+
+	return info, nil
+}
+
+func unmarshalMacaroon(macBytes []byte) (*macaroon.Macaroon, error) {
+	mac := &macaroon.Macaroon{}
+	if err := mac.UnmarshalBinary(macBytes); err != nil {
+		return nil, errors.New("unable to decode macaroon: " + err.Error())
+	}
+
+	return mac, nil
+}
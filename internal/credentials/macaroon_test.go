@@ -0,0 +1,106 @@
+package credentials
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/macaroon.v2"
+)
+
+func newTestMacaroon(t *testing.T) []byte {
+	mac, err := macaroon.New([]byte("root key"), []byte("id"), "lnd", macaroon.LatestVersion)
+	if err != nil {
+		assert.FailNow(t, "unable to create macaroon: "+err.Error())
+	}
+
+	macBytes, err := mac.MarshalBinary()
+	if err != nil {
+		assert.FailNow(t, "unable to encode macaroon: "+err.Error())
+	}
+
+	return macBytes
+}
+
+func TestAttenuateMacaroon(t *testing.T) {
+	macBytes := newTestMacaroon(t)
+
+	restrictions := MacaroonRestrictions{
+		Timeout:      time.Hour,
+		IPAddress:    "192.0.2.1",
+		CustomCaveat: "flash laptop",
+	}
+	attenuated, err := AttenuateMacaroon(macBytes, restrictions)
+	if err != nil {
+		assert.FailNow(t, "unable to attenuate macaroon: "+err.Error())
+	}
+
+	info, err := DecodeMacaroon(attenuated)
+	if err != nil {
+		assert.FailNow(t, "unable to decode macaroon: "+err.Error())
+	}
+	caveats := info.Caveats
+
+	if assert.Len(t, caveats, 3) {
+		assert.True(t, strings.HasPrefix(caveats[0], "time-before "))
+		assert.Equal(t, "ipaddr 192.0.2.1", caveats[1])
+		assert.Equal(t, "lnd-custom flash laptop", caveats[2])
+	}
+
+	// The original macaroon is left untouched
+	info, _ = DecodeMacaroon(macBytes)
+	assert.Empty(t, info.Caveats)
+}
+
+func TestAttenuateProfileWithoutRestrictions(t *testing.T) {
+	macBytes := newTestMacaroon(t)
+	profile := Profile{Name: "node", Macaroon: macBytes}
+
+	assert.NoError(t, profile.Attenuate(MacaroonRestrictions{}))
+	assert.Equal(t, macBytes, profile.Macaroon)
+}
+
+func TestAttenuateMacaroonRejectsInvalidInput(t *testing.T) {
+	macBytes := newTestMacaroon(t)
+
+	_, err := AttenuateMacaroon(macBytes, MacaroonRestrictions{Timeout: -time.Minute})
+	assert.Error(t, err)
+
+	_, err = AttenuateMacaroon(macBytes, MacaroonRestrictions{IPAddress: "not an ip"})
+	assert.Error(t, err)
+
+	_, err = AttenuateMacaroon([]byte("not a macaroon"), MacaroonRestrictions{Timeout: time.Hour})
+	assert.Error(t, err)
+}
+
+func TestDecodeMacaroonPermissions(t *testing.T) {
+	id, err := proto.Marshal(&lnrpc.MacaroonId{
+		Nonce:     []byte("nonce"),
+		StorageId: []byte("0"),
+		Ops: []*lnrpc.Op{
+			{Entity: "info", Actions: []string{"read"}},
+			{Entity: "offchain", Actions: []string{"read", "write"}},
+		},
+	})
+	if err != nil {
+		assert.FailNow(t, "unable to encode identifier: "+err.Error())
+	}
+
+	mac, err := macaroon.New([]byte("root key"), append([]byte{lndMacaroonIDVersion}, id...), "lnd", macaroon.LatestVersion)
+	if err != nil {
+		assert.FailNow(t, "unable to create macaroon: "+err.Error())
+	}
+	macBytes, _ := mac.MarshalBinary()
+
+	info, err := DecodeMacaroon(macBytes)
+	if err != nil {
+		assert.FailNow(t, "unable to decode macaroon: "+err.Error())
+	}
+
+	assert.Equal(t, "lnd", info.Location)
+	assert.Equal(t, "0", info.RootKeyID)
+	assert.Equal(t, []string{"info:read", "offchain:read", "offchain:write"}, info.Permissions)
+}
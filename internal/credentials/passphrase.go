@@ -0,0 +1,124 @@
+package credentials
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/google/tink/go/insecurecleartextkeyset"
+	"github.com/google/tink/go/keyset"
+	gcmpb "github.com/google/tink/go/proto/aes_gcm_go_proto"
+	tinkpb "github.com/google/tink/go/proto/tink_go_proto"
+	"golang.org/x/crypto/argon2"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// Default Argon2id cost parameters. 64 MiB of memory keeps brute force
+	// attempts expensive while still unlocking in well under a second.
+	defaultKdfTime    = 3
+	defaultKdfMemory  = 64 * 1024
+	defaultKdfThreads = 4
+
+	// Upper limits of the cost parameters read from an auth file, so a
+	// crafted file can't make the key derivation exhaust memory or time.
+	// Memory is in KiB, 4 GiB at most.
+	maxKdfTime   = 64
+	maxKdfMemory = 4 * 1024 * 1024
+
+	kdfSaltLength = 16
+	kdfKeyLength  = 32
+
+	aesGcmTypeURL = "type.googleapis.com/google.crypto.tink.AesGcmKey"
+)
+
+// kdfParams contains the salt and Argon2id parameters used to derive
+// an encryption key from a passphrase
+type kdfParams struct {
+	Salt    []byte
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// newKdfParams creates a new kdfParams instance with a random salt
+func newKdfParams() (*kdfParams, error) {
+	salt := make([]byte, kdfSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return &kdfParams{
+		Salt:    salt,
+		Time:    defaultKdfTime,
+		Memory:  defaultKdfMemory,
+		Threads: defaultKdfThreads,
+	}, nil
+}
+
+// Size returns the size of the serialized parameters in bytes
+func (p *kdfParams) Size() int {
+	return kdfSaltLength + 9 // salt, 2 integers and the thread count
+}
+
+// serialize encodes the parameters into a byte slice
+func (p *kdfParams) serialize() []byte {
+	paramBytes := make([]byte, p.Size())
+	copy(paramBytes, p.Salt)
+	binary.BigEndian.PutUint32(paramBytes[kdfSaltLength:], p.Time)
+	binary.BigEndian.PutUint32(paramBytes[kdfSaltLength+4:], p.Memory)
+	paramBytes[kdfSaltLength+8] = p.Threads
+	return paramBytes
+}
+
+// deserializeKdfParams decodes the byte slice into kdfParams
+func deserializeKdfParams(data []byte) (*kdfParams, error) {
+	p := &kdfParams{}
+	if len(data) != p.Size() {
+		return nil, errors.New("invalid key derivation parameters size")
+	}
+
+	p.Salt = append([]byte{}, data[:kdfSaltLength]...)
+	p.Time = binary.BigEndian.Uint32(data[kdfSaltLength:])
+	p.Memory = binary.BigEndian.Uint32(data[kdfSaltLength+4:])
+	p.Threads = data[kdfSaltLength+8]
+
+	if p.Time == 0 || p.Memory == 0 || p.Threads == 0 || p.Time > maxKdfTime || p.Memory > maxKdfMemory {
+		return nil, fmt.Errorf("%w: invalid key derivation parameters", ErrCorruptedFile)
+	}
+
+	return p, nil
+}
+
+// deriveKeyset derives an AES256-GCM keyset handle from the passphrase
+func (p *kdfParams) deriveKeyset(passphrase []byte) (*keyset.Handle, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+
+	derivedKey := argon2.IDKey(passphrase, p.Salt, p.Time, p.Memory, p.Threads, kdfKeyLength)
+
+	serializedKey, err := proto.Marshal(&gcmpb.AesGcmKey{Version: 0, KeyValue: derivedKey})
+	if err != nil {
+		return nil, err
+	}
+
+	// The key is wrapped in a single-key keyset with a RAW output prefix so
+	// the ciphertext does not depend on a randomly assigned key ID.
+	ks := &tinkpb.Keyset{
+		PrimaryKeyId: 1,
+		Key: []*tinkpb.Keyset_Key{{
+			KeyData: &tinkpb.KeyData{
+				TypeUrl:         aesGcmTypeURL,
+				Value:           serializedKey,
+				KeyMaterialType: tinkpb.KeyData_SYMMETRIC,
+			},
+			Status:           tinkpb.KeyStatusType_ENABLED,
+			KeyId:            1,
+			OutputPrefixType: tinkpb.OutputPrefixType_RAW,
+		}},
+	}
+
+	return insecurecleartextkeyset.Read(&keyset.MemReaderWriter{Keyset: ks})
+}
@@ -0,0 +1,28 @@
+package credentials
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeserializeKdfParams(t *testing.T) {
+	p, err := newKdfParams()
+	if err != nil {
+		assert.FailNow(t, "unable to create parameters: "+err.Error())
+	}
+
+	decoded, err := deserializeKdfParams(p.serialize())
+	assert.NoError(t, err)
+	assert.Equal(t, p, decoded)
+
+	// Costs a crafted auth file could use to exhaust memory or time
+	for _, params := range []kdfParams{
+		{Salt: p.Salt, Time: 1, Memory: 1<<32 - 1, Threads: 1},
+		{Salt: p.Salt, Time: 1 << 20, Memory: defaultKdfMemory, Threads: 1},
+		{Salt: p.Salt, Time: 0, Memory: defaultKdfMemory, Threads: 1},
+	} {
+		_, err := deserializeKdfParams(params.serialize())
+		assert.ErrorIs(t, err, ErrCorruptedFile)
+	}
+}
@@ -0,0 +1,296 @@
+package credentials
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Encryption keys can be split into shares with Shamir's secret sharing over
+// GF(256), so that any threshold of shares reconstructs the key while fewer
+// reveal nothing about it. A serialized share has the following layout:
+//
+//	version | threshold | index | key id | data | checksum
+//
+// The key id is random and identifies shares belonging together, the
+// checksum detects typos in a share. Mnemonic encoded shares
+// are prefixed with their length and use the BIP39 English word list.
+
+const (
+	keyShareVersion = 1
+
+	keyIDSize         = 4
+	keyShareSumSize   = 4
+	keyShareFixedSize = 3 + keyIDSize + keyShareSumSize
+
+	mnemonicBitsPerWord = 11
+)
+
+// BIP39 English word list
+//
+//go:embed wordlist.txt
+var wordListData string
+
+var (
+	wordList        = strings.Fields(wordListData)
+	reverseWordList = newReverseWordList(wordList)
+)
+
+func newReverseWordList(words []string) map[string]int {
+	reverse := make(map[string]int, len(words))
+	for i, w := range words {
+		reverse[w] = i
+	}
+	return reverse
+}
+
+// KeyShare is a single share of a split encryption key
+type KeyShare struct {
+	Threshold uint8
+	Index     uint8
+	keyID     []byte
+	data      []byte
+}
+
+// SplitKey splits the hex encoded keyset into n shares, any threshold of
+// which can reconstruct it
+func SplitKey(encodedKeyset string, n, threshold int) ([]KeyShare, error) {
+	secret, err := hex.DecodeString(encodedKeyset)
+	if err != nil {
+		return nil, errors.New("invalid encryption key")
+	}
+
+	if threshold < 2 {
+		return nil, errors.New("threshold must be at least 2")
+	}
+
+	if n < threshold || n > 255 {
+		return nil, errors.New("number of shares must be between the threshold and 255")
+	}
+
+	keyID, err := newKeyID()
+	if err != nil {
+		return nil, err
+	}
+
+	shares := make([]KeyShare, n)
+	for i := range shares {
+		shares[i] = KeyShare{
+			Threshold: uint8(threshold),
+			Index:     uint8(i + 1),
+			keyID:     keyID,
+			data:      make([]byte, len(secret)),
+		}
+	}
+
+	// Every byte of the secret is the constant term of its own random
+	// polynomial of degree threshold-1, evaluated at the share indices
+	coefficients := make([]byte, threshold)
+	for pos, b := range secret {
+		coefficients[0] = b
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, err
+		}
+
+		for i := range shares {
+			shares[i].data[pos] = evaluatePolynomial(coefficients, shares[i].Index)
+		}
+	}
+
+	return shares, nil
+}
+
+// CombineKeyShares reconstructs the hex encoded keyset from the shares
+func CombineKeyShares(shares []KeyShare) (string, error) {
+	if len(shares) == 0 {
+		return "", errors.New("no key shares given")
+	}
+
+	first := shares[0]
+	if len(shares) < int(first.Threshold) {
+		return "", errors.New(strconv.Itoa(int(first.Threshold)) + " key shares required, got " + strconv.Itoa(len(shares)))
+	}
+
+	seen := make(map[uint8]bool)
+	for _, s := range shares {
+		if s.Threshold != first.Threshold || !bytes.Equal(s.keyID, first.keyID) || len(s.data) != len(first.data) {
+			return "", errors.New("key shares belong to different keys")
+		}
+
+		if seen[s.Index] {
+			return "", errors.New("key share " + strconv.Itoa(int(s.Index)) + " given more than once")
+		}
+		seen[s.Index] = true
+	}
+
+	// Interpolate the polynomials at x = 0
+	shares = shares[:first.Threshold]
+	secret := make([]byte, len(first.data))
+	for i, si := range shares {
+		var basis byte = 1
+		for j, sj := range shares {
+			if i != j {
+				basis = gfMul(basis, gfDiv(sj.Index, si.Index^sj.Index))
+			}
+		}
+
+		for pos := range secret {
+			secret[pos] ^= gfMul(si.data[pos], basis)
+		}
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+// Hex returns the share encoded as a hex string
+func (s KeyShare) Hex() string {
+	return hex.EncodeToString(s.serialize())
+}
+
+// Mnemonic returns the share encoded as a list of words
+func (s KeyShare) Mnemonic() string {
+	shareBytes := s.serialize()
+	data := binary.BigEndian.AppendUint16(nil, uint16(len(shareBytes)))
+	data = append(data, shareBytes...)
+
+	var words []string
+	var acc, bits uint
+	for _, b := range data {
+		acc = acc<<8 | uint(b)
+		bits += 8
+		for bits >= mnemonicBitsPerWord {
+			bits -= mnemonicBitsPerWord
+			words = append(words, wordList[acc>>bits&0x7ff])
+		}
+	}
+	if bits > 0 {
+		words = append(words, wordList[acc<<(mnemonicBitsPerWord-bits)&0x7ff])
+	}
+
+	return strings.Join(words, " ")
+}
+
+// ParseKeyShare decodes a share encoded as hex or as a list of words
+func ParseKeyShare(encoded string) (KeyShare, error) {
+	fields := strings.Fields(strings.ToLower(encoded))
+	if len(fields) == 1 {
+		data, err := hex.DecodeString(fields[0])
+		if err != nil {
+			return KeyShare{}, errors.New("invalid key share")
+		}
+		return deserializeKeyShare(data)
+	}
+
+	var data []byte
+	var acc, bits uint
+	for _, word := range fields {
+		index, ok := reverseWordList[word]
+		if !ok {
+			return KeyShare{}, errors.New("unknown word in key share: " + word)
+		}
+
+		acc = acc<<mnemonicBitsPerWord | uint(index)
+		bits += mnemonicBitsPerWord
+		for bits >= 8 {
+			bits -= 8
+			data = append(data, byte(acc>>bits))
+		}
+	}
+
+	if len(data) < 2 {
+		return KeyShare{}, errors.New("invalid key share")
+	}
+
+	length := int(binary.BigEndian.Uint16(data))
+	if len(data)-2 < length {
+		return KeyShare{}, errors.New("truncated key share")
+	}
+
+	return deserializeKeyShare(data[2 : 2+length])
+}
+
+func (s KeyShare) serialize() []byte {
+	data := []byte{keyShareVersion, s.Threshold, s.Index}
+	data = append(data, s.keyID...)
+	data = append(data, s.data...)
+	sum := sha256.Sum256(data)
+	return append(data, sum[:keyShareSumSize]...)
+}
+
+func deserializeKeyShare(data []byte) (KeyShare, error) {
+	if len(data) <= keyShareFixedSize {
+		return KeyShare{}, errors.New("truncated key share")
+	}
+
+	body := data[:len(data)-keyShareSumSize]
+	sum := sha256.Sum256(body)
+	if !bytes.Equal(sum[:keyShareSumSize], data[len(body):]) {
+		return KeyShare{}, errors.New("key share checksum mismatch")
+	}
+
+	if body[0] != keyShareVersion {
+		return KeyShare{}, errors.New("unsupported key share version")
+	}
+
+	s := KeyShare{
+		Threshold: body[1],
+		Index:     body[2],
+		keyID:     append([]byte{}, body[3:3+keyIDSize]...),
+		data:      append([]byte{}, body[3+keyIDSize:]...),
+	}
+	if s.Threshold < 2 || s.Index == 0 {
+		return KeyShare{}, errors.New("invalid key share")
+	}
+
+	return s, nil
+}
+
+// The id is random rather than derived from the key, so that shares below the
+// threshold reveal nothing about it
+func newKeyID() ([]byte, error) {
+	keyID := make([]byte, keyIDSize)
+	_, err := rand.Read(keyID)
+	return keyID, err
+}
+
+// Evaluate the polynomial with the given coefficients at x
+func evaluatePolynomial(coefficients []byte, x byte) byte {
+	var result byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result = gfMul(result, x) ^ coefficients[i]
+	}
+	return result
+}
+
+// Multiply in GF(256) with the AES reduction polynomial
+func gfMul(a, b byte) byte {
+	var product byte
+	for b > 0 {
+		if b&1 == 1 {
+			product ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return product
+}
+
+// Divide in GF(256), b must not be zero
+func gfDiv(a, b byte) byte {
+	// b^254 is the multiplicative inverse of b
+	inverse := byte(1)
+	for i := 0; i < 254; i++ {
+		inverse = gfMul(inverse, b)
+	}
+	return gfMul(a, inverse)
+}
@@ -0,0 +1,90 @@
+package credentials
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitAndCombineKey(t *testing.T) {
+	key := generateKey(t)
+
+	shares, err := SplitKey(key, 5, 3)
+	if err != nil {
+		assert.FailNow(t, "unable to split key: "+err.Error())
+	}
+	assert.Len(t, shares, 5)
+
+	// Any three shares reconstruct the key
+	combined, err := CombineKeyShares([]KeyShare{shares[4], shares[0], shares[2]})
+	assert.NoError(t, err)
+	assert.Equal(t, key, combined)
+
+	combined, err = CombineKeyShares(shares[1:4])
+	assert.NoError(t, err)
+	assert.Equal(t, key, combined)
+
+	// Two shares are not enough
+	_, err = CombineKeyShares(shares[:2])
+	assert.Error(t, err)
+
+	// Shares can't be given twice
+	_, err = CombineKeyShares([]KeyShare{shares[0], shares[0], shares[1]})
+	assert.Error(t, err)
+}
+
+func TestCombineSharesOfDifferentKeys(t *testing.T) {
+	shares, _ := SplitKey(generateKey(t), 3, 2)
+	otherShares, _ := SplitKey(generateKey(t), 3, 2)
+
+	_, err := CombineKeyShares([]KeyShare{shares[0], otherShares[1]})
+	assert.Error(t, err)
+}
+
+func TestKeyIDIsRandom(t *testing.T) {
+	encKey := generateKey(t)
+	shares, _ := SplitKey(encKey, 3, 2)
+	again, _ := SplitKey(encKey, 3, 2)
+
+	// Splitting the same key again doesn't give shares with the same id
+	assert.NotEqual(t, shares[0].keyID, again[0].keyID)
+	_, err := CombineKeyShares([]KeyShare{shares[0], again[1]})
+	assert.Error(t, err)
+}
+
+func TestKeyShareEncoding(t *testing.T) {
+	key := generateKey(t)
+	shares, _ := SplitKey(key, 3, 2)
+
+	fromHex, err := ParseKeyShare(shares[0].Hex())
+	assert.NoError(t, err)
+
+	fromWords, err := ParseKeyShare(" " + shares[1].Mnemonic() + "\n")
+	assert.NoError(t, err)
+
+	combined, err := CombineKeyShares([]KeyShare{fromHex, fromWords})
+	assert.NoError(t, err)
+	assert.Equal(t, key, combined)
+
+	// Typos are detected by the checksum
+	encoded := []byte(shares[2].Hex())
+	encoded[20] ^= 1
+	_, err = ParseKeyShare(string(encoded))
+	assert.Error(t, err)
+
+	_, err = ParseKeyShare("abandon ability notaword")
+	assert.Error(t, err)
+}
+
+func TestSplitKeyRejectsInvalidParameters(t *testing.T) {
+	key := generateKey(t)
+
+	_, err := SplitKey(key, 3, 1)
+	assert.Error(t, err)
+
+	_, err = SplitKey(key, 2, 3)
+	assert.Error(t, err)
+
+	_, err = SplitKey("not hex", 3, 2)
+	assert.Error(t, err)
+}
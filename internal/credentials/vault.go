@@ -0,0 +1,250 @@
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+)
+
+// Name of the profile created for auth files that predate profiles
+const DefaultProfileName = "default"
+
+// Networks a profile can be configured for
+var Networks = []string{"mainnet", "testnet", "signet", "regtest", "simnet"}
+
+// Node implementations a profile can connect to
+const (
+	BackendLND = "lnd"
+	BackendCLN = "cln"
+)
+
+// Profile contains the credentials and connection details of a single node
+type Profile struct {
+	Name        string `json:"name"`
+	Certificate []byte `json:"cert"`
+	Macaroon    []byte `json:"macaroon"`
+	RPCHost     string `json:"rpc_host,omitempty"`
+	Network     string `json:"network,omitempty"`
+	Label       string `json:"label,omitempty"`
+	Proxy       string `json:"proxy,omitempty"`
+	// Node implementation, lnd when empty
+	Backend string `json:"backend,omitempty"`
+	// Client certificate and key Core Lightning's gRPC interface requires.
+	// The certificate field holds the CA certificate of the node.
+	ClientCert []byte `json:"client_cert,omitempty"`
+	ClientKey  []byte `json:"client_key,omitempty"`
+}
+
+// IsCLN indicates whether the profile connects to a Core Lightning node
+func (p Profile) IsCLN() bool {
+	return p.Backend == BackendCLN
+}
+
+// Validate indicates whether the profile holds everything needed to connect to the node
+func (p Profile) Validate() error {
+	if p.Name == "" {
+		return errors.New("profile name required")
+	}
+
+	if p.RPCHost == "" {
+		return errors.New("no RPC host stored for profile " + p.Name)
+	}
+
+	if p.Proxy != "" {
+		if _, _, err := net.SplitHostPort(p.Proxy); err != nil {
+			return fmt.Errorf("invalid proxy address for profile %s: %w", p.Name, err)
+		}
+	}
+
+	switch p.Backend {
+	case "", BackendLND:
+	case BackendCLN:
+		if len(p.Certificate) == 0 || len(p.ClientCert) == 0 || len(p.ClientKey) == 0 {
+			return errors.New("CA certificate, client certificate and client key required for Core Lightning profile " + p.Name)
+		}
+	default:
+		return errors.New("unknown backend " + p.Backend + " for profile " + p.Name)
+	}
+
+	return ValidateNetwork(p.Network)
+}
+
+// ValidateNetwork indicates whether the network name is supported. An empty
+// network name is treated as mainnet.
+func ValidateNetwork(network string) error {
+	if network == "" {
+		return nil
+	}
+
+	for _, n := range Networks {
+		if n == network {
+			return nil
+		}
+	}
+
+	return errors.New("unknown network " + network)
+}
+
+// Vault is a collection of node profiles stored in a single encrypted auth file
+type Vault struct {
+	Profiles []Profile `json:"profiles"`
+	// Outdated is set when the vault was loaded from an auth file in an
+	// earlier format, which should be upgraded with MigrateCredentials
+	Outdated bool `json:"-"`
+}
+
+// Create a profile from a TLS certificate file and a macaroon file
+func NewProfile(name, certificatePath, macaroonPath string) (Profile, error) {
+	certData, err := os.ReadFile(certificatePath)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	macData, err := os.ReadFile(macaroonPath)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	return Profile{Name: name, Certificate: certData, Macaroon: macData}, nil
+}
+
+// Create a Core Lightning profile from the CA certificate, client certificate
+// and client key files of its gRPC interface
+func NewCLNProfile(name, caCertPath, clientCertPath, clientKeyPath string) (Profile, error) {
+	var data [3][]byte
+	for i, path := range []string{caCertPath, clientCertPath, clientKeyPath} {
+		var err error
+		if data[i], err = os.ReadFile(path); err != nil {
+			return Profile{}, err
+		}
+	}
+
+	return Profile{Name: name, Backend: BackendCLN, Certificate: data[0], ClientCert: data[1], ClientKey: data[2]}, nil
+}
+
+// Names returns the sorted profile names
+func (v *Vault) Names() []string {
+	names := make([]string, 0, len(v.Profiles))
+	for _, p := range v.Profiles {
+		names = append(names, p.Name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Get the profile with the given name
+func (v *Vault) Get(name string) (*Profile, error) {
+	i := v.indexOf(name)
+	if i < 0 {
+		return nil, errors.New("no profile named " + name)
+	}
+
+	return &v.Profiles[i], nil
+}
+
+// Add a profile. Profile names must be unique.
+func (v *Vault) Add(p Profile) error {
+	if p.Name == "" {
+		return errors.New("profile name required")
+	}
+
+	if v.indexOf(p.Name) >= 0 {
+		return errors.New("a profile named " + p.Name + " already exists")
+	}
+
+	v.Profiles = append(v.Profiles, p)
+	return nil
+}
+
+// Remove the profile with the given name
+func (v *Vault) Remove(name string) error {
+	i := v.indexOf(name)
+	if i < 0 {
+		return errors.New("no profile named " + name)
+	}
+
+	v.Profiles = append(v.Profiles[:i], v.Profiles[i+1:]...)
+	return nil
+}
+
+// Rename a profile
+func (v *Vault) Rename(oldName, newName string) error {
+	if newName == "" {
+		return errors.New("profile name required")
+	}
+
+	i := v.indexOf(oldName)
+	if i < 0 {
+		return errors.New("no profile named " + oldName)
+	}
+
+	if oldName != newName && v.indexOf(newName) >= 0 {
+		return errors.New("a profile named " + newName + " already exists")
+	}
+
+	v.Profiles[i].Name = newName
+	return nil
+}
+
+func (v *Vault) indexOf(name string) int {
+	for i, p := range v.Profiles {
+		if p.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Save encrypts the vault with the key and writes it to path
+func (v *Vault) Save(key Key, path string) error {
+	encryptedData, err := sealVault(key, v)
+	if err != nil {
+		return err
+	}
+
+	return writeAuthFile(path, encryptedData)
+}
+
+// Load and decrypt the vault stored at path. Auth files written by earlier
+// versions are returned as a vault with a single default profile.
+func LoadVault(key Key, path string) (*Vault, error) {
+	encryptedData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := openVault(key, encryptedData)
+	if err != nil {
+		return nil, &FileError{Path: path, Err: err}
+	}
+	v.Outdated = isOutdatedFormat(encryptedData)
+
+	return v, nil
+}
+
+// Create a vault holding a single default profile
+func newSingleProfileVault(certBytes, macBytes []byte) *Vault {
+	return &Vault{Profiles: []Profile{{
+		Name:        DefaultProfileName,
+		Certificate: certBytes,
+		Macaroon:    macBytes,
+	}}}
+}
+
+func marshalVault(v *Vault) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func unmarshalVault(data []byte) (*Vault, error) {
+	v := &Vault{}
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptedFile, err)
+	}
+
+	return v, nil
+}
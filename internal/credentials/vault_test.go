@@ -0,0 +1,73 @@
+package credentials
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVaultProfiles(t *testing.T) {
+	v := &Vault{}
+
+	assert.NoError(t, v.Add(Profile{Name: "bravo", RPCHost: "bravo:10009"}))
+	assert.NoError(t, v.Add(Profile{Name: "alpha", RPCHost: "alpha:10009"}))
+	assert.Error(t, v.Add(Profile{Name: "alpha"}))
+	assert.Error(t, v.Add(Profile{}))
+
+	assert.Equal(t, []string{"alpha", "bravo"}, v.Names())
+
+	assert.NoError(t, v.Rename("bravo", "charlie"))
+	assert.Error(t, v.Rename("charlie", "alpha"))
+	assert.Error(t, v.Rename("bravo", "delta"))
+
+	p, err := v.Get("charlie")
+	if err != nil {
+		assert.FailNow(t, "profile not found: "+err.Error())
+	}
+	assert.Equal(t, "bravo:10009", p.RPCHost)
+
+	assert.NoError(t, v.Remove("alpha"))
+	assert.Error(t, v.Remove("alpha"))
+	assert.Equal(t, []string{"charlie"}, v.Names())
+}
+
+func TestVaultSaveAndLoad(t *testing.T) {
+	key := Key{Keyset: generateKey(t)}
+	path := filepath.Join(t.TempDir(), "auth.bin")
+
+	v := &Vault{}
+	assert.NoError(t, v.Add(Profile{Name: "alpha", Certificate: []byte("cert"), Macaroon: []byte("mac"),
+		RPCHost: "alpha:10009", Network: "testnet"}))
+	assert.NoError(t, v.Add(Profile{Name: "bravo", Certificate: []byte("cert2"), Macaroon: []byte("mac2")}))
+
+	if err := v.Save(key, path); err != nil {
+		assert.FailNow(t, "unable to save vault: "+err.Error())
+	}
+
+	loaded, err := LoadVault(key, path)
+	if err != nil {
+		assert.FailNow(t, "unable to load vault: "+err.Error())
+	}
+	assert.Equal(t, v, loaded)
+
+	_, err = LoadVault(Key{Keyset: generateKey(t)}, path)
+	assert.Error(t, err)
+
+	_, err = LoadVault(Key{Passphrase: []byte("passphrase")}, path)
+	assert.Error(t, err)
+}
+
+func TestProfileValidation(t *testing.T) {
+	assert.NoError(t, Profile{Name: "alpha", RPCHost: "alpha:10009"}.Validate())
+	assert.NoError(t, Profile{Name: "alpha", RPCHost: "alpha:10009", Network: "signet"}.Validate())
+	assert.Error(t, Profile{Name: "alpha", RPCHost: "alpha:10009", Network: "testnet4"}.Validate())
+	assert.NoError(t, Profile{Name: "alpha", RPCHost: "alpha.onion:10009", Proxy: "127.0.0.1:9050"}.Validate())
+	assert.Error(t, Profile{Name: "alpha", RPCHost: "alpha.onion:10009", Proxy: "127.0.0.1"}.Validate())
+	assert.NoError(t, Profile{Name: "alpha", RPCHost: "alpha:9736", Backend: BackendCLN,
+		Certificate: []byte("ca"), ClientCert: []byte("cert"), ClientKey: []byte("key")}.Validate())
+	assert.Error(t, Profile{Name: "alpha", RPCHost: "alpha:9736", Backend: BackendCLN, Certificate: []byte("ca")}.Validate())
+	assert.Error(t, Profile{Name: "alpha", RPCHost: "alpha:10009", Backend: "eclair"}.Validate())
+	assert.Error(t, Profile{Name: "alpha"}.Validate())
+	assert.Error(t, Profile{RPCHost: "alpha:10009"}.Validate())
+}
@@ -0,0 +1,143 @@
+package demo
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/lightninglabs/lndclient"
+	"github.com/lightningnetwork/lnd/routing/route"
+)
+
+func (n *Node) GetChannels(ctx context.Context) ([]lnd.Channel, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var channels []lnd.Channel
+	for _, c := range n.channels {
+		info := c.info
+		info.PendingHtlcs = append([]lndclient.PendingHtlc(nil), c.info.PendingHtlcs...)
+		channels = append(channels, lnd.Channel{Info: info, Alias: c.alias})
+	}
+
+	return channels, nil
+}
+
+func (n *Node) GetPendingChannels(ctx context.Context) ([]lnd.PendingChannel, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var pendingChannels []lnd.PendingChannel
+	for _, p := range n.pending {
+		pendingChannels = append(pendingChannels, p.PendingChannel)
+	}
+
+	return pendingChannels, nil
+}
+
+func (n *Node) GetChannelEdge(ctx context.Context, channelID uint64) (*lndclient.ChannelEdge, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, c := range n.channels {
+		if c.info.ChannelID != channelID {
+			continue
+		}
+
+		localPolicy, remotePolicy := c.localPolicy, c.remotePolicy
+		edge := &lndclient.ChannelEdge{
+			ChannelID:    channelID,
+			ChannelPoint: c.info.ChannelPoint,
+			Capacity:     c.info.Capacity,
+			Node1:        route.NewVertex(n.key.PubKey()),
+			Node2:        c.info.PubKeyBytes,
+			Node1Policy:  &localPolicy,
+			Node2Policy:  &remotePolicy,
+		}
+		return edge, nil
+	}
+
+	return nil, &lnd.RPCError{Op: "get channel info", Err: errors.New("edge not found")}
+}
+
+func (n *Node) UpdateChannelPolicy(ctx context.Context, channelPoint string, policy lndclient.PolicyUpdateRequest) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	c, err := n.findChannel(channelPoint)
+	if err != nil {
+		return err
+	}
+
+	c.localPolicy.FeeBaseMsat = policy.BaseFeeMsat
+	c.localPolicy.FeeRateMilliMsat = int64(math.Round(policy.FeeRate * 1e6))
+	c.localPolicy.TimeLockDelta = policy.TimeLockDelta
+	if policy.MaxHtlcMsat > 0 {
+		c.localPolicy.MaxHtlcMsat = policy.MaxHtlcMsat
+	}
+	if policy.MinHtlcMsatSpecified {
+		c.localPolicy.MinHtlcMsat = int64(policy.MinHtlcMsat)
+	}
+
+	return nil
+}
+
+// CloseChannel moves the channel to the pending channels. The closing
+// transaction confirms a few ticks later, after the funds of a force close
+// are swept.
+func (n *Node) CloseChannel(ctx context.Context, channelPoint string, force bool, targetBlocks int32) (
+	<-chan lndclient.CloseChannelUpdate, <-chan error, error) {
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	c, err := n.findChannel(channelPoint)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var remaining []*channel
+	for _, other := range n.channels {
+		if other != c {
+			remaining = append(remaining, other)
+		}
+	}
+	n.channels = remaining
+
+	updates := make(chan lndclient.CloseChannelUpdate, 2)
+	pending := &pendingChannel{
+		PendingChannel: lnd.PendingChannel{
+			Capacity:     c.info.Capacity,
+			LocalBalance: c.info.LocalBalance,
+			Type:         lnd.CooperativeClosure,
+			Alias:        c.alias,
+		},
+		ticksLeft: 3,
+		closeTx:   chainhash.Hash(n.randomHash()),
+		closed:    updates,
+	}
+	if force {
+		pending.Type = lnd.ForceClosure
+		pending.LimboBalance = c.info.LocalBalance
+		pending.BlocksUntilMaturity = 10
+		pending.ticksLeft = 10
+	}
+	n.pending = append(n.pending, pending)
+	n.emit(lnd.NodeEvent{Kind: lnd.ChannelClosed, ChannelPoint: channelPoint})
+
+	updates <- &lndclient.PendingCloseUpdate{CloseTx: pending.closeTx}
+	return updates, make(chan error), nil
+}
+
+// Find the channel with the funding outpoint, called with the lock held
+func (n *Node) findChannel(channelPoint string) (*channel, error) {
+	for _, c := range n.channels {
+		if c.info.ChannelPoint == channelPoint {
+			return c, nil
+		}
+	}
+
+	return nil, errors.New("no channel with channel point " + channelPoint)
+}
@@ -0,0 +1,35 @@
+package demo
+
+import (
+	"context"
+
+	"github.com/ardevd/flash/internal/lnd"
+)
+
+func (n *Node) SubscribeEvents(ctx context.Context) (<-chan lnd.NodeEvent, <-chan error, error) {
+	events := make(chan lnd.NodeEvent, 16)
+
+	n.mu.Lock()
+	n.eventSubscribers[events] = struct{}{}
+	n.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		n.mu.Lock()
+		delete(n.eventSubscribers, events)
+		n.mu.Unlock()
+	}()
+
+	return events, make(chan error), nil
+}
+
+// Send the event to the subscribers, dropping it for those that fall behind.
+// Called with the lock held.
+func (n *Node) emit(event lnd.NodeEvent) {
+	for subscriber := range n.eventSubscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
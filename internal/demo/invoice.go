@@ -0,0 +1,293 @@
+package demo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/lightninglabs/lndclient"
+	"github.com/lightningnetwork/lnd/invoices"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/lightningnetwork/lnd/zpay32"
+)
+
+// Networks of the invoices the demo node decodes
+var invoiceNetworks = []*chaincfg.Params{
+	&chaincfg.MainNetParams, &chaincfg.TestNet3Params, &chaincfg.SigNetParams, &chaincfg.RegressionNetParams,
+}
+
+type invoice struct {
+	lndclient.Invoice
+	// When a simulated payer pays the invoice
+	payAt time.Time
+}
+
+// CreateInvoice creates a signed invoice, which a simulated payer pays after
+// a few seconds
+func (n *Node) CreateInvoice(ctx context.Context, memo string, satsAmount uint64, expiry int64) (lntypes.Hash, string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var preimage lntypes.Preimage
+	var paymentAddr [32]byte
+	n.rand.Read(preimage[:])
+	n.rand.Read(paymentAddr[:])
+	hash := preimage.Hash()
+	now := time.Now()
+
+	options := []func(*zpay32.Invoice){
+		zpay32.Description(memo),
+		zpay32.Expiry(time.Duration(expiry) * time.Second),
+		zpay32.PaymentAddr(paymentAddr),
+	}
+	if satsAmount > 0 {
+		options = append(options, zpay32.Amount(lnwire.MilliSatoshi(satsAmount*1000)))
+	}
+
+	bolt11, err := zpay32.NewInvoice(&chaincfg.MainNetParams, hash, now, options...)
+	if err != nil {
+		return lntypes.Hash{}, "", &lnd.RPCError{Op: "add invoice", Err: err}
+	}
+
+	paymentRequest, err := bolt11.Encode(zpay32.MessageSigner{
+		SignCompact: func(msg []byte) ([]byte, error) {
+			return ecdsa.SignCompact(n.key, chainhash.HashB(msg), true)
+		},
+	})
+	if err != nil {
+		return lntypes.Hash{}, "", &lnd.RPCError{Op: "add invoice", Err: err}
+	}
+
+	inv := &invoice{
+		Invoice: lndclient.Invoice{
+			Preimage:       &preimage,
+			Hash:           hash,
+			Memo:           memo,
+			PaymentRequest: paymentRequest,
+			Amount:         lnwire.MilliSatoshi(satsAmount * 1000),
+			CreationDate:   now,
+			State:          invoices.ContractOpen,
+			AddIndex:       uint64(len(n.invoices) + 1),
+		},
+		payAt: now.Add(n.payDelay),
+	}
+	n.invoices = append(n.invoices, inv)
+	n.notify(inv.Invoice)
+
+	return hash, paymentRequest, nil
+}
+
+// Let the simulated payers pay the invoices that are due into the channel
+// with the most inbound liquidity. Invoices without an amount get a random
+// one.
+func (n *Node) settleInvoices(now time.Time) {
+	for _, inv := range n.invoices {
+		if inv.State != invoices.ContractOpen || now.Before(inv.payAt) {
+			continue
+		}
+
+		amount := inv.Amount.ToSatoshis()
+		if amount == 0 {
+			amount = n.randomAmount(1_000, 50_000)
+		}
+
+		var into *channel
+		for _, c := range n.channels {
+			if c.info.Active && c.info.RemoteBalance >= amount && (into == nil || c.info.RemoteBalance > into.info.RemoteBalance) {
+				into = c
+			}
+		}
+		if into == nil {
+			continue
+		}
+
+		into.info.RemoteBalance -= amount
+		into.info.LocalBalance += amount
+		into.info.TotalReceived += amount
+
+		inv.State = invoices.ContractSettled
+		inv.AmountPaid = lnwire.NewMSatFromSatoshis(amount)
+		inv.SettleDate = now
+		n.settleIndex++
+		inv.SettleIndex = n.settleIndex
+		n.notify(inv.Invoice)
+		n.emit(lnd.NodeEvent{Kind: lnd.BalanceChanged})
+	}
+}
+
+// Send the invoice update to the subscribers, dropping it for those that
+// fall behind. Called with the lock held.
+func (n *Node) notify(update lndclient.Invoice) {
+	for subscriber := range n.subscribers {
+		update := update
+		select {
+		case subscriber <- &update:
+		default:
+		}
+	}
+}
+
+func (n *Node) SubscribeInvoices(ctx context.Context) (<-chan *lndclient.Invoice, <-chan error, error) {
+	updates := make(chan *lndclient.Invoice, 16)
+
+	n.mu.Lock()
+	n.subscribers[updates] = struct{}{}
+	n.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		n.mu.Lock()
+		delete(n.subscribers, updates)
+		n.mu.Unlock()
+	}()
+
+	return updates, make(chan error), nil
+}
+
+func (n *Node) DecodeInvoice(ctx context.Context, invoice string) (*lndclient.PaymentRequest, error) {
+	bolt11, err := decodeInvoice(invoice)
+	if err != nil {
+		return nil, &lnd.RPCError{Op: "decode invoice", Err: err}
+	}
+
+	request := &lndclient.PaymentRequest{
+		Destination: route.NewVertex(bolt11.Destination),
+		Hash:        *bolt11.PaymentHash,
+		Timestamp:   bolt11.Timestamp,
+		Expiry:      bolt11.Timestamp.Add(bolt11.Expiry()),
+	}
+	if bolt11.MilliSat != nil {
+		request.Value = *bolt11.MilliSat
+	}
+	if bolt11.Description != nil {
+		request.Description = *bolt11.Description
+	}
+	if bolt11.PaymentAddr != nil {
+		request.PaymentAddress = *bolt11.PaymentAddr
+	}
+
+	return request, nil
+}
+
+// PayInvoice pays any valid invoice with an amount from a channel with enough
+// balance, after a short delay for finding a route
+func (n *Node) PayInvoice(ctx context.Context, invoice string, maxFee btcutil.Amount) (lndclient.PaymentResult, error) {
+	fail := func(err error) (lndclient.PaymentResult, error) {
+		err = &lnd.RPCError{Op: "pay invoice", Err: err}
+		return lndclient.PaymentResult{Err: err}, err
+	}
+
+	bolt11, err := decodeInvoice(invoice)
+	if err != nil {
+		return fail(err)
+	}
+
+	if bolt11.MilliSat == nil || *bolt11.MilliSat == 0 {
+		return fail(errors.New("amount must be specified when paying a zero amount invoice"))
+	}
+
+	if route.NewVertex(bolt11.Destination) == route.NewVertex(n.key.PubKey()) {
+		return fail(errors.New("no self-payments allowed"))
+	}
+
+	select {
+	case <-ctx.Done():
+		return fail(ctx.Err())
+	case <-time.After(n.payDelay / 8):
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	amount := bolt11.MilliSat.ToSatoshis()
+	fee := min(maxFee, 1+amount/1_000)
+	payment, ok := n.pay(amount, fee, time.Now())
+	if !ok {
+		return fail(errors.New("insufficient local balance"))
+	}
+	payment.PaymentRequest = invoice
+	payment.Hash = *bolt11.PaymentHash
+
+	return lndclient.PaymentResult{Preimage: *payment.Preimage, PaidAmt: amount, PaidFee: fee}, nil
+}
+
+// GetPayments returns the latest payments, newest first
+func (n *Node) GetPayments(ctx context.Context, max uint64) ([]lnd.Payment, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var payments []lnd.Payment
+	for i := len(n.payments) - 1; i >= 0 && uint64(len(payments)) < max; i-- {
+		payments = append(payments, lnd.Payment{Payment: *n.payments[i]})
+	}
+
+	return payments, nil
+}
+
+// Record a payment to a simulated payee
+func (n *Node) addPayment(amount btcutil.Amount, at time.Time) {
+	n.pay(amount, 1+amount/2_000, at)
+}
+
+// Pay from the active channel with the most local balance and record the
+// payment. Called with the lock held.
+func (n *Node) pay(amount, fee btcutil.Amount, at time.Time) (*lndclient.Payment, bool) {
+	var from *channel
+	for _, c := range n.channels {
+		if c.info.Active && c.info.LocalBalance >= amount+fee && (from == nil || c.info.LocalBalance > from.info.LocalBalance) {
+			from = c
+		}
+	}
+	if from == nil {
+		return nil, false
+	}
+
+	from.info.LocalBalance -= amount + fee
+	from.info.RemoteBalance += amount + fee
+	from.info.TotalSent += amount + fee
+
+	var preimage lntypes.Preimage
+	n.rand.Read(preimage[:])
+	payment := &lndclient.Payment{
+		Hash:     preimage.Hash(),
+		Preimage: &preimage,
+		Amount:   lnwire.NewMSatFromSatoshis(amount),
+		Fee:      lnwire.NewMSatFromSatoshis(fee),
+		Status:   &lndclient.PaymentStatus{State: lnrpc.Payment_SUCCEEDED},
+		Htlcs: []*lnrpc.HTLCAttempt{{
+			Status:        lnrpc.HTLCAttempt_SUCCEEDED,
+			AttemptTimeNs: at.Add(-time.Second).UnixNano(),
+			ResolveTimeNs: at.UnixNano(),
+		}},
+		SequenceNumber: uint64(len(n.payments) + 1),
+	}
+	payment.Status.Preimage = preimage
+	payment.Status.Fee = payment.Fee
+	payment.Status.Value = payment.Amount
+
+	n.payments = append(n.payments, payment)
+	n.emit(lnd.NodeEvent{Kind: lnd.PaymentSent})
+	return payment, true
+}
+
+func decodeInvoice(invoice string) (*zpay32.Invoice, error) {
+	invoice = lnd.SantizeBoltInvoice(invoice)
+
+	var err error
+	for _, network := range invoiceNetworks {
+		var bolt11 *zpay32.Invoice
+		if bolt11, err = zpay32.Decode(invoice, network); err == nil {
+			return bolt11, nil
+		}
+	}
+
+	return nil, err
+}
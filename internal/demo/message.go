@@ -0,0 +1,93 @@
+package demo
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/lightningnetwork/lnd/routing/route"
+)
+
+// Prefix lnd adds to messages before signing them
+const signedMessagePrefix = "Lightning Signed Message:"
+
+const zbase32Alphabet = "ybndrfg8ejkmcpqxot1uwisza345h769"
+
+// SignMessage signs the message with the node's key the way lnd does, so the
+// signature verifies on real nodes
+func (n *Node) SignMessage(ctx context.Context, message string) (string, error) {
+	signature, err := ecdsa.SignCompact(n.key, chainhash.DoubleHashB([]byte(signedMessagePrefix+message)), true)
+	if err != nil {
+		return "", &lnd.RPCError{Op: "sign message", Err: err}
+	}
+
+	return zbase32Encode(signature), nil
+}
+
+// VerifyMessage recovers the public key of the signing node. Like lnd, the
+// signature is only valid if the node is known, here the demo node or one of
+// its peers.
+func (n *Node) VerifyMessage(ctx context.Context, message, signature string) (bool, string, error) {
+	sig, err := zbase32Decode(signature)
+	if err != nil {
+		return false, "", &lnd.RPCError{Op: "verify message", Err: err}
+	}
+
+	pubKey, _, err := ecdsa.RecoverCompact(sig, chainhash.DoubleHashB([]byte(signedMessagePrefix+message)))
+	if err != nil {
+		return false, "", nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	vertex := route.NewVertex(pubKey)
+	_, known := n.peers[vertex]
+	known = known || vertex == route.NewVertex(n.key.PubKey())
+
+	return known, hex.EncodeToString(pubKey.SerializeCompressed()), nil
+}
+
+// Encode the bytes in the human-oriented base32 encoding lnd uses for
+// signatures
+func zbase32Encode(data []byte) string {
+	var encoded strings.Builder
+	var buffer, bits uint
+	for _, b := range data {
+		buffer = buffer<<8 | uint(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			encoded.WriteByte(zbase32Alphabet[buffer>>bits&31])
+		}
+	}
+	if bits > 0 {
+		encoded.WriteByte(zbase32Alphabet[buffer<<(5-bits)&31])
+	}
+
+	return encoded.String()
+}
+
+func zbase32Decode(encoded string) ([]byte, error) {
+	var decoded []byte
+	var buffer, bits uint
+	for _, r := range encoded {
+		value := strings.IndexRune(zbase32Alphabet, r)
+		if value < 0 {
+			return nil, errors.New("invalid zbase32 character " + string(r))
+		}
+
+		buffer = buffer<<5 | uint(value)
+		bits += 5
+		if bits >= 8 {
+			bits -= 8
+			decoded = append(decoded, byte(buffer>>bits))
+		}
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,366 @@
+package demo
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/lightninglabs/lndclient"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing/route"
+)
+
+var _ lnd.NodeBackend = (*Node)(nil)
+
+// Aliases of the simulated peers
+var peerAliases = []string{
+	"Bolt Badger", "Zap Zebra", "Mempool Mermaid", "Routing Robin", "Hodl Hedgehog",
+	"Channel Cheetah", "Nakamoto Nook", "Fee Dragon", "Lightning Lemonade", "Satoshi's Café",
+	"Blue Heron", "Torrent Tapir",
+}
+
+// Node is a simulated lnd node. Its channels, payments and invoices change
+// while Run is active: payments are made and forwarded, peers go offline,
+// HTLCs come and go, pending channels confirm and invoices get paid.
+type Node struct {
+	// Interval between simulated events
+	tickInterval time.Duration
+	// Simulated time for an invoice to get paid and a payment to complete
+	payDelay time.Duration
+
+	mu          sync.Mutex
+	rand        *rand.Rand
+	key         *btcec.PrivateKey
+	onChain     btcutil.Amount
+	peers       map[route.Vertex]string
+	channels    []*channel
+	pending     []*pendingChannel
+	payments    []*lndclient.Payment
+	invoices    []*invoice
+	settleIndex uint64
+	subscribers map[chan *lndclient.Invoice]struct{}
+
+	eventSubscribers map[chan lnd.NodeEvent]struct{}
+}
+
+type channel struct {
+	info         lndclient.ChannelInfo
+	alias        string
+	localPolicy  lndclient.RoutingPolicy
+	remotePolicy lndclient.RoutingPolicy
+}
+
+type pendingChannel struct {
+	lnd.PendingChannel
+	// Ticks until the channel confirms, or its funds are swept after a close
+	ticksLeft int
+	// Channel that opens once the funding transaction confirms
+	opening *channel
+	// Closing transaction and the channel receiving its confirmation
+	closeTx chainhash.Hash
+	closed  chan<- lndclient.CloseChannelUpdate
+}
+
+// NewNode generates a node with channels, pending channels and payments. The
+// same seed generates the same node.
+func NewNode(seed int64) *Node {
+	n := &Node{
+		tickInterval: 3 * time.Second,
+		payDelay:     8 * time.Second,
+		rand:         rand.New(rand.NewSource(seed)),
+		peers:        make(map[route.Vertex]string),
+		subscribers:  make(map[chan *lndclient.Invoice]struct{}),
+
+		eventSubscribers: make(map[chan lnd.NodeEvent]struct{}),
+	}
+	n.key = n.newKey()
+	n.onChain = n.randomAmount(500_000, 5_000_000)
+
+	for i, alias := range peerAliases {
+		c := n.newChannel(alias)
+		switch {
+		case i < 9:
+			n.channels = append(n.channels, c)
+		case i == 9:
+			n.pending = append(n.pending, &pendingChannel{
+				PendingChannel: lnd.PendingChannel{Capacity: c.info.Capacity, LocalBalance: c.info.LocalBalance,
+					Type: lnd.PendingOpen, Alias: alias},
+				ticksLeft: 10,
+				opening:   c,
+			})
+		case i == 10:
+			n.pending = append(n.pending, &pendingChannel{
+				PendingChannel: lnd.PendingChannel{Capacity: c.info.Capacity, LocalBalance: c.info.LocalBalance,
+					LimboBalance: c.info.LocalBalance, BlocksUntilMaturity: 144, Type: lnd.ForceClosure, Alias: alias},
+				ticksLeft: 144,
+			})
+		default:
+			n.pending = append(n.pending, &pendingChannel{
+				PendingChannel: lnd.PendingChannel{Capacity: c.info.Capacity, LocalBalance: c.info.LocalBalance,
+					Type: lnd.CooperativeClosure, Alias: alias},
+				ticksLeft: 20,
+			})
+		}
+	}
+
+	// A peer that's offline and one with HTLCs in flight
+	n.channels[3].info.Active = false
+	n.addHtlc(n.channels[1])
+	n.addHtlc(n.channels[1])
+
+	now := time.Now()
+	for i := 0; i < 8; i++ {
+		n.addPayment(n.randomAmount(1_000, 250_000), now.Add(-time.Duration(8-i)*time.Hour))
+	}
+
+	return n
+}
+
+// Run simulates activity on the node until the context is canceled
+func (n *Node) Run(ctx context.Context) {
+	ticker := time.NewTicker(n.tickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			n.tick(now)
+		}
+	}
+}
+
+// Simulate one event and let time pass for channels and invoices
+func (n *Node) tick(now time.Time) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, c := range n.channels {
+		c.info.LifeTime += n.tickInterval
+		if c.info.Active {
+			c.info.Uptime += n.tickInterval
+		}
+	}
+
+	n.settleInvoices(now)
+	n.confirmPendingChannels()
+
+	switch event := n.rand.Intn(10); {
+	case event < 4:
+		n.forward()
+	case event < 6:
+		n.addPayment(n.randomAmount(1_000, 100_000), now)
+	case event < 7 && len(n.channels) > 0:
+		c := n.channels[n.rand.Intn(len(n.channels))]
+		c.info.Active = !c.info.Active
+		if c.info.Active {
+			n.emit(lnd.NodeEvent{Kind: lnd.ChannelActive, ChannelPoint: c.info.ChannelPoint})
+		} else {
+			n.emit(lnd.NodeEvent{Kind: lnd.ChannelInactive, ChannelPoint: c.info.ChannelPoint})
+		}
+	case event < 9 && len(n.channels) > 0:
+		c := n.channels[n.rand.Intn(len(n.channels))]
+		if len(c.info.PendingHtlcs) > 0 {
+			c.info.PendingHtlcs = c.info.PendingHtlcs[1:]
+			c.info.NumPendingHtlcs--
+		} else {
+			n.addHtlc(c)
+		}
+		n.emit(lnd.NodeEvent{Kind: lnd.BalanceChanged})
+	}
+}
+
+// Route a payment through two active channels, earning the fee of the
+// outgoing channel
+func (n *Node) forward() {
+	if len(n.channels) == 0 {
+		return
+	}
+
+	in := n.channels[n.rand.Intn(len(n.channels))]
+	out := n.channels[n.rand.Intn(len(n.channels))]
+	if in == out || !in.info.Active || !out.info.Active {
+		return
+	}
+
+	amt := n.randomAmount(5_000, 200_000)
+	fee := btcutil.Amount(out.localPolicy.FeeBaseMsat/1000) + amt*btcutil.Amount(out.localPolicy.FeeRateMilliMsat)/1_000_000
+	if in.info.RemoteBalance < amt+fee || out.info.LocalBalance < amt {
+		return
+	}
+
+	in.info.RemoteBalance -= amt + fee
+	in.info.LocalBalance += amt + fee
+	in.info.TotalReceived += amt + fee
+	out.info.LocalBalance -= amt
+	out.info.RemoteBalance += amt
+	out.info.TotalSent += amt
+	in.info.NumUpdates++
+	out.info.NumUpdates++
+	n.emit(lnd.NodeEvent{Kind: lnd.BalanceChanged})
+}
+
+// Open pending channels once they confirmed and finish force closes once the
+// funds are swept
+func (n *Node) confirmPendingChannels() {
+	var stillPending []*pendingChannel
+	for _, p := range n.pending {
+		p.ticksLeft--
+		if p.Type == lnd.ForceClosure {
+			p.BlocksUntilMaturity = int32(p.ticksLeft)
+		}
+
+		if p.ticksLeft > 0 {
+			stillPending = append(stillPending, p)
+			continue
+		}
+
+		switch {
+		case p.opening != nil:
+			n.channels = append(n.channels, p.opening)
+			opened := lnd.Channel{Info: p.opening.info, Alias: p.opening.alias}
+			n.emit(lnd.NodeEvent{Kind: lnd.ChannelOpened, ChannelPoint: opened.Info.ChannelPoint, Channel: &opened})
+		case p.Type == lnd.ForceClosure:
+			n.onChain += p.LimboBalance
+			n.emit(lnd.NodeEvent{Kind: lnd.OnChainChanged})
+		default:
+			n.onChain += p.LocalBalance
+			n.emit(lnd.NodeEvent{Kind: lnd.OnChainChanged})
+		}
+		n.emit(lnd.NodeEvent{Kind: lnd.ChannelPending})
+
+		if p.closed != nil {
+			p.closed <- &lndclient.ChannelClosedUpdate{CloseTx: p.closeTx}
+			close(p.closed)
+		}
+	}
+
+	n.pending = stillPending
+}
+
+func (n *Node) GetNode(ctx context.Context) (lnd.Node, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	node := lnd.Node{
+		Implementation: lnd.ImplementationLND,
+		Alias:          "flash-demo",
+		PubKey:         route.NewVertex(n.key.PubKey()).String(),
+		Version:        "0.17.4-beta demo",
+		Network:        "mainnet",
+		OnChainBalance: n.onChain,
+	}
+
+	for _, c := range n.channels {
+		node.ChannelBalance += c.info.LocalBalance
+		node.TotalCapacity += c.info.Capacity
+	}
+
+	return node, nil
+}
+
+func (n *Node) GetNodeAlias(ctx context.Context, pubKey route.Vertex) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.peers[pubKey]
+}
+
+// GetPermissions allows everything, the demo node has no macaroon
+func (n *Node) GetPermissions(ctx context.Context) lnd.Permissions {
+	return lnd.Permissions{}
+}
+
+// Close does nothing, the node only lives in memory
+func (n *Node) Close() {}
+
+// Generate a key from the random source, so the same seed gives the same keys
+func (n *Node) newKey() *btcec.PrivateKey {
+	keyBytes := make([]byte, 32)
+	n.rand.Read(keyBytes)
+	key, _ := btcec.PrivKeyFromBytes(keyBytes)
+
+	return key
+}
+
+func (n *Node) randomAmount(min, max int64) btcutil.Amount {
+	return btcutil.Amount(min + n.rand.Int63n(max-min))
+}
+
+func (n *Node) randomHash() lntypes.Hash {
+	var hash lntypes.Hash
+	n.rand.Read(hash[:])
+
+	return hash
+}
+
+// Generate a channel with the peer, part of the capacity on the local side
+func (n *Node) newChannel(alias string) *channel {
+	pubKey := route.NewVertex(n.newKey().PubKey())
+	n.peers[pubKey] = alias
+
+	capacity := btcutil.Amount(n.rand.Intn(20)+1) * 500_000
+	local := capacity * btcutil.Amount(n.rand.Intn(90)+5) / 100
+	lifeTime := time.Duration(n.rand.Intn(200)+10) * 24 * time.Hour
+	scid := lnwire.ShortChannelID{
+		BlockHeight: uint32(780_000 + n.rand.Intn(60_000)),
+		TxIndex:     uint32(n.rand.Intn(3_000)),
+		TxPosition:  uint16(n.rand.Intn(4)),
+	}
+
+	return &channel{
+		alias: alias,
+		info: lndclient.ChannelInfo{
+			ChannelPoint:  fmt.Sprintf("%s:%d", n.randomHash(), scid.TxPosition),
+			Active:        true,
+			ChannelID:     scid.ToUint64(),
+			PubKeyBytes:   pubKey,
+			Capacity:      capacity,
+			LocalBalance:  local,
+			RemoteBalance: capacity - local - 3_000,
+			Initiator:     n.rand.Intn(2) == 0,
+			Private:       n.rand.Intn(5) == 0,
+			LifeTime:      lifeTime,
+			Uptime:        lifeTime * time.Duration(n.rand.Intn(15)+85) / 100,
+			TotalSent:     n.randomAmount(0, int64(capacity)),
+			TotalReceived: n.randomAmount(0, int64(capacity)),
+			NumUpdates:    uint64(n.rand.Intn(5_000)),
+			CSVDelay:      144,
+			CommitFee:     3_000,
+		},
+		localPolicy: lndclient.RoutingPolicy{
+			TimeLockDelta:    80,
+			MinHtlcMsat:      1_000,
+			MaxHtlcMsat:      uint64(capacity) * 990,
+			FeeBaseMsat:      1_000,
+			FeeRateMilliMsat: int64(n.rand.Intn(10)+1) * 50,
+			LastUpdate:       time.Now().Add(-lifeTime / 2),
+		},
+		remotePolicy: lndclient.RoutingPolicy{
+			TimeLockDelta:    144,
+			MinHtlcMsat:      1_000,
+			MaxHtlcMsat:      uint64(capacity) * 990,
+			FeeBaseMsat:      int64(n.rand.Intn(2)) * 1_000,
+			FeeRateMilliMsat: int64(n.rand.Intn(20)+1) * 25,
+			LastUpdate:       time.Now().Add(-lifeTime / 3),
+		},
+	}
+}
+
+func (n *Node) addHtlc(c *channel) {
+	c.info.PendingHtlcs = append(c.info.PendingHtlcs, lndclient.PendingHtlc{
+		Incoming: n.rand.Intn(2) == 0,
+		Amount:   n.randomAmount(1_000, 50_000),
+		Hash:     n.randomHash(),
+		Expiry:   uint32(840_000 + n.rand.Intn(200)),
+	})
+	c.info.NumPendingHtlcs++
+}
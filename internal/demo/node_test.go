@@ -0,0 +1,199 @@
+package demo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/lightningnetwork/lnd/invoices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewNodeIsDeterministic(t *testing.T) {
+	ctx := context.Background()
+	a, b := NewNode(42), NewNode(42)
+
+	nodeA, err := a.GetNode(ctx)
+	require.NoError(t, err)
+	nodeB, err := b.GetNode(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, nodeA, nodeB)
+
+	channels, err := a.GetChannels(ctx)
+	require.NoError(t, err)
+	assert.Len(t, channels, 9)
+	assert.False(t, channels[3].Info.Active)
+	assert.Len(t, channels[1].Info.PendingHtlcs, 2)
+	assert.Equal(t, "Bolt Badger", a.GetNodeAlias(ctx, channels[0].Info.PubKeyBytes))
+
+	pending, err := a.GetPendingChannels(ctx)
+	require.NoError(t, err)
+	assert.Len(t, pending, 3)
+
+	payments, err := a.GetPayments(ctx, 5)
+	require.NoError(t, err)
+	assert.Len(t, payments, 5)
+	assert.Greater(t, payments[0].Payment.SequenceNumber, payments[1].Payment.SequenceNumber)
+
+	assert.NotEqual(t, nodeA.PubKey, mustGetNode(t, NewNode(43)).PubKey)
+}
+
+func TestInvoiceGetsPaid(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	n := NewNode(1)
+	n.payDelay = time.Millisecond
+	updates, _, err := n.SubscribeInvoices(ctx)
+	require.NoError(t, err)
+
+	hash, paymentRequest, err := n.CreateInvoice(ctx, "coffee", 2_500, 3600)
+	require.NoError(t, err)
+
+	request, err := n.DecodeInvoice(ctx, paymentRequest)
+	require.NoError(t, err)
+	assert.Equal(t, hash, request.Hash)
+	assert.Equal(t, "coffee", request.Description)
+	assert.EqualValues(t, 2_500_000, request.Value)
+	assert.Equal(t, mustGetNode(t, n).PubKey, request.Destination.String())
+
+	added := <-updates
+	assert.Equal(t, invoices.ContractOpen, added.State)
+
+	n.tick(time.Now().Add(time.Second))
+
+	settled := <-updates
+	assert.Equal(t, paymentRequest, settled.PaymentRequest)
+	assert.Equal(t, invoices.ContractSettled, settled.State)
+	assert.EqualValues(t, 2_500_000, settled.AmountPaid)
+}
+
+func TestPayInvoice(t *testing.T) {
+	ctx := context.Background()
+	payee := NewNode(2)
+	_, paymentRequest, err := payee.CreateInvoice(ctx, "", 10_000, 3600)
+	require.NoError(t, err)
+
+	n := NewNode(3)
+	n.payDelay = time.Millisecond
+	before := mustGetNode(t, n).ChannelBalance
+
+	result, err := n.PayInvoice(ctx, paymentRequest, 100)
+	require.NoError(t, err)
+	assert.EqualValues(t, 10_000, result.PaidAmt)
+	assert.LessOrEqual(t, result.PaidFee, int64(100))
+	assert.Equal(t, before-10_000-result.PaidFee, mustGetNode(t, n).ChannelBalance)
+
+	payments, err := n.GetPayments(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, paymentRequest, payments[0].Payment.PaymentRequest)
+
+	_, ownRequest, err := n.CreateInvoice(ctx, "", 10_000, 3600)
+	require.NoError(t, err)
+	_, err = n.PayInvoice(ctx, ownRequest, 100)
+	assert.ErrorContains(t, err, "self-payments")
+
+	_, err = n.PayInvoice(ctx, "lnbc1invalid", 100)
+	assert.Error(t, err)
+}
+
+func TestCloseChannelEmitsEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	n := NewNode(6)
+	events, _, err := n.SubscribeEvents(ctx)
+	require.NoError(t, err)
+
+	channels, err := n.GetChannels(ctx)
+	require.NoError(t, err)
+	channelPoint := channels[0].Info.ChannelPoint
+
+	updates, _, err := n.CloseChannel(ctx, channelPoint, false, 6)
+	require.NoError(t, err)
+	event := <-events
+	assert.Equal(t, lnd.ChannelClosed, event.Kind)
+	assert.Equal(t, channelPoint, event.ChannelPoint)
+	<-updates
+
+	for i := 0; i < 3; i++ {
+		n.tick(time.Now())
+	}
+	_, ok := <-updates
+	assert.True(t, ok)
+	_, ok = <-updates
+	assert.False(t, ok)
+}
+
+func TestTickWithoutChannels(t *testing.T) {
+	ctx := context.Background()
+	n := NewNode(7)
+
+	channels, err := n.GetChannels(ctx)
+	require.NoError(t, err)
+	for _, c := range channels {
+		_, _, err := n.CloseChannel(ctx, c.Info.ChannelPoint, false, 6)
+		require.NoError(t, err)
+	}
+
+	// The pending channel opens on the tenth tick
+	for i := 0; i < 9; i++ {
+		n.tick(time.Now())
+	}
+	assert.Zero(t, mustGetNode(t, n).ChannelBalance)
+}
+
+func TestSettleIndex(t *testing.T) {
+	ctx := context.Background()
+	n := NewNode(8)
+	n.payDelay = time.Millisecond
+
+	for i := 0; i < 2; i++ {
+		_, _, err := n.CreateInvoice(ctx, "", 1_000, 3600)
+		require.NoError(t, err)
+	}
+	n.tick(time.Now().Add(time.Second))
+
+	assert.EqualValues(t, 1, n.invoices[0].SettleIndex)
+	assert.EqualValues(t, 2, n.invoices[1].SettleIndex)
+}
+
+func TestSignAndVerifyMessage(t *testing.T) {
+	ctx := context.Background()
+	n := NewNode(4)
+
+	signature, err := n.SignMessage(ctx, "hello")
+	require.NoError(t, err)
+
+	valid, pubKey, err := n.VerifyMessage(ctx, "hello", signature)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, mustGetNode(t, n).PubKey, pubKey)
+
+	valid, pubKey, err = NewNode(5).VerifyMessage(ctx, "hello", signature)
+	require.NoError(t, err)
+	assert.False(t, valid)
+	assert.Equal(t, mustGetNode(t, n).PubKey, pubKey)
+
+	_, _, err = n.VerifyMessage(ctx, "hello", "not zbase32!")
+	assert.Error(t, err)
+}
+
+func TestZbase32(t *testing.T) {
+	assert.Equal(t, "6n9hq", zbase32Encode([]byte{0xf0, 0xbf, 0xc7}))
+	assert.Equal(t, "4t7ye", zbase32Encode([]byte{0xd4, 0x7a, 0x04}))
+
+	data := []byte("Lightning Signed Message")
+	decoded, err := zbase32Decode(zbase32Encode(data))
+	require.NoError(t, err)
+	assert.Equal(t, data, decoded)
+}
+
+func mustGetNode(t *testing.T, n *Node) lnd.Node {
+	node, err := n.GetNode(context.Background())
+	require.NoError(t, err)
+
+	return node
+}
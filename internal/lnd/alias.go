@@ -0,0 +1,156 @@
+package lnd
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lightningnetwork/lnd/routing/route"
+)
+
+const (
+	// Number of aliases looked up at the same time
+	aliasWorkers = 8
+
+	// Time a single alias lookup may take before the alias is left empty
+	aliasTimeout = 10 * time.Second
+
+	// Age after which an alias is looked up again. The old one is kept if
+	// the lookup fails.
+	aliasMaxAge = 24 * time.Hour
+)
+
+// AliasLookup returns the alias of the node, empty and no error if the node
+// is unknown
+type AliasLookup func(ctx context.Context, pubKey route.Vertex) (string, error)
+
+// CachedAlias is an alias and the time it was looked up
+type CachedAlias struct {
+	Alias string
+	Found time.Time
+}
+
+// AliasCache keeps the aliases of nodes that were looked up, so refreshes,
+// reconnects and channels with the same peer don't ask the node again.
+// Lookups that fail are tried again the next time.
+type AliasCache struct {
+	mu      sync.Mutex
+	aliases map[route.Vertex]CachedAlias
+}
+
+func NewAliasCache() *AliasCache {
+	return &AliasCache{aliases: make(map[route.Vertex]CachedAlias)}
+}
+
+// Get returns the alias of the node, empty if it is unknown or the lookup
+// failed
+func (c *AliasCache) Get(ctx context.Context, pubKey route.Vertex, lookup AliasLookup) string {
+	c.mu.Lock()
+	cached, ok := c.aliases[pubKey]
+	c.mu.Unlock()
+	if ok && time.Since(cached.Found) < aliasMaxAge {
+		return cached.Alias
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, aliasTimeout)
+	defer cancel()
+
+	alias, err := lookup(ctx, pubKey)
+	if err != nil {
+		return cached.Alias
+	}
+
+	c.mu.Lock()
+	c.aliases[pubKey] = CachedAlias{Alias: alias, Found: time.Now()}
+	c.mu.Unlock()
+
+	return alias
+}
+
+// Resolve looks up the aliases of the nodes with a bounded number of workers
+// and reports the progress under the stage. The aliases are returned in the
+// order of the public keys.
+func (c *AliasCache) Resolve(ctx context.Context, stage string, pubKeys []route.Vertex, lookup AliasLookup) []string {
+	aliases := make([]string, len(pubKeys))
+	if len(pubKeys) == 0 {
+		return aliases
+	}
+
+	// Count under a lock so the progress is reported in order
+	var mu sync.Mutex
+	done := 0
+	reportProgress(ctx, stage, done, len(pubKeys))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(aliasWorkers, len(pubKeys)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				aliases[i] = c.Get(ctx, pubKeys[i], lookup)
+
+				mu.Lock()
+				done++
+				reportProgress(ctx, stage, done, len(pubKeys))
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range pubKeys {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return aliases
+}
+
+// Entries returns a copy of the cached aliases
+func (c *AliasCache) Entries() map[route.Vertex]CachedAlias {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := make(map[route.Vertex]CachedAlias, len(c.aliases))
+	for pubKey, cached := range c.aliases {
+		entries[pubKey] = cached
+	}
+
+	return entries
+}
+
+// Restore adds aliases looked up earlier, such as those stored on disk.
+// Aliases in the cache that were found later are kept.
+func (c *AliasCache) Restore(entries map[route.Vertex]CachedAlias) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for pubKey, cached := range entries {
+		if current, ok := c.aliases[pubKey]; !ok || current.Found.Before(cached.Found) {
+			c.aliases[pubKey] = cached
+		}
+	}
+}
+
+// Progress of a loading stage, e.g. the aliases of 120 of 340 channels
+// looked up
+type Progress struct {
+	Stage string
+	Done  int
+	Total int
+}
+
+type progressKey struct{}
+
+// WithProgress returns a context that reports the progress of the calls made
+// with it to the function
+func WithProgress(ctx context.Context, report func(Progress)) context.Context {
+	return context.WithValue(ctx, progressKey{}, report)
+}
+
+func reportProgress(ctx context.Context, stage string, done, total int) {
+	if report, ok := ctx.Value(progressKey{}).(func(Progress)); ok {
+		report(Progress{Stage: stage, Done: done, Total: total})
+	}
+}
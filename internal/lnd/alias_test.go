@@ -0,0 +1,89 @@
+package lnd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAliasCacheResolve(t *testing.T) {
+	var lookups, running, maxRunning atomic.Int32
+	lookup := func(ctx context.Context, pubKey route.Vertex) (string, error) {
+		lookups.Add(1)
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+
+		if pubKey[0] == 0xff {
+			return "", errors.New("deadline exceeded")
+		}
+		return string(rune('a' + pubKey[0])), nil
+	}
+	cache := NewAliasCache()
+
+	pubKeys := make([]route.Vertex, 40)
+	for i := range pubKeys {
+		pubKeys[i][0] = byte(i % 20)
+	}
+	pubKeys[39][0] = 0xff
+
+	var mu sync.Mutex
+	var progress []Progress
+	ctx := WithProgress(context.Background(), func(p Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+		progress = append(progress, p)
+	})
+
+	aliases := cache.Resolve(ctx, "channels", pubKeys, lookup)
+	assert.Equal(t, "a", aliases[0])
+	assert.Equal(t, "t", aliases[19])
+	assert.Equal(t, "a", aliases[20])
+	assert.Empty(t, aliases[39])
+	assert.LessOrEqual(t, maxRunning.Load(), int32(aliasWorkers))
+
+	assert.Len(t, progress, len(pubKeys)+1)
+	for i, p := range progress {
+		assert.Equal(t, Progress{Stage: "channels", Done: i, Total: len(pubKeys)}, p)
+	}
+
+	// Found aliases are cached, failed lookups are tried again
+	lookups.Store(0)
+	cache.Resolve(context.Background(), "channels", pubKeys, lookup)
+	assert.EqualValues(t, 1, lookups.Load())
+}
+
+func TestAliasCacheRestore(t *testing.T) {
+	var alice, bob route.Vertex
+	alice[0], bob[0] = 1, 2
+	failing := func(ctx context.Context, pubKey route.Vertex) (string, error) {
+		return "", errors.New("connection refused")
+	}
+
+	cache := NewAliasCache()
+	cache.Restore(map[route.Vertex]CachedAlias{
+		alice: {Alias: "alice", Found: time.Now()},
+		bob:   {Alias: "bob", Found: time.Now().Add(-2 * aliasMaxAge)},
+	})
+	assert.Equal(t, "alice", cache.Get(context.Background(), alice, failing))
+
+	// Outdated aliases are looked up again and kept if that fails
+	assert.Equal(t, "bob", cache.Get(context.Background(), bob, failing))
+	renamed := func(ctx context.Context, pubKey route.Vertex) (string, error) {
+		return "robert", nil
+	}
+	assert.Equal(t, "robert", cache.Get(context.Background(), bob, renamed))
+	assert.Equal(t, "robert", cache.Entries()[bob].Alias)
+}
@@ -0,0 +1,82 @@
+package lnd
+
+import (
+	"context"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/lightninglabs/lndclient"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/routing/route"
+)
+
+// NodeBackend covers the node operations flash uses. Node implementations
+// return their errors wrapped in RPCError so callers can tell unavailable
+// nodes and missing permissions apart.
+type NodeBackend interface {
+	// Node info and balances
+	GetNode(ctx context.Context) (Node, error)
+	// Alias of the node with the public key, empty if the node is unknown
+	GetNodeAlias(ctx context.Context, pubKey route.Vertex) string
+	// Permissions of the credentials used to connect
+	GetPermissions(ctx context.Context) Permissions
+
+	GetChannels(ctx context.Context) ([]Channel, error)
+	GetPendingChannels(ctx context.Context) ([]PendingChannel, error)
+	GetChannelEdge(ctx context.Context, channelID uint64) (*lndclient.ChannelEdge, error)
+	UpdateChannelPolicy(ctx context.Context, channelPoint string, policy lndclient.PolicyUpdateRequest) error
+	// Close the channel, the updates end once the closing transaction confirmed
+	CloseChannel(ctx context.Context, channelPoint string, force bool, targetBlocks int32) (
+		<-chan lndclient.CloseChannelUpdate, <-chan error, error)
+
+	// Latest payments, newest first
+	GetPayments(ctx context.Context, max uint64) ([]Payment, error)
+	DecodeInvoice(ctx context.Context, invoice string) (*lndclient.PaymentRequest, error)
+	CreateInvoice(ctx context.Context, memo string, satsAmount uint64, expiry int64) (lntypes.Hash, string, error)
+	// Pay the invoice, spending at most maxFee on routing fees, and wait for
+	// the payment to complete
+	PayInvoice(ctx context.Context, invoice string, maxFee btcutil.Amount) (lndclient.PaymentResult, error)
+	// Updates of added and settled invoices until the context is canceled
+	SubscribeInvoices(ctx context.Context) (<-chan *lndclient.Invoice, <-chan error, error)
+	// Updates of channels, balances and payments until the context is
+	// canceled
+	SubscribeEvents(ctx context.Context) (<-chan NodeEvent, <-chan error, error)
+
+	SignMessage(ctx context.Context, message string) (string, error)
+	// Check the signature of the message and return the public key of the
+	// signing node
+	VerifyMessage(ctx context.Context, message, signature string) (bool, string, error)
+
+	Close()
+}
+
+var (
+	_ NodeBackend = (*LndBackend)(nil)
+	_ NodeBackend = (*Supervisor)(nil)
+)
+
+// LndBackend talks to lnd through lndclient
+type LndBackend struct {
+	services *lndclient.GrpcLndServices
+	macaroon []byte
+	aliases  *AliasCache
+}
+
+// NewLndBackend returns the backend for the connected services. The macaroon
+// is the one the services were created with, it's checked for permissions.
+// The alias cache may be shared with other connections, a new one is used if
+// it is nil.
+func NewLndBackend(services *lndclient.GrpcLndServices, macaroon []byte, aliases *AliasCache) *LndBackend {
+	if aliases == nil {
+		aliases = NewAliasCache()
+	}
+
+	return &LndBackend{services: services, macaroon: macaroon, aliases: aliases}
+}
+
+func (b *LndBackend) GetPermissions(ctx context.Context) Permissions {
+	return GetPermissions(b.services, ctx, b.macaroon)
+}
+
+func (b *LndBackend) Close() {
+	b.services.Close()
+}
@@ -1,11 +1,13 @@
 package lnd
 
 import (
+	"context"
 	"math"
 
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/lightninglabs/lndclient"
+	"github.com/lightningnetwork/lnd/routing/route"
 )
 
 // A wrapper around lndclient's ChannelInfo combined with a node Alias
@@ -14,6 +16,53 @@ type Channel struct {
 	Alias string
 }
 
+// GetChannels lists the channels of the node along with the aliases of the peers
+func (b *LndBackend) GetChannels(ctx context.Context) ([]Channel, error) {
+	infos, err := b.services.Client.ListChannels(ctx, false, false)
+	if err != nil {
+		return nil, newRPCError("list channels", err)
+	}
+
+	pubKeys := make([]route.Vertex, len(infos))
+	for i, chanInfo := range infos {
+		pubKeys[i] = chanInfo.PubKeyBytes
+	}
+	aliases := b.aliases.Resolve(ctx, "channels", pubKeys, b.lookupAlias)
+
+	var channels []Channel
+	for i, chanInfo := range infos {
+		channels = append(channels, Channel{Info: chanInfo, Alias: aliases[i]})
+	}
+
+	return channels, nil
+}
+
+func (b *LndBackend) GetChannelEdge(ctx context.Context, channelID uint64) (*lndclient.ChannelEdge, error) {
+	edge, err := b.services.Client.GetChanInfo(ctx, channelID)
+	return edge, newRPCError("get channel info", err)
+}
+
+func (b *LndBackend) UpdateChannelPolicy(ctx context.Context, channelPoint string, policy lndclient.PolicyUpdateRequest) error {
+	outPoint, err := lndclient.NewOutpointFromStr(channelPoint)
+	if err != nil {
+		return err
+	}
+
+	return newRPCError("update channel policy", b.services.Client.UpdateChanPolicy(ctx, policy, outPoint))
+}
+
+func (b *LndBackend) CloseChannel(ctx context.Context, channelPoint string, force bool, targetBlocks int32) (
+	<-chan lndclient.CloseChannelUpdate, <-chan error, error) {
+
+	outPoint, err := lndclient.NewOutpointFromStr(channelPoint)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updates, errs, err := b.services.Client.CloseChannel(ctx, outPoint, force, targetBlocks, nil)
+	return updates, errs, newRPCError("close channel", err)
+}
+
 // bubbletea interface function
 func (c Channel) FilterValue() string {
 	return c.Alias
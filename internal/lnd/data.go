@@ -7,6 +7,7 @@ type NodeData struct {
 	Channels        []Channel
 	PendingChannels []PendingChannel
 	Payments        []Payment
+	Permissions     Permissions
 }
 
 func (n NodeData) GetChannelsAsListItems(onlyOffline bool) []list.Item {
@@ -35,17 +36,5 @@ func (n NodeData) GetPaymentsAsListItems() []list.Item {
 		paymentItems = append(paymentItems, payment)
 	}
 
-	reverseSlice(paymentItems)
 	return paymentItems
 }
-
-func reverseSlice(slice []list.Item) {
-	// Get the length of the slice
-	length := len(slice)
-
-	// Iterate through the slice up to its midpoint
-	for i := 0; i < length/2; i++ {
-		// Swap elements from the beginning and end of the slice
-		slice[i], slice[length-i-1] = slice[length-i-1], slice[i]
-	}
-}
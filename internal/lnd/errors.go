@@ -0,0 +1,72 @@
+package lnd
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrRPCUnavailable is returned when the node can't be reached
+	ErrRPCUnavailable = errors.New("node unavailable")
+
+	// ErrPermissionDenied is returned when the macaroon does not allow a call
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// RPCError records the node call that failed. It matches ErrRPCUnavailable
+// and ErrPermissionDenied according to the gRPC status of the error.
+type RPCError struct {
+	Op  string
+	Err error
+}
+
+func (e *RPCError) Error() string {
+	return e.Op + ": " + e.Err.Error()
+}
+
+func (e *RPCError) Unwrap() error {
+	return e.Err
+}
+
+func (e *RPCError) Is(target error) bool {
+	switch target {
+	case ErrRPCUnavailable:
+		return isUnavailable(e.Err)
+	case ErrPermissionDenied:
+		return isPermissionDenied(e.Err)
+	}
+
+	return false
+}
+
+// Wrap the error of a node call, nil stays nil
+func newRPCError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &RPCError{Op: op, Err: err}
+}
+
+func isUnavailable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+func isPermissionDenied(err error) bool {
+	switch status.Code(err) {
+	case codes.PermissionDenied, codes.Unauthenticated:
+		return true
+	}
+
+	// lnd rejects macaroons lacking a permission without a status code
+	return strings.Contains(err.Error(), "permission denied")
+}
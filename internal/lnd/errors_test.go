@@ -0,0 +1,31 @@
+package lnd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRPCErrorMatching(t *testing.T) {
+	err := newRPCError("get info", status.Error(codes.Unavailable, "connection refused"))
+	assert.ErrorIs(t, err, ErrRPCUnavailable)
+	assert.NotErrorIs(t, err, ErrPermissionDenied)
+
+	var rpcErr *RPCError
+	if assert.ErrorAs(t, err, &rpcErr) {
+		assert.Equal(t, "get info", rpcErr.Op)
+	}
+
+	err = newRPCError("add invoice", status.Error(codes.Unknown, "verification failed: permission denied"))
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	assert.NotErrorIs(t, err, ErrRPCUnavailable)
+
+	err = newRPCError("get info", errors.New("unexpected"))
+	assert.NotErrorIs(t, err, ErrRPCUnavailable)
+	assert.NotErrorIs(t, err, ErrPermissionDenied)
+
+	assert.NoError(t, newRPCError("get info", nil))
+}
@@ -0,0 +1,190 @@
+package lnd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lightninglabs/lndclient"
+	"github.com/lightningnetwork/lnd/invoices"
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+)
+
+// EventKind tells what changed on the node
+type EventKind int
+
+const (
+	// The channel at ChannelPoint became active or inactive, usually because
+	// the peer connected or disconnected
+	ChannelActive EventKind = iota
+	ChannelInactive
+	// The Channel opened
+	ChannelOpened
+	// The channel at ChannelPoint closed
+	ChannelClosed
+	// A channel started opening or closing
+	ChannelPending
+	// Channel balances changed by a forward or a paid invoice
+	BalanceChanged
+	// A payment of the node completed
+	PaymentSent
+	// An on-chain transaction was received or confirmed
+	OnChainChanged
+)
+
+// NodeEvent is an update pushed by the node
+type NodeEvent struct {
+	Kind         EventKind
+	ChannelPoint string
+	Channel      *Channel
+}
+
+// SubscribeEvents merges the channel, invoice, transaction and HTLC streams
+// of lnd. Streams the macaroon doesn't allow are left out. lndclient has no
+// peer events, peers connecting and disconnecting show as active and
+// inactive channels.
+func (b *LndBackend) SubscribeEvents(ctx context.Context) (<-chan NodeEvent, <-chan error, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	channelUpdates, channelErrs, err := b.services.Client.SubscribeChannelEvents(ctx)
+	if err != nil {
+		cancel()
+		return nil, nil, newRPCError("subscribe channel events", err)
+	}
+
+	invoiceUpdates, invoiceErrs, err := b.services.Client.SubscribeInvoices(ctx, lndclient.InvoiceSubscriptionRequest{})
+	if err != nil {
+		cancel()
+		return nil, nil, newRPCError("subscribe invoices", err)
+	}
+
+	transactions, transactionErrs, err := b.services.Client.SubscribeTransactions(ctx)
+	if err != nil {
+		cancel()
+		return nil, nil, newRPCError("subscribe transactions", err)
+	}
+
+	htlcEvents, htlcErrs, err := b.services.Router.SubscribeHtlcEvents(ctx)
+	if err != nil {
+		cancel()
+		return nil, nil, newRPCError("subscribe htlc events", err)
+	}
+
+	events := make(chan NodeEvent)
+	errs := make(chan error, 1)
+	go func() {
+		defer cancel()
+
+		for {
+			var event NodeEvent
+			var ok bool
+			var streamErr error
+
+			select {
+			case update, open := <-channelUpdates:
+				if !open {
+					errs <- errors.New("channel event stream closed")
+					return
+				}
+				event, ok = b.channelEvent(ctx, update)
+
+			case invoice, open := <-invoiceUpdates:
+				if !open {
+					errs <- errors.New("invoice stream closed")
+					return
+				}
+				event, ok = NodeEvent{Kind: BalanceChanged}, invoice.State == invoices.ContractSettled
+
+			case _, open := <-transactions:
+				if !open {
+					errs <- errors.New("transaction stream closed")
+					return
+				}
+				event, ok = NodeEvent{Kind: OnChainChanged}, true
+
+			case htlc, open := <-htlcEvents:
+				if !open {
+					errs <- errors.New("htlc event stream closed")
+					return
+				}
+				event, ok = htlcEvent(htlc)
+
+			case err, open := <-channelErrs:
+				if !open || isPermissionDenied(err) {
+					channelUpdates, channelErrs = nil, nil
+					continue
+				}
+				streamErr = err
+			case err, open := <-invoiceErrs:
+				if !open || isPermissionDenied(err) {
+					invoiceUpdates, invoiceErrs = nil, nil
+					continue
+				}
+				streamErr = err
+			case err, open := <-transactionErrs:
+				if !open || isPermissionDenied(err) {
+					transactions, transactionErrs = nil, nil
+					continue
+				}
+				streamErr = err
+			case err, open := <-htlcErrs:
+				if !open || isPermissionDenied(err) {
+					htlcEvents, htlcErrs = nil, nil
+					continue
+				}
+				streamErr = err
+
+			case <-ctx.Done():
+				return
+			}
+
+			if streamErr != nil {
+				errs <- newRPCError("subscribe events", streamErr)
+				return
+			}
+
+			if !ok {
+				continue
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, errs, nil
+}
+
+func (b *LndBackend) channelEvent(ctx context.Context, update *lndclient.ChannelEventUpdate) (NodeEvent, bool) {
+	switch update.UpdateType {
+	case lndclient.ActiveChannelUpdate:
+		return NodeEvent{Kind: ChannelActive, ChannelPoint: update.ChannelPoint.String()}, true
+	case lndclient.InactiveChannelUpdate:
+		return NodeEvent{Kind: ChannelInactive, ChannelPoint: update.ChannelPoint.String()}, true
+	case lndclient.OpenChannelUpdate:
+		info := *update.OpenedChannelInfo
+		channel := &Channel{Info: info, Alias: b.GetNodeAlias(ctx, info.PubKeyBytes)}
+		return NodeEvent{Kind: ChannelOpened, ChannelPoint: info.ChannelPoint, Channel: channel}, true
+	case lndclient.ClosedChannelUpdate:
+		return NodeEvent{Kind: ChannelClosed, ChannelPoint: update.ClosedChannelInfo.ChannelPoint}, true
+	case lndclient.PendingOpenChannelUpdate, lndclient.FullyResolvedChannelUpdate:
+		return NodeEvent{Kind: ChannelPending}, true
+	}
+
+	return NodeEvent{}, false
+}
+
+// Settled HTLCs move balances, the ones the node sent complete a payment
+func htlcEvent(htlc *routerrpc.HtlcEvent) (NodeEvent, bool) {
+	if htlc.GetSettleEvent() == nil {
+		return NodeEvent{}, false
+	}
+
+	if htlc.GetEventType() == routerrpc.HtlcEvent_SEND {
+		return NodeEvent{Kind: PaymentSent}, true
+	}
+
+	return NodeEvent{Kind: BalanceChanged}, true
+}
@@ -0,0 +1,25 @@
+package lnd
+
+import (
+	"testing"
+
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHtlcEvent(t *testing.T) {
+	settled := &routerrpc.HtlcEvent_SettleEvent{SettleEvent: &routerrpc.SettleEvent{}}
+	forwarded := &routerrpc.HtlcEvent_ForwardEvent{ForwardEvent: &routerrpc.ForwardEvent{}}
+
+	event, ok := htlcEvent(&routerrpc.HtlcEvent{EventType: routerrpc.HtlcEvent_SEND, Event: settled})
+	assert.True(t, ok)
+	assert.Equal(t, PaymentSent, event.Kind)
+
+	event, ok = htlcEvent(&routerrpc.HtlcEvent{EventType: routerrpc.HtlcEvent_FORWARD, Event: settled})
+	assert.True(t, ok)
+	assert.Equal(t, BalanceChanged, event.Kind)
+
+	// Balances only move once the HTLC settles
+	_, ok = htlcEvent(&routerrpc.HtlcEvent{EventType: routerrpc.HtlcEvent_FORWARD, Event: forwarded})
+	assert.False(t, ok)
+}
@@ -3,20 +3,19 @@ package lnd
 import (
 	"context"
 	"crypto/rand"
-	"log"
 
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/lightninglabs/lndclient"
 	"github.com/lightningnetwork/lnd/lnrpc/invoicesrpc"
 	"github.com/lightningnetwork/lnd/lntypes"
 	"github.com/lightningnetwork/lnd/lnwire"
 )
 
-func GeneratePaymentInvoice(service *lndclient.GrpcLndServices, ctx context.Context, memo string,
-	satsAmount uint64, expiry int64) (lntypes.Hash, string, error) {
+func (b *LndBackend) CreateInvoice(ctx context.Context, memo string, satsAmount uint64, expiry int64) (lntypes.Hash, string, error) {
 
 	preimage, hash, err := generateRandomPreimageAndHash()
 	if err != nil {
-		log.Fatal(err)
+		return lntypes.Hash{}, "", err
 	}
 
 	invoice := invoicesrpc.AddInvoiceData{
@@ -26,7 +25,23 @@ func GeneratePaymentInvoice(service *lndclient.GrpcLndServices, ctx context.Cont
 		Hash:     &hash,
 		Preimage: preimage,
 	}
-	return service.Client.AddInvoice(ctx, &invoice)
+	invoiceHash, paymentRequest, err := b.services.Client.AddInvoice(ctx, &invoice)
+	return invoiceHash, paymentRequest, newRPCError("add invoice", err)
+}
+
+func (b *LndBackend) PayInvoice(ctx context.Context, invoice string, maxFee btcutil.Amount) (lndclient.PaymentResult, error) {
+	result := <-b.services.Client.PayInvoice(ctx, SantizeBoltInvoice(invoice), maxFee, nil)
+	return result, newRPCError("pay invoice", result.Err)
+}
+
+func (b *LndBackend) DecodeInvoice(ctx context.Context, invoice string) (*lndclient.PaymentRequest, error) {
+	request, err := b.services.Client.DecodePaymentRequest(ctx, SantizeBoltInvoice(invoice))
+	return request, newRPCError("decode invoice", err)
+}
+
+func (b *LndBackend) SubscribeInvoices(ctx context.Context) (<-chan *lndclient.Invoice, <-chan error, error) {
+	invoices, errs, err := b.services.Client.SubscribeInvoices(ctx, lndclient.InvoiceSubscriptionRequest{})
+	return invoices, errs, newRPCError("subscribe invoices", err)
 }
 
 func generateRandomPreimageAndHash() (*lntypes.Preimage,
@@ -0,0 +1,16 @@
+package lnd
+
+import "context"
+
+// SignMessage signs the message with the node's key
+func (b *LndBackend) SignMessage(ctx context.Context, message string) (string, error) {
+	signature, err := b.services.Client.SignMessage(ctx, []byte(message))
+	return signature, newRPCError("sign message", err)
+}
+
+// VerifyMessage checks the signature of the message and returns the public
+// key of the signing node
+func (b *LndBackend) VerifyMessage(ctx context.Context, message, signature string) (bool, string, error) {
+	valid, pubKey, err := b.services.Client.VerifyMessage(ctx, []byte(message), signature)
+	return valid, pubKey, newRPCError("verify message", err)
+}
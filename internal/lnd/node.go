@@ -2,48 +2,77 @@ package lnd
 
 import (
 	"context"
-	"log"
 
-	"github.com/lightninglabs/lndclient"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-// A representation of the user's LND Node
+// Node implementations flash supports
+const (
+	ImplementationLND = "lnd"
+	ImplementationCLN = "cln"
+)
+
+// A representation of the user's Lightning node
 type Node struct {
-	Alias          string
-	PubKey         string
-	Version        string
-	ChannelBalance string
-	TotalCapacity  string
-	OnChainBalance string
+	Implementation string         `json:"implementation"`
+	Alias          string         `json:"alias"`
+	PubKey         string         `json:"pubkey"`
+	Version        string         `json:"version"`
+	Network        string         `json:"network"`
+	ChannelBalance btcutil.Amount `json:"channel_balance_sat"`
+	TotalCapacity  btcutil.Amount `json:"total_capacity_sat"`
+	OnChainBalance btcutil.Amount `json:"onchain_balance_sat"`
 }
 
-func GetDataFromAPI(service *lndclient.GrpcLndServices, ctx context.Context) Node {
-	info, err := service.Client.GetInfo(ctx)
+func (b *LndBackend) GetNode(ctx context.Context) (Node, error) {
+	info, err := b.services.Client.GetInfo(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return Node{}, newRPCError("get info", err)
 	}
 
-	nodeInfo, err := service.Client.GetNodeInfo(ctx, service.NodePubkey, false)
+	nodeInfo, err := b.services.Client.GetNodeInfo(ctx, b.services.NodePubkey, false)
 	if err != nil {
-		log.Fatal(err)
+		return Node{}, newRPCError("get node info", err)
 	}
 
-	channelBalance, err := service.Client.ChannelBalance(ctx)
+	channelBalance, err := b.services.Client.ChannelBalance(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return Node{}, newRPCError("get channel balance", err)
 	}
 
-	walletBalance, err := service.Client.WalletBalance(ctx)
+	walletBalance, err := b.services.Client.WalletBalance(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return Node{}, newRPCError("get wallet balance", err)
 	}
 
 	return Node{
+		Implementation: ImplementationLND,
 		Alias:          nodeInfo.Alias,
 		PubKey:         nodeInfo.PubKey.String(),
 		Version:        info.Version,
-		ChannelBalance: channelBalance.Balance.String(),
-		TotalCapacity:  nodeInfo.TotalCapacity.String(),
-		OnChainBalance: walletBalance.Confirmed.String(),
+		Network:        info.Network,
+		ChannelBalance: channelBalance.Balance,
+		TotalCapacity:  nodeInfo.TotalCapacity,
+		OnChainBalance: walletBalance.Confirmed,
+	}, nil
+}
+
+func (b *LndBackend) GetNodeAlias(ctx context.Context, pubKey route.Vertex) string {
+	return b.aliases.Get(ctx, pubKey, b.lookupAlias)
+}
+
+// Nodes that lnd doesn't know from the gossip have no alias
+func (b *LndBackend) lookupAlias(ctx context.Context, pubKey route.Vertex) (string, error) {
+	node, err := b.services.Client.GetNodeInfo(ctx, pubKey, false)
+	if status.Code(err) == codes.NotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
 	}
+
+	return node.Alias, nil
 }
@@ -1,6 +1,7 @@
 package lnd
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/lightninglabs/lndclient"
@@ -20,4 +21,22 @@ func (p Payment) Title() string {
 
 func (p Payment) Description() string {
 	return p.Payment.Status.State.String()
-}
\ No newline at end of file
+}
+
+// GetPayments returns the latest payments, newest first
+func (b *LndBackend) GetPayments(ctx context.Context, max uint64) ([]Payment, error) {
+	request := lndclient.ListPaymentsRequest{MaxPayments: max, Reversed: true, IncludeIncomplete: false}
+	response, err := b.services.Client.ListPayments(ctx, request)
+	if err != nil {
+		return nil, newRPCError("list payments", err)
+	}
+
+	// Reversed selects the latest payments but lnd still lists them oldest
+	// first
+	var payments []Payment
+	for i := len(response.Payments) - 1; i >= 0; i-- {
+		payments = append(payments, Payment{Payment: response.Payments[i]})
+	}
+
+	return payments, nil
+}
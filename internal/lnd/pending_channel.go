@@ -1,9 +1,11 @@
 package lnd
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/lightningnetwork/lnd/routing/route"
 )
 
 // ChannelType represents the type of Lightning network channel.
@@ -49,3 +51,57 @@ func (c PendingChannel) Description() string {
 
 	return ""
 }
+
+// GetPendingChannels lists channels being opened or closed
+func (b *LndBackend) GetPendingChannels(ctx context.Context) ([]PendingChannel, error) {
+	var pendingChannels []PendingChannel
+	channels, err := b.services.Client.PendingChannels(ctx)
+	if err != nil {
+		return nil, newRPCError("list pending channels", err)
+	}
+
+	// Peers of the channels, their aliases are looked up together
+	var pubKeys []route.Vertex
+
+	// Force close channels
+	for _, fc := range channels.PendingForceClose {
+		pendingChannel := PendingChannel{
+			Capacity:            fc.Capacity,
+			LocalBalance:        fc.LocalBalance,
+			RecoveredBalance:    fc.RecoveredBalance,
+			LimboBalance:        fc.LimboBalance,
+			BlocksUntilMaturity: fc.BlocksUntilMaturity,
+			Type:                ForceClosure,
+		}
+		pendingChannels = append(pendingChannels, pendingChannel)
+		pubKeys = append(pubKeys, fc.PubKeyBytes)
+	}
+
+	// Cooperative closing channels
+	for _, fc := range channels.WaitingClose {
+		pendingChannel := PendingChannel{
+			Capacity:     fc.Capacity,
+			LocalBalance: fc.LocalBalance,
+			Type:         CooperativeClosure,
+		}
+		pendingChannels = append(pendingChannels, pendingChannel)
+		pubKeys = append(pubKeys, fc.PubKeyBytes)
+	}
+
+	// Pending channel opens
+	for _, fc := range channels.PendingOpen {
+		pendingChannel := PendingChannel{
+			Capacity:     fc.Capacity,
+			LocalBalance: fc.LocalBalance,
+			Type:         CooperativeClosure,
+		}
+		pendingChannels = append(pendingChannels, pendingChannel)
+		pubKeys = append(pubKeys, fc.PubKeyBytes)
+	}
+
+	for i, alias := range b.aliases.Resolve(ctx, "pending channels", pubKeys, b.lookupAlias) {
+		pendingChannels[i].Alias = alias
+	}
+
+	return pendingChannels, nil
+}
@@ -0,0 +1,168 @@
+package lnd
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/ardevd/flash/internal/credentials"
+	"github.com/lightninglabs/lndclient"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Action is an operation offered by the UI that needs write access to the node
+type Action int
+
+const (
+	CloseChannel Action = iota
+	ForceCloseChannel
+	UpdateChannelPolicy
+	SendPayment
+)
+
+// The RPC method behind an action and the permissions lnd requires for it
+type actionRequirement struct {
+	method      string
+	permissions []lndclient.MacaroonPermission
+}
+
+var actionRequirements = map[Action]actionRequirement{
+	CloseChannel: {
+		method:      "/lnrpc.Lightning/CloseChannel",
+		permissions: []lndclient.MacaroonPermission{{Entity: "onchain", Action: "write"}, {Entity: "offchain", Action: "write"}},
+	},
+	ForceCloseChannel: {
+		method:      "/lnrpc.Lightning/CloseChannel",
+		permissions: []lndclient.MacaroonPermission{{Entity: "onchain", Action: "write"}, {Entity: "offchain", Action: "write"}},
+	},
+	UpdateChannelPolicy: {
+		method:      "/lnrpc.Lightning/UpdateChannelPolicy",
+		permissions: []lndclient.MacaroonPermission{{Entity: "offchain", Action: "write"}},
+	},
+	SendPayment: {
+		method:      "/lnrpc.Lightning/SendPaymentSync",
+		permissions: []lndclient.MacaroonPermission{{Entity: "offchain", Action: "write"}},
+	},
+}
+
+// Permissions maps the actions the macaroon does not allow to the reason
+// they are unavailable. All actions are allowed for the zero value.
+type Permissions map[Action]string
+
+// Allowed indicates whether the macaroon allows the action
+func (p Permissions) Allowed(a Action) bool {
+	_, denied := p[a]
+	return !denied
+}
+
+// Reason explains why the action is unavailable
+func (p Permissions) Reason(a Action) string {
+	return p[a]
+}
+
+// DenyAll returns permissions that deny every action for the reason
+func DenyAll(reason string) Permissions {
+	permissions := Permissions{}
+	for action := range actionRequirements {
+		permissions[action] = reason
+	}
+
+	return permissions
+}
+
+// GetPermissions determines which actions the macaroon allows. The node is
+// asked first, which also takes caveats such as an expiry into account. If the
+// macaroon may not query the node, the permissions baked into the macaroon are
+// used instead.
+func GetPermissions(service *lndclient.GrpcLndServices, ctx context.Context, macaroon []byte) Permissions {
+	permissions := Permissions{}
+
+	for action, requirement := range actionRequirements {
+		valid, err := service.Client.CheckMacaroonPermissions(ctx, macaroon, requirement.permissions, requirement.method)
+
+		// lnd reports a denied check as an invalid argument
+		if status.Code(err) == codes.InvalidArgument {
+			permissions[action] = "the macaroon lacks " + formatPermissions(requirement.permissions)
+			continue
+		}
+
+		if err != nil {
+			return getPermissionsFromMacaroon(macaroon)
+		}
+
+		if !valid {
+			permissions[action] = "the macaroon lacks " + formatPermissions(requirement.permissions)
+		}
+	}
+
+	return permissions
+}
+
+// Determine the allowed actions from the permissions lnd baked into the macaroon
+func getPermissionsFromMacaroon(macaroon []byte) Permissions {
+	permissions := Permissions{}
+
+	info, err := credentials.DecodeMacaroon(macaroon)
+	if err != nil {
+		// Let the node decide when the actions are used
+		return permissions
+	}
+
+	granted := make(map[string]bool, len(info.Permissions))
+	for _, p := range info.Permissions {
+		granted[p] = true
+	}
+
+	for action, requirement := range actionRequirements {
+		var missing []lndclient.MacaroonPermission
+		for _, p := range requirement.permissions {
+			if !granted[p.String()] {
+				missing = append(missing, p)
+			}
+		}
+
+		if len(missing) > 0 {
+			permissions[action] = "the macaroon lacks " + formatPermissions(missing)
+		}
+	}
+
+	return permissions
+}
+
+func formatPermissions(permissions []lndclient.MacaroonPermission) string {
+	names := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		names = append(names, p.String())
+	}
+
+	return strings.Join(names, " and ")
+}
+
+// GetAllowedMethods asks the node which RPC methods the macaroon grants access to
+func GetAllowedMethods(service *lndclient.GrpcLndServices, ctx context.Context, macaroon []byte) ([]string, error) {
+	methodPermissions, err := service.Client.ListPermissions(ctx)
+	if err != nil {
+		return nil, newRPCError("list permissions", err)
+	}
+
+	var allowed []string
+	for method, permissions := range methodPermissions {
+		valid, err := service.Client.CheckMacaroonPermissions(ctx, macaroon, permissions, method)
+
+		// lnd reports a denied check as an invalid argument
+		if status.Code(err) == codes.InvalidArgument {
+			continue
+		}
+		if err != nil {
+			return nil, newRPCError("check macaroon permissions", err)
+		}
+
+		if valid {
+			allowed = append(allowed, method)
+		}
+	}
+
+	sort.Strings(allowed)
+	return allowed, nil
+}
@@ -0,0 +1,63 @@
+package lnd
+
+import (
+	"testing"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/macaroon.v2"
+)
+
+func newTestMacaroon(t *testing.T, ops ...*lnrpc.Op) []byte {
+	id, err := proto.Marshal(&lnrpc.MacaroonId{Nonce: []byte("nonce"), StorageId: []byte("0"), Ops: ops})
+	if err != nil {
+		assert.FailNow(t, "unable to encode identifier: "+err.Error())
+	}
+
+	// lnd prefixes the identifier with the bakery version
+	mac, err := macaroon.New([]byte("root key"), append([]byte{3}, id...), "lnd", macaroon.LatestVersion)
+	if err != nil {
+		assert.FailNow(t, "unable to create macaroon: "+err.Error())
+	}
+
+	macBytes, err := mac.MarshalBinary()
+	if err != nil {
+		assert.FailNow(t, "unable to encode macaroon: "+err.Error())
+	}
+
+	return macBytes
+}
+
+func TestPermissionsFromReadonlyMacaroon(t *testing.T) {
+	macBytes := newTestMacaroon(t,
+		&lnrpc.Op{Entity: "offchain", Actions: []string{"read"}},
+		&lnrpc.Op{Entity: "onchain", Actions: []string{"read"}})
+
+	permissions := getPermissionsFromMacaroon(macBytes)
+
+	for _, action := range []Action{CloseChannel, ForceCloseChannel, UpdateChannelPolicy, SendPayment} {
+		assert.False(t, permissions.Allowed(action))
+	}
+	assert.Equal(t, "the macaroon lacks onchain:write and offchain:write", permissions.Reason(CloseChannel))
+	assert.Equal(t, "the macaroon lacks offchain:write", permissions.Reason(SendPayment))
+}
+
+func TestPermissionsFromOffchainMacaroon(t *testing.T) {
+	macBytes := newTestMacaroon(t,
+		&lnrpc.Op{Entity: "offchain", Actions: []string{"read", "write"}},
+		&lnrpc.Op{Entity: "onchain", Actions: []string{"read"}})
+
+	permissions := getPermissionsFromMacaroon(macBytes)
+
+	assert.True(t, permissions.Allowed(SendPayment))
+	assert.True(t, permissions.Allowed(UpdateChannelPolicy))
+	assert.False(t, permissions.Allowed(CloseChannel))
+	assert.Equal(t, "the macaroon lacks onchain:write", permissions.Reason(CloseChannel))
+}
+
+func TestZeroPermissionsAllowEverything(t *testing.T) {
+	var permissions Permissions
+	assert.True(t, permissions.Allowed(CloseChannel))
+	assert.Empty(t, permissions.Reason(CloseChannel))
+}
@@ -0,0 +1,57 @@
+package lnd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/lightninglabs/lndclient"
+	"golang.org/x/net/proxy"
+)
+
+// Timeouts for connections through a proxy. Building a Tor circuit to an
+// onion service regularly takes tens of seconds, and each call adds latency.
+const (
+	ProxyDialTimeout = time.Minute
+	ProxyRPCTimeout  = 2 * time.Minute
+)
+
+const defaultRPCPort = "10009"
+
+// ProxyDialer returns a dialer connecting to the node through the SOCKS5 proxy
+// at proxyAddress. Host names are resolved by the proxy, which is required for
+// onion addresses and keeps clearnet lookups from leaking.
+func ProxyDialer(proxyAddress string) (lndclient.DialerFunc, error) {
+	dialer, err := proxy.SOCKS5("tcp", proxyAddress, nil, &net.Dialer{Timeout: ProxyDialTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	contextDialer, ok := dialer.(proxy.ContextDialer)
+	if !ok {
+		return nil, errors.New("SOCKS5 dialer does not support contexts")
+	}
+
+	return func(ctx context.Context, address string) (net.Conn, error) {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			address = net.JoinHostPort(address, defaultRPCPort)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, ProxyDialTimeout)
+		defer cancel()
+
+		return contextDialer.DialContext(ctx, "tcp", address)
+	}, nil
+}
+
+// IsOnion indicates whether the host:port address is a Tor onion service
+func IsOnion(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+
+	return strings.HasSuffix(strings.ToLower(host), ".onion")
+}
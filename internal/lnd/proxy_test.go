@@ -0,0 +1,89 @@
+package lnd
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Accept a single SOCKS5 CONNECT without authentication and report the
+// requested destination. The connection is then answered with "pong".
+func serveSOCKS5(t *testing.T, listener net.Listener, destination chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// Greeting: version, number of methods, methods
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		t.Error(err)
+		return
+	}
+	io.ReadFull(conn, make([]byte, header[1]))
+	conn.Write([]byte{5, 0})
+
+	// Request: version, command, reserved, address type
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		t.Error(err)
+		return
+	}
+	if request[3] != 3 {
+		t.Errorf("expected a domain name, got address type %d", request[3])
+		return
+	}
+	length := make([]byte, 1)
+	io.ReadFull(conn, length)
+	host := make([]byte, length[0])
+	io.ReadFull(conn, host)
+	port := make([]byte, 2)
+	io.ReadFull(conn, port)
+	destination <- net.JoinHostPort(string(host), strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	conn.Write([]byte("pong"))
+}
+
+func TestProxyDialer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	defer listener.Close()
+
+	destination := make(chan string, 1)
+	go serveSOCKS5(t, listener, destination)
+
+	dial, err := ProxyDialer(listener.Addr().String())
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	// Onion addresses are passed to the proxy unresolved, with lnd's default port
+	conn, err := dial(context.Background(), "examplenode.onion")
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	defer conn.Close()
+
+	assert.Equal(t, "examplenode.onion:10009", <-destination)
+
+	reply := make([]byte, 4)
+	_, err = io.ReadFull(conn, reply)
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", string(reply))
+}
+
+func TestIsOnion(t *testing.T) {
+	assert.True(t, IsOnion("examplenode.onion:10009"))
+	assert.True(t, IsOnion("EXAMPLENODE.ONION"))
+	assert.False(t, IsOnion("node.example.com:10009"))
+	assert.False(t, IsOnion("127.0.0.1:10009"))
+}
@@ -0,0 +1,512 @@
+package lnd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/lightninglabs/lndclient"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ConnectionState of the supervised connection to the node
+type ConnectionState int
+
+const (
+	Connected ConnectionState = iota
+	Reconnecting
+	Offline
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case Connected:
+		return "connected"
+	case Reconnecting:
+		return "reconnecting"
+	default:
+		return "offline"
+	}
+}
+
+// ConnectionStatus describes the connection to the node
+type ConnectionStatus struct {
+	State ConnectionState
+	// Time of the last successful call to the node
+	LastSync time.Time
+	// Why the connection was lost, nil while connected
+	Err error
+}
+
+// StatusReporter is implemented by backends that track their connection
+type StatusReporter interface {
+	Status() ConnectionStatus
+}
+
+// Subscription runs until its context is canceled, the stream fails or it is
+// done. The supervisor starts it again on every new connection until it
+// returns nil or an error retrying won't fix.
+type Subscription func(ctx context.Context, backend NodeBackend) error
+
+// Number of failed reconnect attempts after which the node is reported offline
+const offlineAttempts = 3
+
+// Supervisor keeps the connection to the node alive. It checks the connection
+// periodically and when calls report it unavailable, reconnects with
+// exponential backoff and restarts the subscriptions on the new connection.
+// As a NodeBackend it passes calls to the current connection and reports
+// their results.
+type Supervisor struct {
+	connect func(ctx context.Context) (NodeBackend, error)
+	check   func(ctx context.Context, backend NodeBackend) error
+
+	checkInterval time.Duration
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+
+	mu            sync.Mutex
+	backend       NodeBackend
+	status        ConnectionStatus
+	connCtx       context.Context
+	connCancel    context.CancelFunc
+	subscriptions map[*subscription]struct{}
+
+	lost    chan error
+	updates chan ConnectionStatus
+}
+
+type subscription struct {
+	run    Subscription
+	cancel context.CancelFunc
+	errs   chan error
+}
+
+// NewSupervisor supervises the connected backend. The connect function
+// creates a new connection when the current one is lost.
+func NewSupervisor(backend NodeBackend, connect func(ctx context.Context) (NodeBackend, error)) *Supervisor {
+	connCtx, connCancel := context.WithCancel(context.Background())
+	return &Supervisor{
+		connect: connect,
+		check: func(ctx context.Context, backend NodeBackend) error {
+			_, err := backend.GetNode(ctx)
+			return err
+		},
+		checkInterval: 15 * time.Second,
+		minBackoff:    time.Second,
+		maxBackoff:    time.Minute,
+		backend:       backend,
+		status:        ConnectionStatus{State: Connected, LastSync: time.Now()},
+		connCtx:       connCtx,
+		connCancel:    connCancel,
+		subscriptions: make(map[*subscription]struct{}),
+		lost:          make(chan error, 1),
+		updates:       make(chan ConnectionStatus, 1),
+	}
+}
+
+// Backend returns the current connection. It changes after a reconnect, so
+// don't hold on to it.
+func (s *Supervisor) Backend() NodeBackend {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.backend
+}
+
+// Status returns the current state of the connection
+func (s *Supervisor) Status() ConnectionStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.status
+}
+
+// Updates delivers the status after every check and state change. Only the
+// latest status is kept when it isn't received in time.
+func (s *Supervisor) Updates() <-chan ConnectionStatus {
+	return s.updates
+}
+
+// Report the result of a call to the node. Successful calls update the sync
+// time, unavailable errors start a reconnect.
+func (s *Supervisor) Report(err error) {
+	if err == nil {
+		s.mu.Lock()
+		if s.status.State == Connected {
+			s.status.LastSync = time.Now()
+		}
+		s.mu.Unlock()
+		return
+	}
+
+	if connectionLost(err) {
+		select {
+		case s.lost <- err:
+		default:
+		}
+	}
+}
+
+// Subscribe starts the subscription and restarts it on every new connection.
+// Call the returned function to stop it. Errors retrying won't fix, like a
+// macaroon lacking the permission, stop the subscription and are delivered
+// on the returned channel.
+func (s *Supervisor) Subscribe(run Subscription) (func(), <-chan error) {
+	sub := &subscription{run: run, errs: make(chan error, 1)}
+
+	s.mu.Lock()
+	s.subscriptions[sub] = struct{}{}
+	if s.status.State == Connected {
+		s.start(sub)
+	}
+	s.mu.Unlock()
+
+	return func() { s.unsubscribe(sub) }, sub.errs
+}
+
+// Run supervises the connection until the context is canceled
+func (s *Supervisor) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.connCancel()
+			s.mu.Unlock()
+			return
+
+		case err := <-s.lost:
+			s.reconnect(ctx, err)
+
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, s.checkInterval)
+			err := s.check(checkCtx, s.Backend())
+			cancel()
+
+			if connectionLost(err) && ctx.Err() == nil {
+				s.reconnect(ctx, err)
+				continue
+			}
+
+			s.Report(err)
+			s.publish()
+		}
+	}
+}
+
+// Reconnect until it succeeds or the context is canceled. Subscriptions are
+// stopped while disconnected and started again on the new connection.
+func (s *Supervisor) reconnect(ctx context.Context, cause error) {
+	s.mu.Lock()
+	s.connCancel()
+	s.status.State = Reconnecting
+	s.status.Err = cause
+	s.mu.Unlock()
+	s.publish()
+
+	backoff := s.minBackoff
+	for attempt := 1; ; attempt++ {
+		backend, err := s.connect(ctx)
+		if err == nil {
+			s.mu.Lock()
+			old := s.backend
+			s.backend = backend
+			s.status = ConnectionStatus{State: Connected, LastSync: time.Now()}
+			s.connCtx, s.connCancel = context.WithCancel(context.Background())
+			for sub := range s.subscriptions {
+				s.start(sub)
+			}
+			s.mu.Unlock()
+
+			if old != nil {
+				old.Close()
+			}
+			s.drainLost()
+			s.publish()
+			return
+		}
+
+		s.mu.Lock()
+		s.status.Err = err
+		if attempt >= offlineAttempts {
+			s.status.State = Offline
+		}
+		s.mu.Unlock()
+		s.publish()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = s.nextBackoff(backoff)
+	}
+}
+
+// Double the backoff up to the maximum
+func (s *Supervisor) nextBackoff(backoff time.Duration) time.Duration {
+	return min(backoff*2, s.maxBackoff)
+}
+
+// Start the subscription on the current connection, called with the lock held
+func (s *Supervisor) start(sub *subscription) {
+	ctx, cancel := context.WithCancel(s.connCtx)
+	sub.cancel = cancel
+	backend := s.backend
+
+	go func() {
+		backoff := s.minBackoff
+		for {
+			started := time.Now()
+			err := sub.run(ctx, backend)
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err == nil {
+				s.unsubscribe(sub)
+				return
+			}
+
+			if permanent(err) {
+				s.unsubscribe(sub)
+				select {
+				case sub.errs <- err:
+				default:
+				}
+				return
+			}
+
+			// A lost connection cancels the context, other errors are retried
+			// with backoff. A stream that ran for a while starts over.
+			s.Report(err)
+			if time.Since(started) > s.maxBackoff {
+				backoff = s.minBackoff
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = s.nextBackoff(backoff)
+		}
+	}()
+}
+
+func (s *Supervisor) unsubscribe(sub *subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subscriptions, sub)
+	if sub.cancel != nil {
+		sub.cancel()
+	}
+}
+
+func connectionLost(err error) bool {
+	return err != nil && (errors.Is(err, ErrRPCUnavailable) || isUnavailable(err))
+}
+
+// Errors of subscriptions that fail the same way when retried
+func permanent(err error) bool {
+	return isPermissionDenied(err) || status.Code(err) == codes.Unimplemented
+}
+
+// Errors reported for the old connection don't need another reconnect
+func (s *Supervisor) drainLost() {
+	select {
+	case <-s.lost:
+	default:
+	}
+}
+
+// Send the current status, replacing one that wasn't received yet
+func (s *Supervisor) publish() {
+	status := s.Status()
+	for {
+		select {
+		case s.updates <- status:
+			return
+		default:
+		}
+
+		select {
+		case <-s.updates:
+		default:
+		}
+	}
+}
+
+func (s *Supervisor) GetNode(ctx context.Context) (Node, error) {
+	node, err := s.Backend().GetNode(ctx)
+	s.Report(err)
+	return node, err
+}
+
+func (s *Supervisor) GetNodeAlias(ctx context.Context, pubKey route.Vertex) string {
+	return s.Backend().GetNodeAlias(ctx, pubKey)
+}
+
+func (s *Supervisor) GetPermissions(ctx context.Context) Permissions {
+	return s.Backend().GetPermissions(ctx)
+}
+
+func (s *Supervisor) GetChannels(ctx context.Context) ([]Channel, error) {
+	channels, err := s.Backend().GetChannels(ctx)
+	s.Report(err)
+	return channels, err
+}
+
+func (s *Supervisor) GetPendingChannels(ctx context.Context) ([]PendingChannel, error) {
+	channels, err := s.Backend().GetPendingChannels(ctx)
+	s.Report(err)
+	return channels, err
+}
+
+func (s *Supervisor) GetChannelEdge(ctx context.Context, channelID uint64) (*lndclient.ChannelEdge, error) {
+	edge, err := s.Backend().GetChannelEdge(ctx, channelID)
+	s.Report(err)
+	return edge, err
+}
+
+func (s *Supervisor) UpdateChannelPolicy(ctx context.Context, channelPoint string, policy lndclient.PolicyUpdateRequest) error {
+	err := s.Backend().UpdateChannelPolicy(ctx, channelPoint, policy)
+	s.Report(err)
+	return err
+}
+
+func (s *Supervisor) CloseChannel(ctx context.Context, channelPoint string, force bool, targetBlocks int32) (
+	<-chan lndclient.CloseChannelUpdate, <-chan error, error) {
+
+	updates, errs, err := s.Backend().CloseChannel(ctx, channelPoint, force, targetBlocks)
+	s.Report(err)
+	return updates, errs, err
+}
+
+func (s *Supervisor) GetPayments(ctx context.Context, max uint64) ([]Payment, error) {
+	payments, err := s.Backend().GetPayments(ctx, max)
+	s.Report(err)
+	return payments, err
+}
+
+func (s *Supervisor) DecodeInvoice(ctx context.Context, invoice string) (*lndclient.PaymentRequest, error) {
+	request, err := s.Backend().DecodeInvoice(ctx, invoice)
+	s.Report(err)
+	return request, err
+}
+
+func (s *Supervisor) CreateInvoice(ctx context.Context, memo string, satsAmount uint64, expiry int64) (lntypes.Hash, string, error) {
+	hash, paymentRequest, err := s.Backend().CreateInvoice(ctx, memo, satsAmount, expiry)
+	s.Report(err)
+	return hash, paymentRequest, err
+}
+
+func (s *Supervisor) PayInvoice(ctx context.Context, invoice string, maxFee btcutil.Amount) (lndclient.PaymentResult, error) {
+	result, err := s.Backend().PayInvoice(ctx, invoice, maxFee)
+	s.Report(err)
+	return result, err
+}
+
+// SubscribeInvoices returns a stream that continues on the new connection
+// after a reconnect. Stream errors are retried, only errors retrying won't
+// fix are delivered.
+func (s *Supervisor) SubscribeInvoices(ctx context.Context) (<-chan *lndclient.Invoice, <-chan error, error) {
+	invoices := make(chan *lndclient.Invoice)
+	stop, errs := s.Subscribe(func(subCtx context.Context, backend NodeBackend) error {
+		updates, streamErrs, err := backend.SubscribeInvoices(subCtx)
+		if err != nil {
+			return err
+		}
+
+		for {
+			select {
+			case invoice, ok := <-updates:
+				if !ok {
+					return errors.New("invoice stream closed")
+				}
+				select {
+				case invoices <- invoice:
+				case <-subCtx.Done():
+					return subCtx.Err()
+				}
+			case err := <-streamErrs:
+				return err
+			case <-subCtx.Done():
+				return subCtx.Err()
+			}
+		}
+	})
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	return invoices, errs, nil
+}
+
+// SubscribeEvents returns a stream that continues on the new connection
+// after a reconnect. Stream errors are retried, only errors retrying won't
+// fix are delivered.
+func (s *Supervisor) SubscribeEvents(ctx context.Context) (<-chan NodeEvent, <-chan error, error) {
+	events := make(chan NodeEvent)
+	stop, errs := s.Subscribe(func(subCtx context.Context, backend NodeBackend) error {
+		updates, streamErrs, err := backend.SubscribeEvents(subCtx)
+		if err != nil {
+			return err
+		}
+
+		for {
+			select {
+			case event, ok := <-updates:
+				if !ok {
+					return errors.New("event stream closed")
+				}
+				select {
+				case events <- event:
+				case <-subCtx.Done():
+					return subCtx.Err()
+				}
+			case err := <-streamErrs:
+				return err
+			case <-subCtx.Done():
+				return subCtx.Err()
+			}
+		}
+	})
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	return events, errs, nil
+}
+
+func (s *Supervisor) SignMessage(ctx context.Context, message string) (string, error) {
+	signature, err := s.Backend().SignMessage(ctx, message)
+	s.Report(err)
+	return signature, err
+}
+
+func (s *Supervisor) VerifyMessage(ctx context.Context, message, signature string) (bool, string, error) {
+	valid, pubKey, err := s.Backend().VerifyMessage(ctx, message, signature)
+	s.Report(err)
+	return valid, pubKey, err
+}
+
+// Close the current connection. Stop Run first so it doesn't reconnect.
+func (s *Supervisor) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.connCancel()
+	s.backend.Close()
+}
@@ -0,0 +1,184 @@
+package lnd
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Backend whose node info fails while the node is down
+type fakeBackend struct {
+	NodeBackend
+	down *atomic.Bool
+	// Returned when subscribing to events
+	subscribeErr error
+}
+
+func (b *fakeBackend) GetNode(ctx context.Context) (Node, error) {
+	if b.down.Load() {
+		return Node{}, newRPCError("get info", status.Error(codes.Unavailable, "connection refused"))
+	}
+	return Node{}, nil
+}
+
+func (b *fakeBackend) SubscribeEvents(ctx context.Context) (<-chan NodeEvent, <-chan error, error) {
+	return nil, nil, b.subscribeErr
+}
+
+func (b *fakeBackend) Close() {}
+
+// Supervisor with fast timings whose connections only fail when told to
+func newTestSupervisor(connect func(ctx context.Context) (NodeBackend, error)) (*Supervisor, *atomic.Bool) {
+	down := &atomic.Bool{}
+	s := NewSupervisor(&fakeBackend{down: down}, connect)
+	s.checkInterval = 10 * time.Millisecond
+	s.minBackoff = time.Millisecond
+	s.maxBackoff = 5 * time.Millisecond
+
+	return s, down
+}
+
+// Wait for an update with the state
+func waitForState(t *testing.T, s *Supervisor, state ConnectionState) ConnectionStatus {
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case status := <-s.Updates():
+			if status.State == state {
+				return status
+			}
+		case <-timeout:
+			assert.FailNow(t, "timed out waiting for state "+state.String())
+		}
+	}
+}
+
+func TestSupervisorReconnects(t *testing.T) {
+	attempts := atomic.Int32{}
+	var s *Supervisor
+	var down *atomic.Bool
+	s, down = newTestSupervisor(func(ctx context.Context) (NodeBackend, error) {
+		// Come back after the node was reported offline
+		if attempts.Add(1) <= offlineAttempts {
+			return nil, errors.New("connection refused")
+		}
+		down.Store(false)
+		return &fakeBackend{down: down}, nil
+	})
+	initial := s.Backend()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	waitForState(t, s, Connected)
+	down.Store(true)
+
+	status := waitForState(t, s, Reconnecting)
+	assert.Error(t, status.Err)
+	waitForState(t, s, Offline)
+
+	status = waitForState(t, s, Connected)
+	assert.NoError(t, status.Err)
+	assert.NotSame(t, initial, s.Backend())
+	assert.Equal(t, int32(offlineAttempts+1), attempts.Load())
+}
+
+func TestSupervisorRestartsSubscriptions(t *testing.T) {
+	s, _ := newTestSupervisor(func(ctx context.Context) (NodeBackend, error) {
+		return &fakeBackend{down: &atomic.Bool{}}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	started := make(chan NodeBackend, 2)
+	stop, _ := s.Subscribe(func(ctx context.Context, backend NodeBackend) error {
+		started <- backend
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	defer stop()
+
+	assert.Same(t, s.Backend(), <-started)
+
+	// A failing call starts a reconnect, after which the subscription runs
+	// on the new connection
+	s.Report(&RPCError{Op: "get info", Err: status.Error(codes.Unavailable, "connection reset")})
+	waitForState(t, s, Reconnecting)
+	waitForState(t, s, Connected)
+
+	select {
+	case backend := <-started:
+		assert.Same(t, s.Backend(), backend)
+	case <-time.After(2 * time.Second):
+		assert.Fail(t, "subscription not restarted")
+	}
+}
+
+func TestSupervisorIgnoresOtherErrors(t *testing.T) {
+	s, _ := newTestSupervisor(func(ctx context.Context) (NodeBackend, error) {
+		return nil, errors.New("no reconnect expected")
+	})
+
+	s.Report(status.Error(codes.PermissionDenied, "permission denied"))
+	s.Report(errors.New("invoice expired"))
+
+	select {
+	case err := <-s.lost:
+		assert.Fail(t, "unexpected reconnect", err)
+	default:
+	}
+	assert.Equal(t, Connected, s.Status().State)
+}
+
+func TestSupervisorBacksOffFailingSubscriptions(t *testing.T) {
+	s, _ := newTestSupervisor(func(ctx context.Context) (NodeBackend, error) {
+		return nil, errors.New("no reconnect expected")
+	})
+	s.maxBackoff = 20 * time.Millisecond
+
+	runs := atomic.Int32{}
+	stop, _ := s.Subscribe(func(ctx context.Context, backend NodeBackend) error {
+		runs.Add(1)
+		return errors.New("stream failed")
+	})
+	time.Sleep(100 * time.Millisecond)
+	stop()
+
+	// Retrying every millisecond would run it about a hundred times
+	assert.Greater(t, runs.Load(), int32(3))
+	assert.Less(t, runs.Load(), int32(20))
+}
+
+func TestSupervisorDeliversPermanentErrors(t *testing.T) {
+	s, _ := newTestSupervisor(func(ctx context.Context) (NodeBackend, error) {
+		return nil, errors.New("no reconnect expected")
+	})
+	s.Backend().(*fakeBackend).subscribeErr = newRPCError("subscribe channel events",
+		status.Error(codes.PermissionDenied, "permission denied"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, errs, err := s.SubscribeEvents(ctx)
+	assert.NoError(t, err)
+
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+	case <-time.After(2 * time.Second):
+		assert.FailNow(t, "permanent error not delivered")
+	}
+
+	s.mu.Lock()
+	assert.Empty(t, s.subscriptions)
+	s.mu.Unlock()
+}
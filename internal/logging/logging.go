@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	// Entries kept for the log console
+	ringSize = 1000
+	// Size in MB at which the log file is rotated, and the number of rotated
+	// files kept
+	maxSize    = 5
+	maxBackups = 3
+)
+
+// Path of the log file in the cache directory of the user
+func Path() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "flash", "flash.log"), nil
+}
+
+// New returns a logger writing JSON lines to the log file at path, which is
+// rotated once it grows too large, and to the ring buffer it returns. Close
+// the returned file when done.
+func New(path string) (*log.Logger, *Ring, io.Closer, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, nil, nil, err
+	}
+
+	file := &lumberjack.Logger{Filename: path, MaxSize: maxSize, MaxBackups: maxBackups}
+	ring := NewRing(ringSize)
+
+	return newLogger(io.MultiWriter(ring, file)), ring, file, nil
+}
+
+// NewRingLogger returns a logger writing only to the ring buffer it returns,
+// for when the log file can't be written
+func NewRingLogger() (*log.Logger, *Ring) {
+	ring := NewRing(ringSize)
+	return newLogger(ring), ring
+}
+
+func newLogger(w io.Writer) *log.Logger {
+	return log.NewWithOptions(w, log.Options{
+		Level:           log.DebugLevel,
+		ReportTimestamp: true,
+		TimeFormat:      time.RFC3339Nano,
+		Formatter:       log.JSONFormatter,
+	})
+}
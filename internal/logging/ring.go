@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Entry is a log record kept for the log console
+type Entry struct {
+	Time    time.Time
+	Level   log.Level
+	Message string
+	// The other fields as key=value pairs, sorted by key
+	Fields string
+}
+
+// Ring keeps the latest log entries in memory. It takes the JSON lines of a
+// logger as an io.Writer.
+type Ring struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+	written uint64
+}
+
+func NewRing(size int) *Ring {
+	return &Ring{entries: make([]Entry, 0, size)}
+}
+
+func (r *Ring) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(bytes.TrimSpace(p), []byte("\n")) {
+		if len(line) > 0 {
+			r.Add(parseEntry(line))
+		}
+	}
+
+	return len(p), nil
+}
+
+// Add the entry, replacing the oldest one if the ring is full
+func (r *Ring) Add(entry Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.entries) < cap(r.entries) {
+		r.entries = append(r.entries, entry)
+	} else {
+		r.entries[r.next] = entry
+		r.next = (r.next + 1) % len(r.entries)
+	}
+	r.written++
+}
+
+// Entries returns the entries in the ring, the oldest first
+func (r *Ring) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]Entry, 0, len(r.entries))
+	entries = append(entries, r.entries[r.next:]...)
+	return append(entries, r.entries[:r.next]...)
+}
+
+// Written returns the number of entries added so far, readers compare it to
+// tell whether there are new entries
+func (r *Ring) Written() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.written
+}
+
+// Lines that aren't JSON are kept as the message of an entry without level
+func parseEntry(line []byte) Entry {
+	// Keep numbers as written
+	var fields map[string]any
+	d := json.NewDecoder(bytes.NewReader(line))
+	d.UseNumber()
+	if err := d.Decode(&fields); err != nil {
+		return Entry{Time: time.Now(), Level: log.InfoLevel, Message: string(line)}
+	}
+
+	entry := Entry{Time: time.Now(), Level: log.InfoLevel}
+	if ts, ok := fields[log.TimestampKey].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			entry.Time = t
+		}
+	}
+	if level, ok := fields[log.LevelKey].(string); ok {
+		if l, err := log.ParseLevel(level); err == nil {
+			entry.Level = l
+		}
+	}
+	if msg, ok := fields[log.MessageKey].(string); ok {
+		entry.Message = msg
+	}
+
+	delete(fields, log.TimestampKey)
+	delete(fields, log.LevelKey)
+	delete(fields, log.MessageKey)
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = fmt.Sprintf("%s=%v", key, fields[key])
+	}
+	entry.Fields = strings.Join(pairs, " ")
+
+	return entry
+}
@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerWritesFileAndRing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flash", "flash.log")
+	logger, ring, file, err := New(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	logger.Debug("Loading node data")
+	logger.Error("Unable to close channel", "channel", "a:0", "amount", 1000000)
+
+	entries := ring.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, log.DebugLevel, entries[0].Level)
+	assert.Equal(t, "Unable to close channel", entries[1].Message)
+	assert.Equal(t, log.ErrorLevel, entries[1].Level)
+	assert.Equal(t, "amount=1000000 channel=a:0", entries[1].Fields)
+	assert.False(t, entries[1].Time.IsZero())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"msg":"Unable to close channel"`)
+}
+
+func TestRingKeepsLatestEntries(t *testing.T) {
+	ring := NewRing(2)
+	for _, msg := range []string{"one", "two", "three"} {
+		ring.Add(Entry{Message: msg})
+	}
+
+	entries := ring.Entries()
+	assert.Equal(t, "two", entries[0].Message)
+	assert.Equal(t, "three", entries[1].Message)
+	assert.Equal(t, uint64(3), ring.Written())
+}
+
+func TestRingLogger(t *testing.T) {
+	logger, ring := NewRingLogger()
+	logger.Warn("Log file unavailable", "path", "/nonexistent/flash.log")
+
+	entries := ring.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, log.WarnLevel, entries[0].Level)
+	assert.Equal(t, "path=/nonexistent/flash.log", entries[0].Fields)
+}
@@ -1,18 +1,25 @@
 package tui
 
 import (
+	"github.com/ardevd/flash/internal/config"
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// forwarded passes a message to the dashboard while another view is shown
+type forwarded struct {
+	msg tea.Msg
+}
+
 // Base model that handles logic common to all views
 type BaseModel struct {
 	NavStack []tea.Model // Navigation stack to store views
+	settings config.Settings
 }
 
-func NewBaseModel(m tea.Model) *BaseModel {
+func NewBaseModel(m tea.Model, settings config.Settings) *BaseModel {
 	initialNavStack := []tea.Model{m}
-	return &BaseModel{NavStack: initialNavStack}
+	return &BaseModel{NavStack: initialNavStack, settings: settings}
 }
 
 func (b *BaseModel) pushView(m tea.Model) {
@@ -33,11 +40,25 @@ func (m *BaseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tea.WindowSizeMsg:
 		windowSizeMsg = msg
+	case Connected, DataLoaded, PartialDataLoaded, DataLoadFailed, NodeEvent, partsReloaded:
+		// Connecting, loading and events happen in the background. While
+		// another view is shown, the dashboard at the bottom of the stack
+		// takes the results.
+		if len(m.NavStack) > 1 {
+			m.NavStack[0].Update(forwarded{msg})
+		}
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, Keymap.Quit):
 			return m, tea.Quit
+		case key.Matches(msg, Keymap.Logs) && len(m.NavStack) > 0:
+			return m.toggleLogs()
 		case key.Matches(msg, Keymap.Back):
+			if len(m.NavStack) > 1 {
+				// Lay out the previous view again, the window may have
+				// been resized in the meantime
+				return m.popView().Update(windowSizeMsg)
+			}
 			newModel := m.popView()
 			return newModel, nil
 		}
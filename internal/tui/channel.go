@@ -15,6 +15,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
 	"github.com/lightninglabs/lndclient"
 )
 
@@ -23,7 +24,7 @@ type ChannelModel struct {
 	styles            *Styles
 	channel           lnd.Channel
 	state             ChannelModelState
-	lndService        *lndclient.GrpcLndServices
+	backend           lnd.NodeBackend
 	ctx               context.Context
 	htlcTable         table.Model
 	base              *BaseModel
@@ -33,6 +34,7 @@ type ChannelModel struct {
 	channelPolicyForm *huh.Form
 	messageChan       chan channelStatusMsg
 	messages          []channelStatusMsg
+	permissions       lnd.Permissions
 }
 
 // ChannelState indicates the state of the selected Channel model
@@ -85,11 +87,16 @@ func (k keyMap) FullHelp() [][]key.Binding {
 	}
 }
 
-// NewChannelModel returns a new Channel Model.
-func NewChannelModel(service *lndclient.GrpcLndServices, channel lnd.Channel, base *BaseModel) *ChannelModel {
+// NewChannelModel returns a new Channel Model. Operations the macaroon does
+// not allow are disabled.
+func NewChannelModel(backend lnd.NodeBackend, channel lnd.Channel, base *BaseModel, permissions lnd.Permissions) *ChannelModel {
 	const numStatusMessages = 1
-	m := ChannelModel{lndService: service, ctx: context.Background(), channel: channel, base: base, help: help.New(), keys: Keymap,
-		messages: make([]channelStatusMsg, numStatusMessages), messageChan: make(chan channelStatusMsg)}
+	m := ChannelModel{backend: backend, ctx: context.Background(), channel: channel, base: base, help: help.New(), keys: Keymap,
+		messages: make([]channelStatusMsg, numStatusMessages), messageChan: make(chan channelStatusMsg), permissions: permissions}
+
+	m.keys.Close.SetEnabled(permissions.Allowed(lnd.CloseChannel))
+	m.keys.ForceClose.SetEnabled(permissions.Allowed(lnd.ForceCloseChannel))
+	m.keys.Update.SetEnabled(permissions.Allowed(lnd.UpdateChannelPolicy))
 
 	m.styles = GetDefaultStyles()
 	m.base.pushView(&m)
@@ -124,15 +131,15 @@ func (m *ChannelModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch {
 		case key.Matches(msg, Keymap.Help):
 			m.help.ShowAll = !m.help.ShowAll
-		case key.Matches(msg, Keymap.Update):
+		case key.Matches(msg, m.keys.Update):
 			return m, updateChannelPolicyMsg
-		case key.Matches(msg, Keymap.ForceClose):
+		case key.Matches(msg, m.keys.ForceClose):
 			// Force close channel
 			if m.state == ChannelStateNone {
 				m.channelCloseForm = m.getChannelOperationForm("Force Close Channel", "Latest commitment transaction will be broadcast. Are you sure?")
 				m.state = ChannelStateWantClose
 			}
-		case key.Matches(msg, Keymap.Close):
+		case key.Matches(msg, m.keys.Close):
 			// Close channel
 			if m.state == ChannelStateNone {
 				m.channelCloseForm = m.getChannelOperationForm("Close Channel", "A cooperative close will be issued. Are you sure?")
@@ -161,8 +168,9 @@ func (m *ChannelModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		if m.channelPolicyForm.State == huh.StateCompleted {
-			// Update channel policy
+			// Update channel policy once, the completed form is dropped
 			m.state = ChannelStateNone
+			m.channelPolicyForm = nil
 			m.updateChannelPolicy()
 		}
 	}
@@ -255,21 +263,21 @@ func (m ChannelModel) updateChannelPolicy() {
 	// Parse the base rate
 	baseRate, err := strconv.ParseInt(policyBaseRate, 10, 64)
 	if err != nil {
-		fmt.Println("Error:", err)
+		log.Error("Invalid base rate", "value", policyBaseRate, "err", err)
 		return
 	}
 
 	// Parse fee rate
 	feeRate, err := strconv.ParseFloat(policyFeeRate, 64)
 	if err != nil {
-		fmt.Println("Error:", err)
+		log.Error("Invalid fee rate", "value", policyFeeRate, "err", err)
 		return
 	}
 
 	// Parse timelock
 	timeLockDelta, err := strconv.ParseUint(policyTimeLockDelta, 10, 32)
 	if err != nil {
-		fmt.Println("Error:", err)
+		log.Error("Invalid time lock delta", "value", policyTimeLockDelta, "err", err)
 		return
 	}
 
@@ -280,18 +288,16 @@ func (m ChannelModel) updateChannelPolicy() {
 		TimeLockDelta: uint32(timeLockDelta),
 	}
 
-	// Get chanpoint
-	outPoint, err := lndclient.NewOutpointFromStr(m.channel.Info.ChannelPoint)
-	if err != nil {
-		fmt.Println("Unable to parse channel outpoint")
-	}
-
 	// Update the channel policy
-	err = m.lndService.Client.UpdateChanPolicy(m.ctx, updateRequest, outPoint)
+	err = m.backend.UpdateChannelPolicy(m.ctx, m.channel.Info.ChannelPoint, updateRequest)
 
 	if err != nil {
-		fmt.Println("Error updating channel policy", err)
+		log.Error("Unable to update channel policy", "channel", m.channel.Info.ChannelPoint, "err", err)
+		return
 	}
+
+	log.Info("Channel policy updated", "channel", m.channel.Info.ChannelPoint,
+		"base_fee_msat", baseRate, "fee_rate_ppm", policyFeeRate, "time_lock_delta", timeLockDelta)
 }
 
 // Get the current channel state view
@@ -309,7 +315,7 @@ func (m ChannelModel) getChannelStateView() string {
 
 // Get current channel parameters view
 func (m ChannelModel) getChannelParameters() string {
-	edge, err := m.lndService.Client.GetChanInfo(m.ctx, m.channel.Info.ChannelID)
+	edge, err := m.backend.GetChannelEdge(m.ctx, m.channel.Info.ChannelID)
 	if err != nil {
 		return "Error retrieving channel edge info"
 	}
@@ -372,7 +378,7 @@ func (m ChannelModel) getChannelStats() string {
 	}
 
 	return m.styles.Keyword("Stats\n") + m.styles.SubKeyword("Activity (total/sent/received): ") + fmt.Sprintf("%v/%v/%v BTC", totalSum.ToBTC(), totalSent.ToBTC(), totalReceived.ToBTC()) + "\n" +
-		m.styles.SubKeyword("Unsettled Balance: ") + unsettledBalance.String() + "\n" +
+		m.styles.SubKeyword("Unsettled Balance: ") + formatAmount(unsettledBalance, m.base.settings) + "\n" +
 		m.styles.SubKeyword("Channel Type: ") + channelType + "\n" +
 		m.styles.SubKeyword("Channel Opener: ") + openType + "\n" +
 		m.styles.SubKeyword("Current Commit Fee: ") + fmt.Sprintf("%v sats", m.channel.Info.CommitFee.ToUnit(btcutil.AmountSatoshi))
@@ -384,24 +390,23 @@ func (m ChannelModel) getChannelBalanceView() string {
 
 // Force Close/Close the channel.
 func (m ChannelModel) closeChannel() {
-	outPoint, err := lndclient.NewOutpointFromStr(m.channel.Info.ChannelPoint)
-	if err != nil {
-		fmt.Println("Unable to parse channel outpoint")
-	}
-
 	forceClose := m.state == ChannelStateWantForceClose
 
-	var targetBlocks int32 = 10
+	targetBlocks := m.base.settings.CloseTargetBlocks
 	if forceClose {
 		// A force close can't include custom fee
 		targetBlocks = 0
 	}
 
-	updateChan, errorsChan, err := m.lndService.Client.CloseChannel(m.ctx, outPoint, forceClose, targetBlocks, nil)
+	updateChan, errorsChan, err := m.backend.CloseChannel(m.ctx, m.channel.Info.ChannelPoint, forceClose, targetBlocks)
 
 	if err != nil {
-		fmt.Println("Unable to close channel", err)
+		log.Error("Unable to close channel", "channel", m.channel.Info.ChannelPoint, "force", forceClose, "err", err)
+		m.messageChan <- channelStatusMsg{message: "Could not close channel: " + ErrorMessage(err)}
+		return
 	}
+	log.Info("Closing channel", "channel", m.channel.Info.ChannelPoint, "force", forceClose)
+
 	// // Use a separate goroutine to receive updates and errors from the lndService
 	go func() {
 		for {
@@ -409,16 +414,19 @@ func (m ChannelModel) closeChannel() {
 			case update, ok := <-updateChan:
 				if !ok {
 					// Channel closed
+					log.Info("Channel closed", "channel", m.channel.Info.ChannelPoint)
 					m.messageChan <- channelStatusMsg{message: "Channel closed"}
 					return
 				}
 
+				log.Info("Closing transaction broadcast", "channel", m.channel.Info.ChannelPoint, "txid", update.CloseTxid())
 				m.messageChan <- channelStatusMsg{message: "Broadasting closing transaction: " + update.CloseTxid().String()}
 			case errorUpdate, ok := <-errorsChan:
 				if !ok {
 					m.messageChan <- channelStatusMsg{message: "Could not close channel: " + errorUpdate.Error()}
 					return
 				}
+				log.Error("Error closing channel", "channel", m.channel.Info.ChannelPoint, "err", errorUpdate)
 				m.messageChan <- channelStatusMsg{message: "Error: " + errorUpdate.Error()}
 			}
 		}
@@ -498,7 +506,9 @@ func (m ChannelModel) View() string {
 
 		bottomView := lipgloss.JoinVertical(lipgloss.Left, s.Base.Render(m.getStatusMessages()))
 
-		helpView := s.Base.Render(m.help.View(m.keys))
+		helpView := s.Base.Render(m.help.View(m.keys) + m.getUnavailableActions())
+
+		statusView := s.Base.Render(s.connectionStatus(m.backend))
 
 		return lipgloss.JoinVertical(lipgloss.Left,
 			topView,
@@ -506,7 +516,8 @@ func (m ChannelModel) View() string {
 			channelBalanceView,
 			htlcTableView,
 			bottomView,
-			helpView)
+			helpView,
+			statusView)
 	} else if m.state == ChannelStateWantForceClose || m.state == ChannelStateWantClose {
 		return m.getFormView(strings.TrimSuffix(m.channelCloseForm.View(), "\n\n"))
 	} else if m.state == ChannelPolicyUpdate {
@@ -516,6 +527,27 @@ func (m ChannelModel) View() string {
 	return ""
 }
 
+// Explain why the disabled operations are unavailable
+func (m ChannelModel) getUnavailableActions() string {
+	actions := []struct {
+		binding key.Binding
+		action  lnd.Action
+	}{
+		{Keymap.Close, lnd.CloseChannel},
+		{Keymap.ForceClose, lnd.ForceCloseChannel},
+		{Keymap.Update, lnd.UpdateChannelPolicy},
+	}
+
+	s := ""
+	for _, a := range actions {
+		if !m.permissions.Allowed(a.action) {
+			s += "\n" + a.binding.Help().Desc + " unavailable: " + m.permissions.Reason(a.action)
+		}
+	}
+
+	return m.styles.Help.Render(s)
+}
+
 func (m ChannelModel) getFormView(view string) string {
 	form := lipgloss.DefaultRenderer().NewStyle().Margin(1, 0).Render(view)
 	return lipgloss.JoinVertical(lipgloss.Left, form)
@@ -1,6 +1,9 @@
 package tui
 
 import (
+	"errors"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/key"
 )
 
@@ -19,6 +22,7 @@ type keyMap struct {
 	ReverseTab      key.Binding
 	Help            key.Binding
 	OfflineChannels key.Binding
+	Logs            key.Binding
 }
 
 // Keymap reusable key mappings shared across models
@@ -40,6 +44,10 @@ var Keymap = keyMap{
 		key.WithKeys("o"),
 		key.WithHelp("o", "offline channels"),
 	),
+	Logs: key.NewBinding(
+		key.WithKeys("ctrl+l"),
+		key.WithHelp("ctrl+l", "log console"),
+	),
 	Update: key.NewBinding(
 		key.WithKeys("u"),
 		key.WithHelp("u", "update"),
@@ -79,3 +87,42 @@ var Keymap = keyMap{
 		key.WithKeys("right"),
 	),
 }
+
+// Bindings of the keymap by the action names used in the config file
+func (k *keyMap) bindings() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"close":            &k.Close,
+		"force_close":      &k.ForceClose,
+		"update":           &k.Update,
+		"enter":            &k.Enter,
+		"refresh":          &k.Refresh,
+		"delete":           &k.Delete,
+		"back":             &k.Back,
+		"quit":             &k.Quit,
+		"left":             &k.Left,
+		"right":            &k.Right,
+		"tab":              &k.Tab,
+		"reverse_tab":      &k.ReverseTab,
+		"help":             &k.Help,
+		"offline_channels": &k.OfflineChannels,
+		"logs":             &k.Logs,
+	}
+}
+
+// Bind the actions to the given keys, keeping their help text
+func (k *keyMap) rebind(keys map[string][]string) error {
+	bindings := k.bindings()
+	for action, actionKeys := range keys {
+		b, ok := bindings[action]
+		if !ok {
+			return errors.New("unknown key binding action " + action)
+		}
+
+		b.SetKeys(actionKeys...)
+		if desc := b.Help().Desc; desc != "" {
+			b.SetHelp(strings.Join(actionKeys, "/"), desc)
+		}
+	}
+
+	return nil
+}
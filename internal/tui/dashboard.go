@@ -2,14 +2,16 @@ package tui
 
 import (
 	"context"
+	"strings"
+	"time"
 
+	"github.com/ardevd/flash/internal/config"
 	"github.com/ardevd/flash/internal/lnd"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/lightninglabs/lndclient"
 )
 
 type dashboardComponent int
@@ -26,8 +28,9 @@ const (
 
 var formSelection string
 
-func InitDashboard(service *lndclient.GrpcLndServices, nodeData lnd.NodeData) *DashboardModel {
-	m := DashboardModel{lndService: service, ctx: context.Background(), nodeData: nodeData, keys: Keymap}
+func InitDashboard(backend lnd.NodeBackend, nodeData lnd.NodeData, label string, settings config.Settings) *DashboardModel {
+	m := DashboardModel{backend: backend, ctx: context.Background(), nodeData: nodeData, label: label, settings: settings, keys: Keymap,
+		lastUpdated: time.Now(), live: true}
 	m.styles = GetDefaultStyles()
 	return &m
 }
@@ -36,6 +39,15 @@ func (m *DashboardModel) initData(width, height int) {
 
 	adjustedHeight := height + height/3
 	adjustedCompressedHeight := height + height/2
+
+	// Keep the lists and their selection when the window is resized
+	if m.lists != nil {
+		m.lists[channels].SetSize(width, adjustedHeight/2)
+		m.lists[payments].SetSize(width, adjustedCompressedHeight/5)
+		m.lists[pendingChannels].SetSize(width, adjustedCompressedHeight/5)
+		return
+	}
+
 	defaultList := list.New([]list.Item{}, list.NewDefaultDelegate(), width, adjustedHeight/2)
 	compressedList := list.New([]list.Item{}, list.NewDefaultDelegate(), width, adjustedCompressedHeight/5)
 	defaultList.SetShowHelp(true)
@@ -61,22 +73,59 @@ func (m *DashboardModel) initData(width, height int) {
 	m.lists[pendingChannels].SetItems(m.nodeData.GetPendingChannelsAsListItems())
 	m.lists[pendingChannels].SetStatusBarItemName("pending channel", "pending channels")
 
-	m.base = *NewBaseModel(m)
+	m.base = *NewBaseModel(m, m.settings)
 }
 
-func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+func (m *DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Base model logic
 	model, cmd := m.base.Update(msg)
 	if cmd != nil {
 		return model, cmd
 	}
 
+	var cmds []tea.Cmd
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		windowSizeMsg = msg
 		v, h := m.styles.BorderedStyle.GetFrameSize()
 		m.initData(windowSizeMsg.Width-h, windowSizeMsg.Height-v)
 		m.loaded = true
+		// Ticks are lost while other views are shown, so the dashboard
+		// restarts the refresh loop whenever it is shown again and reloads
+		// what events changed in the meantime
+		cmds = append(cmds, m.restartRefresh(), m.reloadStale())
+
+	case refreshTick:
+		if msg.generation != m.refreshGeneration || m.refreshing {
+			return m, nil
+		}
+		m.refreshing = true
+		return m, loadData(m.backend, m.ctx, m.refreshGeneration)
+
+	case dataRefreshed:
+		return m, m.applyRefresh(msg)
+
+	case NodeEvent:
+		return m, m.handleEvent(msg)
+
+	case reloadDue:
+		return m, m.reloadStale()
+
+	case Connected, DataLoaded, PartialDataLoaded, DataLoadFailed:
+		return m, m.handleLoading(msg)
+
+	case forwarded:
+		switch forwardedMsg := msg.msg.(type) {
+		case NodeEvent:
+			return m, m.handleHiddenEvent(forwardedMsg)
+		case partsReloaded:
+			return m, m.applyResult(forwardedMsg.parts, forwardedMsg.nodeData, forwardedMsg.errs)
+		}
+		return m, m.handleLoading(msg.msg)
+
+	case partsReloaded:
+		return m, m.applyResult(msg.parts, msg.nodeData, msg.errs)
 
 	case tea.KeyMsg:
 		switch {
@@ -87,9 +136,17 @@ func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.Prev()
 			return m, nil
 		case key.Matches(msg, Keymap.OfflineChannels):
-			m.lists[channels].SetItems(m.nodeData.GetChannelsAsListItems(true))
+			m.offlineOnly = true
+			cmds = append(cmds, setItemsKeepSelection(&m.lists[channels], m.nodeData.GetChannelsAsListItems(true)))
 		case key.Matches(msg, Keymap.Refresh):
-			m.lists[channels].SetItems(m.nodeData.GetChannelsAsListItems(false))
+			m.offlineOnly = false
+			cmds = append(cmds, setItemsKeepSelection(&m.lists[channels], m.nodeData.GetChannelsAsListItems(false)))
+			if m.live && !m.refreshing {
+				// Replace the scheduled refresh with this one
+				m.refreshGeneration++
+				m.refreshing = true
+				cmds = append(cmds, loadData(m.backend, m.ctx, m.refreshGeneration))
+			}
 		case key.Matches(msg, Keymap.Enter):
 			switch m.focused {
 			case channels:
@@ -98,8 +155,6 @@ func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
-	var cmds []tea.Cmd
-
 	switch m.focused {
 	case payments:
 		fallthrough
@@ -132,21 +187,147 @@ func (m DashboardModel) Init() tea.Cmd {
 	return nil
 }
 
+// Start a new refresh loop, replacing the running one. Cached data shown
+// without a connection isn't refreshed.
+func (m *DashboardModel) restartRefresh() tea.Cmd {
+	m.refreshGeneration++
+	m.refreshing = false
+	m.reloadScheduled = false
+	if !m.live {
+		return nil
+	}
+
+	return scheduleRefresh(m.settings.RefreshInterval, m.refreshGeneration)
+}
+
+// Take the results of connecting to the node and loading its data while
+// cached data is shown
+func (m *DashboardModel) handleLoading(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case Connected:
+		m.backend = msg.Backend
+		m.live = true
+
+	case DataLoaded:
+		return m.applyLoaded(lnd.NodeData(msg), nil)
+
+	case PartialDataLoaded:
+		return m.applyLoaded(msg.NodeData, msg.Errors)
+
+	case DataLoadFailed:
+		// Retry with the refresh loop if the connection was made
+		m.refreshErr = msg.Err
+		return m.restartRefresh()
+	}
+
+	return nil
+}
+
+// Show the node data loaded after connecting. Cached parts that failed to
+// load are kept and the error is shown.
+func (m *DashboardModel) applyLoaded(nodeData lnd.NodeData, errs DataErrors) tea.Cmd {
+	if errs == nil {
+		m.cachedAt = time.Time{}
+		m.lastUpdated = time.Now()
+	}
+
+	m.nodeData.Permissions = nodeData.Permissions
+	if m.loaded {
+		m.forms[0] = m.generatePaymentToolsForm()
+	}
+	return tea.Batch(m.applyResult(allParts, nodeData, errs), m.restartRefresh())
+}
+
+// Show the refreshed node data and schedule the next refresh. The data of a
+// failed refresh is kept and the error shown next to the time of the last
+// update. Parts that loaded are shown either way.
+func (m *DashboardModel) applyRefresh(msg dataRefreshed) tea.Cmd {
+	var cmds []tea.Cmd
+	if msg.generation == m.refreshGeneration {
+		m.refreshing = false
+		cmds = append(cmds, scheduleRefresh(m.settings.RefreshInterval, m.refreshGeneration))
+	}
+
+	if msg.errs == nil {
+		m.lastUpdated = time.Now()
+		m.cachedAt = time.Time{}
+	}
+	return tea.Batch(append(cmds, m.applyResult(allParts, msg.nodeData, msg.errs))...)
+}
+
+// Show the parts that loaded and the error of the first one that failed.
+// Placeholders of parts that failed again show the new error.
+func (m *DashboardModel) applyResult(parts dataPart, nodeData lnd.NodeData, errs DataErrors) tea.Cmd {
+	m.refreshErr = errs.first()
+	for part, err := range errs {
+		if _, ok := m.partErrs[part]; ok {
+			m.partErrs[part] = err
+		}
+	}
+
+	return m.applyParts(parts&^errs.parts(), nodeData)
+}
+
+// Take the parts from the loaded node data and show them in place of their
+// placeholders
+func (m *DashboardModel) applyParts(parts dataPart, nodeData lnd.NodeData) tea.Cmd {
+	copyParts(&m.nodeData, nodeData, parts)
+	for part := range m.partErrs {
+		if parts&part != 0 {
+			delete(m.partErrs, part)
+		}
+	}
+
+	if !m.loaded {
+		return nil
+	}
+
+	var cmds []tea.Cmd
+	if parts&partChannels != 0 {
+		cmds = append(cmds, setItemsKeepSelection(&m.lists[channels], m.nodeData.GetChannelsAsListItems(m.offlineOnly)))
+	}
+
+	if parts&partPayments != 0 {
+		cmds = append(cmds, setItemsKeepSelection(&m.lists[payments], m.nodeData.GetPaymentsAsListItems()))
+	}
+
+	if parts&partPendingChannels != 0 {
+		cmds = append(cmds, setItemsKeepSelection(&m.lists[pendingChannels], m.nodeData.GetPendingChannelsAsListItems()))
+	}
+
+	return tea.Batch(cmds...)
+}
+
+// Get the view of the list, or a placeholder with the error if its part
+// failed to load
+func (m DashboardModel) getListView(component dashboardComponent, part dataPart) string {
+	l := m.lists[component]
+	err, failed := m.partErrs[part]
+	if !failed {
+		return l.View()
+	}
+
+	return lipgloss.NewStyle().Width(l.Width()).Height(l.Height()).Render(
+		l.Styles.Title.Render(l.Title) + "\n\n" +
+			m.styles.NegativeString(l.Title+" failed to load") + "\n" + ErrorMessage(err) + "\n\n" +
+			m.styles.Help.Render("Loaded again with the next refresh"))
+}
+
 func (m DashboardModel) getCompressedListViews() string {
 	s := m.styles
 	switch m.focused {
 	case payments:
 		return lipgloss.JoinVertical(lipgloss.Center,
-			s.FocusedStyle.Render(m.lists[payments].View()),
-			s.BorderedStyle.Render(m.lists[pendingChannels].View()))
+			s.FocusedStyle.Render(m.getListView(payments, partPayments)),
+			s.BorderedStyle.Render(m.getListView(pendingChannels, partPendingChannels)))
 	case pendingChannels:
 		return lipgloss.JoinVertical(lipgloss.Center,
-			s.BorderedStyle.Render(m.lists[payments].View()),
-			s.FocusedStyle.Render(m.lists[pendingChannels].View()))
+			s.BorderedStyle.Render(m.getListView(payments, partPayments)),
+			s.FocusedStyle.Render(m.getListView(pendingChannels, partPendingChannels)))
 	default:
 		return lipgloss.JoinVertical(lipgloss.Center,
-			s.BorderedStyle.Render(m.lists[payments].View()),
-			s.BorderedStyle.Render(m.lists[pendingChannels].View()))
+			s.BorderedStyle.Render(m.getListView(payments, partPayments)),
+			s.BorderedStyle.Render(m.getListView(pendingChannels, partPendingChannels)))
 	}
 
 }
@@ -155,7 +336,7 @@ func (m DashboardModel) View() string {
 	s := m.styles
 
 	if m.loaded {
-		channelsView := m.lists[channels].View()
+		channelsView := m.getListView(channels, partChannels)
 
 		var listsView string
 		switch m.focused {
@@ -175,14 +356,18 @@ func (m DashboardModel) View() string {
 			)
 		}
 
-		nodeInfoView := lipgloss.JoinVertical(lipgloss.Left, s.BorderedStyle.Render(
-			s.Keyword(m.nodeData.NodeInfo.Alias)+"\n"+m.nodeData.NodeInfo.PubKey+
-				"\nLnd v"+m.nodeData.NodeInfo.Version))
+		nodeInfo := m.getNodeTitle() + "\n" + m.nodeData.NodeInfo.PubKey +
+			"\n" + m.getNodeVersion() + "\n" + m.getLastUpdated()
+		balances := s.SubKeyword("Lightning Balance ") + formatAmount(m.nodeData.NodeInfo.ChannelBalance, m.settings) +
+			"\n" + s.SubKeyword("Lightning Capacity ") + formatAmount(m.nodeData.NodeInfo.TotalCapacity, m.settings) +
+			"\n" + s.SubKeyword("Onchain Balance ") + formatAmount(m.nodeData.NodeInfo.OnChainBalance, m.settings)
+		if err, failed := m.partErrs[partNodeInfo]; failed {
+			nodeInfo = s.NegativeString("Node info failed to load") + "\n" + ErrorMessage(err) + "\n\n" + m.getLastUpdated()
+			balances = s.Help.Render("Balances unavailable")
+		}
 
-		balanceView := lipgloss.JoinVertical(lipgloss.Left, s.BorderedStyle.Render(
-			s.SubKeyword("Lightning Balance ")+m.nodeData.NodeInfo.ChannelBalance+
-				"\n"+s.SubKeyword("Lightning Capacity ")+m.nodeData.NodeInfo.TotalCapacity+
-				"\n"+s.SubKeyword("Onchain Balance ")+m.nodeData.NodeInfo.OnChainBalance))
+		nodeInfoView := lipgloss.JoinVertical(lipgloss.Left, s.BorderedStyle.Render(nodeInfo))
+		balanceView := lipgloss.JoinVertical(lipgloss.Left, s.BorderedStyle.Render(balances))
 
 		topView := lipgloss.JoinHorizontal(lipgloss.Left,
 			nodeInfoView, balanceView)
@@ -190,17 +375,70 @@ func (m DashboardModel) View() string {
 		toolsView := lipgloss.JoinHorizontal(lipgloss.Left,
 			m.getPaymentTools(), m.getChannelTools(), m.getMessageTools())
 
+		statusView := s.Base.Render(s.connectionStatus(m.backend))
+
 		return lipgloss.JoinVertical(
 			lipgloss.Left,
 			topView,
 			listsView,
-			toolsView)
+			toolsView,
+			statusView)
 
 	}
 
 	return "Loading..."
 }
 
+// Get the node alias along with the profile label and a badge for the network
+func (m DashboardModel) getNodeTitle() string {
+	s := m.styles
+	title := s.Keyword(m.nodeData.NodeInfo.Alias)
+	if m.label != "" {
+		title += " " + s.SubKeyword("("+m.label+")")
+	}
+
+	network := m.nodeData.NodeInfo.Network
+	if network == "" {
+		return title
+	}
+
+	badge := s.TestNetworkBadge
+	if network == "mainnet" {
+		badge = s.MainnetBadge
+	}
+
+	return title + " " + badge.Render(strings.ToUpper(network))
+}
+
+// Get the name and version of the node implementation
+func (m DashboardModel) getNodeVersion() string {
+	name := "Lnd"
+	if m.nodeData.NodeInfo.Implementation == lnd.ImplementationCLN {
+		name = "Core Lightning"
+	}
+
+	return name + " v" + m.nodeData.NodeInfo.Version
+}
+
+// Get the time the node data was last loaded and the error of a failed
+// refresh
+func (m DashboardModel) getLastUpdated() string {
+	if !m.cachedAt.IsZero() {
+		cached := m.styles.NegativeString("cached data from " + m.cachedAt.Format(time.DateTime))
+		if m.refreshErr != nil {
+			return cached + m.styles.Help.Render(" · "+ErrorMessage(m.refreshErr))
+		}
+		return cached
+	}
+
+	updated := "last updated " + m.lastUpdated.Format(time.TimeOnly)
+	if m.refreshErr != nil {
+		return m.styles.Help.Render(updated+" · ") + m.styles.NegativeString("refresh failed: "+ErrorMessage(m.refreshErr))
+	}
+
+	return m.styles.Help.Render(updated)
+}
+
 func (m *DashboardModel) getPaymentTools() string {
 	style := m.styles.BorderedStyle
 	if m.focused == paymentTools {
@@ -238,6 +476,13 @@ func (m *DashboardModel) generatePaymentToolsForm() *huh.Form {
 		).
 		Value(&formSelection)
 
+	// Hide payments the macaroon is not allowed to make
+	permissions := m.nodeData.Permissions
+	if !permissions.Allowed(lnd.SendPayment) {
+		s.Options(huh.NewOption("Generate Invoice", OPTION_PAYMENT_RECEIVE)).
+			Description(m.styles.Help.Render("Send Payment unavailable:\n" + permissions.Reason(lnd.SendPayment)))
+	}
+
 	return huh.NewForm(huh.NewGroup(s))
 }
 
@@ -269,8 +514,11 @@ func (m *DashboardModel) generateMessageToolsForm() *huh.Form {
 }
 
 func (m *DashboardModel) handleChannelClick() (tea.Model, tea.Cmd) {
-	selectedChannel := m.lists[m.focused].SelectedItem().(lnd.Channel)
-	return NewChannelModel(m.lndService, selectedChannel, &m.base).Update(windowSizeMsg)
+	selectedChannel, ok := m.lists[m.focused].SelectedItem().(lnd.Channel)
+	if !ok {
+		return m, nil
+	}
+	return NewChannelModel(m.backend, selectedChannel, &m.base, m.nodeData.Permissions).Update(windowSizeMsg)
 }
 
 func (m *DashboardModel) handleFormClick(component dashboardComponent) (tea.Model, tea.Cmd) {
@@ -278,18 +526,18 @@ func (m *DashboardModel) handleFormClick(component dashboardComponent) (tea.Mode
 	switch component {
 	case paymentTools:
 		if m.forms[0].GetString("payments") == OPTION_PAYMENT_RECEIVE {
-			i = newInvoiceModel(m.ctx, &m.base, m.lndService, StateNone)
+			i = newInvoiceModel(m.ctx, &m.base, m.backend, StateNone)
 		} else {
-			i = newPayInvoiceModel(m.lndService, &m.base)
+			i = newPayInvoiceModel(m.backend, &m.base)
 		}
 		m.forms[0] = m.generatePaymentToolsForm()
 	case messageTools:
 		if m.forms[2].GetString("messages") == OPTION_MESSAGE_SIGN {
 
-			i = newSignMessageModel(m.lndService, &m.base)
+			i = newSignMessageModel(m.backend, &m.base)
 		} else {
 
-			i = newVerifyMessageModel(m.lndService, &m.base)
+			i = newVerifyMessageModel(m.backend, &m.base)
 		}
 		m.forms[2] = m.generateMessageToolsForm()
 	}
@@ -336,14 +584,35 @@ func (m *DashboardModel) Next() {
 
 // Model for the Dashboard view
 type DashboardModel struct {
-	styles     *Styles
-	focused    dashboardComponent
-	lists      []list.Model
-	forms      []*huh.Form
-	lndService *lndclient.GrpcLndServices
-	nodeData   lnd.NodeData
-	ctx        context.Context
-	loaded     bool
-	base       BaseModel
-	keys       keyMap
+	styles   *Styles
+	focused  dashboardComponent
+	lists    []list.Model
+	forms    []*huh.Form
+	backend  lnd.NodeBackend
+	nodeData lnd.NodeData
+	label    string
+	settings config.Settings
+	ctx      context.Context
+	loaded   bool
+	base     BaseModel
+	keys     keyMap
+
+	// Only offline channels are listed
+	offlineOnly bool
+	// Background refresh of the node data
+	lastUpdated       time.Time
+	refreshErr        error
+	refreshing        bool
+	refreshGeneration int
+	// Parts of the node data changed by events and waiting for a reload
+	staleParts      dataPart
+	reloadScheduled bool
+	// Errors of the parts that failed to load, shown in place of their data
+	// until a refresh loads them
+	partErrs DataErrors
+	// Time the shown data was cached, zero once it was loaded from the node
+	cachedAt time.Time
+	// Connected to the node, false while cached data is shown without a
+	// connection
+	live bool
 }
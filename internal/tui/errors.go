@@ -0,0 +1,38 @@
+package tui
+
+import (
+	"errors"
+
+	"github.com/ardevd/flash/internal/cache"
+	"github.com/ardevd/flash/internal/credentials"
+	"github.com/ardevd/flash/internal/lnd"
+)
+
+// ErrorMessage explains errors of the credentials and lnd packages in terms
+// of what the user can do about them. Other errors are returned as is.
+func ErrorMessage(err error) string {
+	var fileErr *credentials.FileError
+	file := "the authentication file"
+	if errors.As(err, &fileErr) {
+		file += " " + fileErr.Path
+	}
+
+	switch {
+	case errors.Is(err, credentials.ErrWrongKey):
+		return "The encryption key or passphrase does not unlock " + file + ", or it was modified"
+	case errors.Is(err, credentials.ErrTruncatedHeader):
+		return "The header of " + file + " is incomplete, it was probably cut off while copying"
+	case errors.Is(err, credentials.ErrCorruptedFile):
+		return "Unable to read " + file + ", it is damaged or not a flash authentication file"
+	case errors.Is(err, credentials.ErrInvalidKey):
+		return "The encryption key is not valid, make sure it was copied completely"
+	case errors.Is(err, lnd.ErrRPCUnavailable):
+		return "The node can't be reached, check the RPC host and that the node is running (" + err.Error() + ")"
+	case errors.Is(err, cache.ErrOffline):
+		return "Flash is offline and shows cached data, connect to the node to do this"
+	case errors.Is(err, lnd.ErrPermissionDenied):
+		return "The macaroon does not allow this, use a macaroon with more permissions (" + err.Error() + ")"
+	}
+
+	return err.Error()
+}
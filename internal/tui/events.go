@@ -0,0 +1,132 @@
+package tui
+
+import (
+	"context"
+	"time"
+
+	"github.com/ardevd/flash/internal/lnd"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// NodeEvent passes an update pushed by the node to the dashboard. While
+// another view is shown, the dashboard reloads what the event changed once
+// it is shown again.
+type NodeEvent lnd.NodeEvent
+
+// Time to collect events before the parts of the node data they changed are
+// loaded, so a burst of forwards causes a single reload
+const reloadDelay = 500 * time.Millisecond
+
+type reloadDue struct{}
+
+// partsReloaded carries the parts of the node data loaded after events
+type partsReloaded struct {
+	parts    dataPart
+	nodeData lnd.NodeData
+	errs     DataErrors
+}
+
+// Parts of the node data the event changes that aren't in the event itself
+func (e NodeEvent) parts() dataPart {
+	switch e.Kind {
+	case lnd.ChannelOpened, lnd.ChannelClosed, lnd.ChannelPending:
+		return partPendingChannels | partNodeInfo
+	case lnd.BalanceChanged:
+		return partChannels | partNodeInfo
+	case lnd.PaymentSent:
+		return partPayments | partChannels | partNodeInfo
+	case lnd.OnChainChanged:
+		return partNodeInfo
+	}
+
+	return 0
+}
+
+func loadParts(backend lnd.NodeBackend, ctx context.Context, parts dataPart) tea.Cmd {
+	return func() tea.Msg {
+		nodeData, errs := getDataParts(backend, ctx, parts, nil)
+		return partsReloaded{parts: parts, nodeData: nodeData, errs: errs}
+	}
+}
+
+// Patch the channels with the event and reload what it changed otherwise
+func (m *DashboardModel) handleEvent(event NodeEvent) tea.Cmd {
+	return tea.Batch(m.patchChannels(event), m.scheduleReload(event.parts()))
+}
+
+// Patch the channels with the event while another view is shown and mark
+// what it changed otherwise for reloading when the dashboard is shown again
+func (m *DashboardModel) handleHiddenEvent(event NodeEvent) tea.Cmd {
+	m.staleParts |= event.parts()
+	return m.patchChannels(event)
+}
+
+// Update the channels the event opened, closed or changed the state of
+func (m *DashboardModel) patchChannels(event NodeEvent) tea.Cmd {
+	channelsChanged := true
+	switch event.Kind {
+	case lnd.ChannelActive, lnd.ChannelInactive:
+		for i := range m.nodeData.Channels {
+			if m.nodeData.Channels[i].Info.ChannelPoint == event.ChannelPoint {
+				m.nodeData.Channels[i].Info.Active = event.Kind == lnd.ChannelActive
+			}
+		}
+
+	case lnd.ChannelOpened:
+		if event.Channel != nil && m.findChannel(event.ChannelPoint) < 0 {
+			m.nodeData.Channels = append(m.nodeData.Channels, *event.Channel)
+		}
+
+	case lnd.ChannelClosed:
+		if i := m.findChannel(event.ChannelPoint); i >= 0 {
+			m.nodeData.Channels = append(m.nodeData.Channels[:i:i], m.nodeData.Channels[i+1:]...)
+		}
+
+	default:
+		channelsChanged = false
+	}
+
+	if !channelsChanged || !m.loaded {
+		return nil
+	}
+
+	return setItemsKeepSelection(&m.lists[channels], m.nodeData.GetChannelsAsListItems(m.offlineOnly))
+}
+
+// Mark the parts for reloading and schedule a reload unless one is due
+func (m *DashboardModel) scheduleReload(parts dataPart) tea.Cmd {
+	if parts == 0 {
+		return nil
+	}
+
+	m.staleParts |= parts
+	if m.reloadScheduled {
+		return nil
+	}
+
+	m.reloadScheduled = true
+	return tea.Tick(reloadDelay, func(time.Time) tea.Msg {
+		return reloadDue{}
+	})
+}
+
+// Reload the parts marked stale by events
+func (m *DashboardModel) reloadStale() tea.Cmd {
+	parts := m.staleParts
+	m.staleParts, m.reloadScheduled = 0, false
+	if parts == 0 {
+		return nil
+	}
+
+	return loadParts(m.backend, m.ctx, parts)
+}
+
+func (m *DashboardModel) findChannel(channelPoint string) int {
+	for i, c := range m.nodeData.Channels {
+		if c.Info.ChannelPoint == channelPoint {
+			return i
+		}
+	}
+
+	return -1
+}
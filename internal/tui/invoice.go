@@ -14,7 +14,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/lightninglabs/lndclient"
+	"github.com/charmbracelet/log"
 	invpkg "github.com/lightningnetwork/lnd/invoices"
 	"github.com/skip2/go-qrcode"
 )
@@ -42,19 +42,13 @@ const (
 // Styling
 const maxWidth = 80
 
-var (
-	red    = lipgloss.AdaptiveColor{Light: "#FE5F86", Dark: "#FE5F86"}
-	indigo = lipgloss.AdaptiveColor{Light: "#5A56E0", Dark: "#7571F9"}
-	green  = lipgloss.AdaptiveColor{Light: "#02BA84", Dark: "#02BF87"}
-)
-
 // InvoiceModel Model struct
 type InvoiceModel struct {
 	lg           *lipgloss.Renderer
 	styles       *Styles
 	form         *huh.Form
 	width        int
-	lndService   *lndclient.GrpcLndServices
+	backend      lnd.NodeBackend
 	ctx          context.Context
 	invoiceState InvoiceState
 	base         *BaseModel
@@ -62,9 +56,9 @@ type InvoiceModel struct {
 
 // Variables for form value reference
 var (
-	amount     string = "100"
+	amount     string
 	memo       string
-	expiration string = "3600"
+	expiration string
 )
 
 // Invoice value
@@ -87,10 +81,15 @@ func isFormReady(v bool) error {
 }
 
 // Invoice generation form
-func newInvoiceModel(context context.Context, base *BaseModel, service *lndclient.GrpcLndServices, state InvoiceState) *InvoiceModel {
-	m := InvoiceModel{width: maxWidth, base: base, lndService: service, ctx: context, invoiceState: state}
+func newInvoiceModel(context context.Context, base *BaseModel, backend lnd.NodeBackend, state InvoiceState) *InvoiceModel {
+	m := InvoiceModel{width: maxWidth, base: base, backend: backend, ctx: context, invoiceState: state}
 	m.lg = lipgloss.DefaultRenderer()
 	m.styles = NewStyles(m.lg)
+
+	// Start from the configured defaults
+	amount = strconv.FormatUint(base.settings.InvoiceAmount, 10)
+	expiration = strconv.FormatInt(int64(base.settings.InvoiceExpiry.Seconds()), 10)
+
 	m.form = huh.NewForm(
 		huh.NewGroup(
 			huh.NewInput().
@@ -179,7 +178,8 @@ func (m InvoiceModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Form is ready, generate invoice
 		generatedInvoice, err := m.generateInvoice()
 		if err != nil {
-			generatedInvoice = err.Error()
+			log.Error("Unable to create invoice", "err", err)
+			generatedInvoice = ErrorMessage(err)
 		}
 
 		invoiceVal = generatedInvoice
@@ -204,34 +204,26 @@ func (m InvoiceModel) subscribeToInvoices() tea.Msg {
 
 	defer cancel()
 
-	// Subscribe to invoices
-	invoiceUpdates, streamErr, err := m.lndService.Client.SubscribeInvoices(ctx, lndclient.InvoiceSubscriptionRequest{})
+	// Supervised backends keep the stream going across reconnects
+	invoiceUpdates, streamErr, err := m.backend.SubscribeInvoices(ctx)
 	if err != nil {
+		log.Error("Unable to subscribe to invoices", "err", err)
 		return err
 	}
 
-	// Handle invoice updates and errors
 	for {
 		select {
 		case invoice := <-invoiceUpdates:
-			// Invoice update received
-			if invoice.PaymentRequest == invoiceVal {
-				// Invoice match
-				if invoice.State == invpkg.ContractSettled {
-					// Invoice settled
-					fmt.Println("Invoice settled")
-					return paymentSettled{}
-				}
+			if invoice.PaymentRequest == invoiceVal && invoice.State == invpkg.ContractSettled {
+				log.Info("Invoice settled", "hash", invoice.Hash)
+				return paymentSettled{}
 			}
 
 		case err := <-streamErr:
-			// TODO: Format this nicely
-			fmt.Println("Received stream error:", err)
-			return (errors.New("stream error"))
-			// Handle the received stream error
+			log.Error("Invoice subscription failed", "err", err)
+			return err
 
 		case <-ctx.Done():
-			fmt.Println("Invoice expired")
 			return paymentExpired{}
 		}
 	}
@@ -251,7 +243,7 @@ func (m InvoiceModel) generateInvoice() (string, error) {
 		return "", errors.New("invalid expiration")
 	}
 
-	_, invoice, err := lnd.GeneratePaymentInvoice(m.lndService, m.ctx, memo, parsedAmount, parsedExpiration)
+	_, invoice, err := m.backend.CreateInvoice(m.ctx, memo, parsedAmount, parsedExpiration)
 	return invoice, err
 }
 
@@ -338,7 +330,7 @@ func (m InvoiceModel) appBoundaryView(text string) string {
 		lipgloss.Left,
 		m.styles.HeaderText.Render(text),
 		lipgloss.WithWhitespaceChars("/"),
-		lipgloss.WithWhitespaceForeground(indigo),
+		lipgloss.WithWhitespaceForeground(currentTheme.accent),
 	)
 }
 
@@ -348,6 +340,6 @@ func (m InvoiceModel) appErrorBoundaryView(text string) string {
 		lipgloss.Left,
 		m.styles.ErrorHeaderText.Render(text),
 		lipgloss.WithWhitespaceChars("/"),
-		lipgloss.WithWhitespaceForeground(red),
+		lipgloss.WithWhitespaceForeground(currentTheme.negative),
 	)
 }
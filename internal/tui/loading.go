@@ -4,23 +4,41 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/ardevd/flash/internal/config"
 	"github.com/ardevd/flash/internal/lnd"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/lightninglabs/lndclient"
 )
 
 type errMsg error
 type LoadingModel struct {
-	lndService *lndclient.GrpcLndServices
-	ctx        context.Context
-	spinner    spinner.Model
-	quitting   bool
-	err        error
+	backend  lnd.NodeBackend
+	label    string
+	stage    string
+	progress []LoadingProgress
+	settings config.Settings
+	ctx      context.Context
+	spinner  spinner.Model
+	quitting bool
+	err      error
+
+	// Parts of the node data being loaded, those that completed and the
+	// errors of those that failed
+	parts    dataPart
+	loaded   dataPart
+	partErrs DataErrors
+	// Node data of which some parts failed, waiting for the user to retry
+	// them or skip them
+	nodeData lnd.NodeData
+	partial  bool
 }
 
-func InitLoading(service *lndclient.GrpcLndServices) LoadingModel {
-	return LoadingModel{spinner: getSpinner(), lndService: service, ctx: context.Background()}
+// InitLoading returns the model shown while node data is loaded. The label
+// is the optional display label of the node profile. The service is nil when
+// the connection is still being made, it is then passed with Connected.
+func InitLoading(backend lnd.NodeBackend, label string, settings config.Settings) LoadingModel {
+	return LoadingModel{spinner: getSpinner(), backend: backend, label: label, stage: "Loading node data",
+		settings: settings, ctx: context.Background()}
 }
 
 func (m LoadingModel) Init() tea.Cmd {
@@ -41,6 +59,18 @@ func (m LoadingModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "q", "esc", "ctrl+c":
 			m.quitting = true
 			return m, tea.Quit
+		case "r":
+			if !m.partial {
+				return m, nil
+			}
+			return m.retry()
+		case "s":
+			if !m.partial {
+				return m, nil
+			}
+			dashboard := InitDashboard(m.backend, m.nodeData, m.label, m.settings)
+			dashboard.partErrs = m.partErrs
+			return dashboard.Update(windowSizeMsg)
 		default:
 			return m, nil
 		}
@@ -49,8 +79,55 @@ func (m LoadingModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.err = msg
 		return m, nil
 
+	case DataLoadFailed:
+		m.err = msg.Err
+		return m, nil
+
+	case LoadingStage:
+		m.stage = string(msg)
+		m.progress = nil
+		return m, nil
+
+	case LoadingProgress:
+		m.progress = updateProgress(m.progress, msg)
+		return m, nil
+
+	case Connected:
+		m.backend = msg.Backend
+		return m, nil
+
+	case partsLoading:
+		m.parts, m.loaded, m.partErrs = msg.parts, 0, nil
+		return m, nil
+
+	case partLoaded:
+		m.setPartLoaded(msg.part, msg.err)
+		return m, nil
+
+	case PartialDataLoaded:
+		m.nodeData, m.partErrs, m.partial = msg.NodeData, msg.Errors, true
+		return m, nil
+
+	case partsReloaded:
+		copyParts(&m.nodeData, msg.nodeData, msg.parts&^msg.errs.parts())
+		for part := partPayments; part&allParts != 0; part <<= 1 {
+			if msg.parts&part != 0 {
+				m.setPartLoaded(part, msg.errs[part])
+			}
+		}
+		if len(m.partErrs) == 0 {
+			return InitDashboard(m.backend, m.nodeData, m.label, m.settings).Update(windowSizeMsg)
+		}
+		m.partial = true
+		return m, nil
+
 	case DataLoaded:
-		dashboard := InitDashboard(m.lndService, lnd.NodeData(msg))
+		dashboard := InitDashboard(m.backend, lnd.NodeData(msg), m.label, m.settings)
+		return dashboard.Update(windowSizeMsg)
+
+	case CachedDataLoaded:
+		dashboard := InitDashboard(m.backend, msg.NodeData, m.label, m.settings)
+		dashboard.cachedAt, dashboard.live = msg.Saved, false
 		return dashboard.Update(windowSizeMsg)
 
 	default:
@@ -60,14 +137,88 @@ func (m LoadingModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 }
 
+// Load the parts that failed again
+func (m LoadingModel) retry() (tea.Model, tea.Cmd) {
+	failed := m.partErrs.parts()
+	m.loaded &^= failed
+	m.partErrs = nil
+	m.partial = false
+	m.stage, m.progress = "Retrying", nil
+	return m, tea.Batch(m.spinner.Tick, loadParts(m.backend, m.ctx, failed))
+}
+
+func (m *LoadingModel) setPartLoaded(part dataPart, err error) {
+	m.loaded |= part
+	if err == nil {
+		delete(m.partErrs, part)
+		return
+	}
+
+	if m.partErrs == nil {
+		m.partErrs = make(DataErrors)
+	}
+	m.partErrs[part] = err
+}
+
 func (m LoadingModel) View() string {
 	if m.err != nil {
-		return m.err.Error()
+		title := "Unable to load node data"
+		if m.backend == nil {
+			title = "Unable to connect to the node"
+		}
+		return fmt.Sprintf("\n\n   %s\n\n   %s\n\n   press q to quit\n\n", title, ErrorMessage(m.err))
+	}
+
+	str := fmt.Sprintf("\n\n   %s %s...press q to quit\n\n", m.spinner.View(), m.stage)
+	if m.partial {
+		str = "\n\n   Some of the node data failed to load\n\n"
 	}
 
-	str := fmt.Sprintf("\n\n   %s Loading node data...press q to quit\n\n", m.spinner.View())
+	str += m.partsView()
+	for _, progress := range m.progress {
+		str += fmt.Sprintf("   %s %d/%d\n", progress.Stage, progress.Done, progress.Total)
+	}
+	if m.partial {
+		str += "\n   r retry · s continue without it · q quit\n"
+	}
 	if m.quitting {
 		return str + "\n"
 	}
 	return str
 }
+
+// Get the status of each part of the node data
+func (m LoadingModel) partsView() string {
+	var str string
+	for part := partPayments; part&allParts != 0; part <<= 1 {
+		if m.parts&part == 0 {
+			continue
+		}
+
+		switch err, failed := m.partErrs[part]; {
+		case failed:
+			str += fmt.Sprintf("   ✗ %s: %s\n", part, ErrorMessage(err))
+		case m.loaded&part != 0:
+			str += fmt.Sprintf("   ✓ %s\n", part)
+		default:
+			str += fmt.Sprintf("   %s %s\n", m.spinner.View(), part)
+		}
+	}
+
+	if str == "" {
+		return ""
+	}
+	return str + "\n"
+}
+
+// Replace the progress of the stage or add it
+func updateProgress(progress []LoadingProgress, update LoadingProgress) []LoadingProgress {
+	for i := range progress {
+		if progress[i].Stage == update.Stage {
+			progress[i] = update
+			return progress
+		}
+	}
+
+	return append(progress, update)
+}
@@ -0,0 +1,218 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ardevd/flash/internal/logging"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// Entries shown by the log console, nil if logging isn't set up
+var logRing *logging.Ring
+
+// ShowLogs makes the log console show the entries of the ring
+func ShowLogs(ring *logging.Ring) {
+	logRing = ring
+}
+
+// Levels the log console filters by, from the lowest
+var logLevels = []log.Level{log.DebugLevel, log.InfoLevel, log.WarnLevel, log.ErrorLevel}
+
+// How often the open log console checks for new entries
+const logPollInterval = 500 * time.Millisecond
+
+// logTick makes the console it was scheduled by check for new entries. Ticks
+// of closed consoles are dropped.
+type logTick struct {
+	console *LogConsoleModel
+}
+
+// Model for the log console, which shows the entries of the log ring with
+// the minimum level and containing the search text
+type LogConsoleModel struct {
+	styles   *Styles
+	base     *BaseModel
+	viewport viewport.Model
+	search   textinput.Model
+	// Index of the minimum level in logLevels
+	level int
+	// Entries of the ring when the view was last updated
+	written uint64
+	shown   int
+	total   int
+}
+
+func newLogConsoleModel(base *BaseModel) *LogConsoleModel {
+	search := textinput.New()
+	search.Prompt = "/"
+	search.Placeholder = "search"
+
+	m := LogConsoleModel{base: base, search: search, level: 1, viewport: viewport.New(0, 0)}
+	m.styles = GetDefaultStyles()
+	m.base.pushView(&m)
+	return &m
+}
+
+// Show the log console, or close it if it is shown
+func (b *BaseModel) toggleLogs() (tea.Model, tea.Cmd) {
+	if _, ok := b.NavStack[len(b.NavStack)-1].(*LogConsoleModel); ok {
+		return b.popView().Update(windowSizeMsg)
+	}
+
+	return newLogConsoleModel(b).Update(windowSizeMsg)
+}
+
+func (m *LogConsoleModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// Keys go to the search field while it is focused
+	if msg, ok := msg.(tea.KeyMsg); ok && m.search.Focused() {
+		return m, m.updateSearch(msg)
+	}
+
+	model, cmd := m.base.Update(msg)
+	if model != nil {
+		return model, cmd
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, v := m.styles.BorderedStyle.GetFrameSize()
+		m.viewport.Width = msg.Width - h
+		// Leave room for the header and the help
+		m.viewport.Height = max(msg.Height-v-4, 1)
+		m.refresh(true)
+		return m, m.poll()
+
+	case logTick:
+		if msg.console != m {
+			return m, nil
+		}
+		if logRing != nil && logRing.Written() != m.written {
+			m.refresh(false)
+		}
+		return m, m.poll()
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, Keymap.Left):
+			m.level = max(m.level-1, 0)
+			m.refresh(true)
+			return m, nil
+		case key.Matches(msg, Keymap.Right):
+			m.level = min(m.level+1, len(logLevels)-1)
+			m.refresh(true)
+			return m, nil
+		case msg.String() == "/":
+			return m, m.search.Focus()
+		}
+	}
+
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+// Enter and leave the search field, enter keeps the search and esc clears it
+func (m *LogConsoleModel) updateSearch(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.search.Blur()
+		return nil
+	case tea.KeyEsc:
+		m.search.Blur()
+		m.search.SetValue("")
+		m.refresh(true)
+		return nil
+	}
+
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	m.refresh(true)
+	return cmd
+}
+
+func (m *LogConsoleModel) poll() tea.Cmd {
+	return tea.Tick(logPollInterval, func(time.Time) tea.Msg {
+		return logTick{console: m}
+	})
+}
+
+// Show the entries of the ring that pass the filter. New entries keep the
+// console at the bottom unless it was scrolled up, changing the filter jumps
+// to the bottom.
+func (m *LogConsoleModel) refresh(filterChanged bool) {
+	if logRing == nil {
+		m.viewport.SetContent(m.styles.Help.Render("Logging is not set up"))
+		return
+	}
+
+	follow := filterChanged || m.viewport.AtBottom()
+	m.written = logRing.Written()
+	entries := logRing.Entries()
+	search := strings.ToLower(m.search.Value())
+
+	var lines []string
+	for _, entry := range entries {
+		if entry.Level < logLevels[m.level] {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(entry.Message+" "+entry.Fields), search) {
+			continue
+		}
+		lines = append(lines, m.formatEntry(entry))
+	}
+	m.shown, m.total = len(lines), len(entries)
+
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+	if follow {
+		m.viewport.GotoBottom()
+	}
+}
+
+func (m *LogConsoleModel) formatEntry(entry logging.Entry) string {
+	s := m.styles
+	level := fmt.Sprintf("%-5s", strings.ToUpper(entry.Level.String()))
+	switch {
+	case entry.Level >= log.ErrorLevel:
+		level = s.NegativeString(level)
+	case entry.Level >= log.WarnLevel:
+		level = s.Keyword(level)
+	case entry.Level >= log.InfoLevel:
+		level = s.PositiveString(level)
+	default:
+		level = s.Help.Render(level)
+	}
+
+	line := s.Help.Render(entry.Time.Format(time.TimeOnly)) + " " + level + " " + entry.Message
+	if entry.Fields != "" {
+		line += " " + s.SubKeyword(entry.Fields)
+	}
+	return line
+}
+
+func (m LogConsoleModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m LogConsoleModel) View() string {
+	s := m.styles
+	header := s.Keyword("Log console") + s.Help.Render(fmt.Sprintf(" · %s and above · %d of %d entries",
+		logLevels[m.level], m.shown, m.total))
+
+	footer := m.search.View()
+	if !m.search.Focused() {
+		help := "←/→ level · / search · " + Keymap.Logs.Help().Key + " close"
+		if m.search.Value() != "" {
+			footer = s.Help.Render("search: " + m.search.Value() + " · " + help)
+		} else {
+			footer = s.Help.Render(help)
+		}
+	}
+
+	return s.BorderedStyle.Render(lipgloss.JoinVertical(lipgloss.Left, header, "", m.viewport.View(), "", footer))
+}
@@ -14,14 +14,14 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/lightninglabs/lndclient"
+	"github.com/charmbracelet/log"
 	"github.com/lightningnetwork/lnd/routing/route"
 )
 
 // Model
 type PayInvoiceModel struct {
 	styles       *Styles
-	lndService   *lndclient.GrpcLndServices
+	backend      lnd.NodeBackend
 	ctx          context.Context
 	base         *BaseModel
 	keys         keyMap
@@ -52,12 +52,13 @@ const (
 
 // Value container
 var invoiceString string
-var maxFee string = "10"
+var maxFee string
 
 // Instantiate model
-func newPayInvoiceModel(service *lndclient.GrpcLndServices, base *BaseModel) *PayInvoiceModel {
-	m := PayInvoiceModel{lndService: service, base: base, ctx: context.Background(), keys: Keymap}
+func newPayInvoiceModel(backend lnd.NodeBackend, base *BaseModel) *PayInvoiceModel {
+	m := PayInvoiceModel{backend: backend, base: base, ctx: context.Background(), keys: Keymap}
 	m.styles = GetDefaultStyles()
+	maxFee = strconv.FormatInt(base.settings.MaxFee, 10)
 	m.base.pushView(&m)
 	m.form = getInvoicePaymentForm()
 	m.invoiceState = PaymentStateNone
@@ -111,7 +112,7 @@ func (m *PayInvoiceModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	if m.form.State == huh.StateCompleted && m.invoiceState == PaymentStateNone {
 		// Form is ready, decode the invoice
-		_, err := m.lndService.Client.DecodePaymentRequest(m.ctx, invoiceString)
+		_, err := m.backend.DecodeInvoice(m.ctx, invoiceString)
 		if err == nil {
 			m.invoiceState = PaymentStateDecoded
 		} else {
@@ -189,26 +190,21 @@ func (m PayInvoiceModel) getPaymentSettledView() string {
 
 // Get node name for a given public key. Returns empty string if we can't find a match
 func (m PayInvoiceModel) getNodeName(pubkey route.Vertex) string {
-	nodeInfo, err := m.lndService.Client.GetNodeInfo(m.ctx, pubkey, false)
-	if err != nil {
-		return ""
-	}
-
-	return nodeInfo.Alias
+	return m.backend.GetNodeAlias(m.ctx, pubkey)
 }
 
 // Decode an invoice string
 func (m PayInvoiceModel) getDecodeInvoiceView() string {
 	// Decode the invoice string
 	invoiceString = lnd.SantizeBoltInvoice(invoiceString)
-	decodedInvoice, err := m.lndService.Client.DecodePaymentRequest(m.ctx, invoiceString)
+	decodedInvoice, err := m.backend.DecodeInvoice(m.ctx, invoiceString)
 	if err != nil {
 		return "Error decoding invoice: " + err.Error()
 	}
 	amountInSats := decodedInvoice.Value.ToSatoshis()
 
 	s := m.styles
-	return s.Keyword("Amount: ") + amountInSats.String() + "\n" +
+	return s.Keyword("Amount: ") + formatAmount(amountInSats, m.base.settings) + "\n" +
 		s.Keyword("To: ") + decodedInvoice.Destination.String() + "\n" +
 		s.Keyword("Node: ") + m.getNodeName(decodedInvoice.Destination) + "\n" +
 		s.Keyword("Description: ") + decodedInvoice.Description + "\n\n" +
@@ -221,21 +217,12 @@ func (m *PayInvoiceModel) payInvoice() tea.Msg {
 	if err != nil {
 		return paymentError{}
 	}
-	result := m.lndService.Client.PayInvoice(m.ctx, invoiceString, btcutil.Amount(fee), nil)
-	defer close(result)
-	completion := make(chan tea.Msg)
-
-	defer close(completion)
-	go func() {
-		for update := range result {
-			if update.Err != nil {
-				fmt.Println(update.Err.Error())
-				completion <- paymentError{}
-			} else {
-				completion <- paymentSettled{}
-			}
-		}
-	}()
+	_, err = m.backend.PayInvoice(m.ctx, invoiceString, btcutil.Amount(fee))
+	if err != nil {
+		log.Error("Payment failed", "err", err)
+		return paymentError{}
+	}
 
-	return <-completion
+	log.Info("Invoice paid", "max_fee", fee)
+	return paymentSettled{}
 }
@@ -0,0 +1,35 @@
+package tui
+
+import (
+	"github.com/ardevd/flash/internal/credentials"
+	"github.com/charmbracelet/huh"
+)
+
+// SelectProfile lets the user pick which of the stored node profiles to connect to
+func SelectProfile(profiles []credentials.Profile) (*credentials.Profile, error) {
+	var options []huh.Option[int]
+	for i, profile := range profiles {
+		label := profile.Name
+		if profile.Label != "" {
+			label += " - " + profile.Label
+		}
+		if profile.RPCHost != "" {
+			label += " (" + profile.RPCHost + ")"
+		}
+		options = append(options, huh.NewOption(label, i))
+	}
+
+	var selected int
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[int]().
+				Title("Select node").
+				Options(options...).
+				Value(&selected)))
+
+	if err := form.Run(); err != nil {
+		return nil, err
+	}
+
+	return &profiles[selected], nil
+}
@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// refreshTick starts a refresh of the dashboard. The dashboard restarts its
+// refresh loop whenever it is shown again, ticks of older loops carry an
+// outdated generation and are dropped.
+type refreshTick struct {
+	generation int
+}
+
+// dataRefreshed carries the node data loaded in the background for the
+// refresh loop of the generation
+type dataRefreshed struct {
+	generation int
+	nodeData   lnd.NodeData
+	errs       DataErrors
+}
+
+// Wait for the refresh interval, then tick
+func scheduleRefresh(interval time.Duration, generation int) tea.Cmd {
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return refreshTick{generation: generation}
+	})
+}
+
+// Load the node data without blocking the UI
+func loadData(backend lnd.NodeBackend, ctx context.Context, generation int) tea.Cmd {
+	return func() tea.Msg {
+		nodeData, errs := getDataParts(backend, ctx, allParts, nil)
+		return dataRefreshed{generation: generation, nodeData: nodeData, errs: errs}
+	}
+}
+
+// Replace the items of the list and keep the selected item selected if it
+// is still there, otherwise the item at the same position
+func setItemsKeepSelection(l *list.Model, items []list.Item) tea.Cmd {
+	selected := l.SelectedItem()
+	index := l.Index()
+
+	cmd := l.SetItems(items)
+	// The filter runs again on the new items and keeps the cursor
+	if l.FilterState() != list.Unfiltered {
+		return cmd
+	}
+
+	if selected != nil {
+		key := itemKey(selected)
+		for i, item := range items {
+			if itemKey(item) == key {
+				index = i
+				break
+			}
+		}
+	}
+
+	if index >= len(items) {
+		index = len(items) - 1
+	}
+	if index >= 0 {
+		l.Select(index)
+	}
+
+	return cmd
+}
+
+// Identify list items across refreshes
+func itemKey(item list.Item) string {
+	switch item := item.(type) {
+	case lnd.Channel:
+		return item.Info.ChannelPoint
+	case lnd.Payment:
+		return item.Payment.Hash.String()
+	case lnd.PendingChannel:
+		return item.Alias + "/" + strconv.Itoa(int(item.Type))
+	}
+
+	return item.FilterValue()
+}
@@ -0,0 +1,30 @@
+package tui
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/ardevd/flash/internal/config"
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+// Configure applies the theme and key bindings of the settings. It has to be
+// called before any model is created.
+func Configure(settings config.Settings) error {
+	t, ok := themes[settings.Theme]
+	if !ok {
+		return errors.New("unknown theme " + settings.Theme)
+	}
+	currentTheme = t
+
+	return Keymap.rebind(settings.Keys)
+}
+
+// Format the amount in the unit of the settings
+func formatAmount(amount btcutil.Amount, settings config.Settings) string {
+	if settings.Units == config.UnitSats {
+		return strconv.FormatInt(int64(amount), 10) + " sats"
+	}
+
+	return amount.String()
+}
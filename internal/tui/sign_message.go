@@ -5,29 +5,29 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/ardevd/flash/internal/lnd"
 	"github.com/ardevd/flash/internal/util"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/lightninglabs/lndclient"
 )
 
 // Model for the message signing view model
 type SignMessageModel struct {
-	styles     *Styles
-	lndService *lndclient.GrpcLndServices
-	ctx        context.Context
-	base       *BaseModel
-	keys       keyMap
-	form       *huh.Form
+	styles  *Styles
+	backend lnd.NodeBackend
+	ctx     context.Context
+	base    *BaseModel
+	keys    keyMap
+	form    *huh.Form
 }
 
 // Value container
 var messageToSign string
 
 // Instantiate a new model
-func newSignMessageModel(service *lndclient.GrpcLndServices, base *BaseModel) *SignMessageModel {
-	m := SignMessageModel{lndService: service, base: base, ctx: context.Background(), keys: Keymap}
+func newSignMessageModel(backend lnd.NodeBackend, base *BaseModel) *SignMessageModel {
+	m := SignMessageModel{backend: backend, base: base, ctx: context.Background(), keys: Keymap}
 	m.styles = GetDefaultStyles()
 	m.base.pushView(&m)
 	m.form = getMessageSigningForm()
@@ -61,7 +61,7 @@ func (m *SignMessageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m SignMessageModel) signMessage() string {
 
 	// Call the SignMessage function
-	signature, err := m.lndService.Client.SignMessage(m.ctx, []byte(messageToSign))
+	signature, err := m.backend.SignMessage(m.ctx, messageToSign)
 
 	if err != nil {
 		return fmt.Sprintf("Error: %s", err.Error())
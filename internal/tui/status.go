@@ -0,0 +1,39 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/ardevd/flash/internal/lnd"
+)
+
+// ConnectionChanged is sent when the supervisor reports a new connection
+// status. Views read the status from the supervisor, so the message only
+// needs to trigger a redraw.
+type ConnectionChanged lnd.ConnectionStatus
+
+// Render the connection state and the time of the last successful sync.
+// Only supervised backends have a status.
+func (s *Styles) connectionStatus(backend lnd.NodeBackend) string {
+	reporter, ok := backend.(lnd.StatusReporter)
+	if !ok {
+		return ""
+	}
+	status := reporter.Status()
+
+	var state string
+	switch status.State {
+	case lnd.Connected:
+		state = s.PositiveString("● connected")
+	case lnd.Reconnecting:
+		state = s.SubKeyword("◌ reconnecting")
+	default:
+		state = s.NegativeString("○ offline")
+	}
+
+	sync := "last sync " + status.LastSync.Format(time.TimeOnly)
+	if status.Err != nil {
+		sync += " · " + ErrorMessage(status.Err)
+	}
+
+	return state + " " + s.Help.Render(sync)
+}
@@ -8,6 +8,49 @@ import (
 
 var term = termenv.EnvColorProfile()
 
+// theme is the set of colors the styles are built from
+type theme struct {
+	accent, positive, negative lipgloss.TerminalColor
+
+	highlight, muted, border, focusedBorder, spinner,
+	keyword, subKeyword, negativeString, positiveString string
+}
+
+// Themes selectable in the config file
+var themes = map[string]theme{
+	"default": {
+		accent:         lipgloss.AdaptiveColor{Light: "#5A56E0", Dark: "#7571F9"},
+		positive:       lipgloss.AdaptiveColor{Light: "#02BA84", Dark: "#02BF87"},
+		negative:       lipgloss.AdaptiveColor{Light: "#FE5F86", Dark: "#FE5F86"},
+		highlight:      "212",
+		muted:          "240",
+		border:         "62",
+		focusedBorder:  "169",
+		spinner:        "205",
+		keyword:        "211",
+		subKeyword:     "140",
+		negativeString: "125",
+		positiveString: "78",
+	},
+	"mono": {
+		accent:         lipgloss.Color("255"),
+		positive:       lipgloss.Color("252"),
+		negative:       lipgloss.Color("245"),
+		highlight:      "255",
+		muted:          "240",
+		border:         "240",
+		focusedBorder:  "255",
+		spinner:        "250",
+		keyword:        "255",
+		subKeyword:     "250",
+		negativeString: "245",
+		positiveString: "252",
+	},
+}
+
+// Theme used for all styles
+var currentTheme = themes["default"]
+
 // Styles contain style elements for views
 type Styles struct {
 	Base,
@@ -18,6 +61,8 @@ type Styles struct {
 	ErrorHeaderText,
 	BorderedStyle,
 	FocusedStyle,
+	MainnetBadge,
+	TestNetworkBadge,
 	Help lipgloss.Style
 	Keyword,
 	SubKeyword,
@@ -31,40 +76,50 @@ func GetDefaultStyles() *Styles {
 
 func NewStyles(lg *lipgloss.Renderer) *Styles {
 	s := Styles{}
+	t := currentTheme
 	s.Base = lg.NewStyle().
 		Padding(1, 4, 0, 1)
 	s.HeaderText = lg.NewStyle().
-		Foreground(indigo).
+		Foreground(t.accent).
 		Bold(true).
 		Padding(0, 1, 0, 2)
 	s.Status = lg.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(indigo).
+		BorderForeground(t.accent).
 		PaddingLeft(1).
 		MarginTop(1)
 	s.StatusHeader = lg.NewStyle().
-		Foreground(green).
+		Foreground(t.positive).
 		Bold(true)
 	s.Highlight = lg.NewStyle().
-		Foreground(lipgloss.Color("212"))
+		Foreground(lipgloss.Color(t.highlight))
 	s.ErrorHeaderText = s.HeaderText.Copy().
-		Foreground(red)
+		Foreground(t.negative)
 	s.Help = lg.NewStyle().
-		Foreground(lipgloss.Color("240"))
+		Foreground(lipgloss.Color(t.muted))
 
-	s.Keyword = makeFgStyle("211")
-	s.SubKeyword = makeFgStyle("140")
-	s.NegativeString = makeFgStyle("125")
-	s.PositiveString = makeFgStyle("78")
+	s.Keyword = makeFgStyle(t.keyword)
+	s.SubKeyword = makeFgStyle(t.subKeyword)
+	s.NegativeString = makeFgStyle(t.negativeString)
+	s.PositiveString = makeFgStyle(t.positiveString)
 	s.BorderedStyle = lipgloss.NewStyle().
 		Padding(1, 2).
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("62"))
+		BorderForeground(lipgloss.Color(t.border))
 
 	s.FocusedStyle = lipgloss.NewStyle().
 		Padding(1, 2).
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("169"))
+		BorderForeground(lipgloss.Color(t.focusedBorder))
+
+	s.MainnetBadge = lg.NewStyle().
+		Bold(true).
+		Padding(0, 1).
+		Foreground(lipgloss.Color("230")).
+		Background(lipgloss.Color("208"))
+	s.TestNetworkBadge = s.MainnetBadge.Copy().
+		Foreground(lipgloss.Color("16")).
+		Background(lipgloss.Color("45"))
 
 	return &s
 }
@@ -79,6 +134,6 @@ func makeFgStyle(color string) func(string) string {
 func getSpinner() spinner.Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color(currentTheme.spinner))
 	return s
 }
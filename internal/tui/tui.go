@@ -2,12 +2,12 @@ package tui
 
 import (
 	"context"
-	"log"
+	"sync"
+	"time"
 
+	"github.com/ardevd/flash/internal/config"
 	"github.com/ardevd/flash/internal/lnd"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/lightninglabs/lndclient"
-	"github.com/lightningnetwork/lnd/routing/route"
 )
 
 var windowSizeMsg tea.WindowSizeMsg
@@ -18,6 +18,37 @@ var Models []tea.Model
 // Message types
 type DataLoaded lnd.NodeData
 
+// CachedDataLoaded passes node data cached on an earlier run, which is shown
+// until DataLoaded replaces it
+type CachedDataLoaded struct {
+	NodeData lnd.NodeData
+	// Time the oldest part of the data was loaded from the node
+	Saved time.Time
+}
+
+// PartialDataLoaded passes node data of which some parts failed to load. The
+// loading screen offers to retry them or to show the dashboard without them.
+type PartialDataLoaded struct {
+	NodeData lnd.NodeData
+	Errors   DataErrors
+}
+
+// DataLoadFailed reports an error connecting to the node or loading its data
+type DataLoadFailed struct {
+	Err error
+}
+
+// Connected passes the backend once the connection to the node is made
+type Connected struct {
+	Backend lnd.NodeBackend
+}
+
+// LoadingStage describes what the loading screen is waiting for
+type LoadingStage string
+
+// LoadingProgress reports how far a stage of loading the node data got
+type LoadingProgress lnd.Progress
+
 // Payments
 type paymentSettled struct{}
 type paymentExpired struct{}
@@ -31,116 +62,173 @@ func updateChannelPolicyMsg() tea.Msg {
 	return updateChannelPolicy{}
 }
 
-func GetData(service *lndclient.GrpcLndServices, ctx context.Context) lnd.NodeData {
-	var nodeData lnd.NodeData
+// Number of payments shown on the dashboard
+const maxPayments = 10
+
+// Parts of the node data that can be loaded on their own
+type dataPart int
 
-	request := lndclient.ListPaymentsRequest{MaxPayments: 10, Reversed: true, IncludeIncomplete: false}
-	payments, err := service.Client.ListPayments(ctx, request)
-	if err != nil {
-		log.Fatal(err)
+const (
+	partPayments dataPart = 1 << iota
+	partChannels
+	partPendingChannels
+	partNodeInfo
+
+	allParts = partPayments | partChannels | partPendingChannels | partNodeInfo
+)
+
+// Names of the parts shown on the loading screen
+func (p dataPart) String() string {
+	switch p {
+	case partPayments:
+		return "Payments"
+	case partChannels:
+		return "Channels"
+	case partPendingChannels:
+		return "Pending channels"
+	case partNodeInfo:
+		return "Node info and balances"
 	}
 
-	// Load Payments
-	var paymentsSlice []lnd.Payment
-	for _, payment := range payments.Payments {
-		paymentsSlice = append(paymentsSlice, lnd.Payment{Payment: payment})
+	return "Node data"
+}
+
+// DataErrors holds the errors of the parts of the node data that failed to
+// load. It is nil if all parts loaded.
+type DataErrors map[dataPart]error
+
+// Parts that failed to load
+func (e DataErrors) parts() dataPart {
+	var parts dataPart
+	for part := range e {
+		parts |= part
 	}
 
-	nodeData.Payments = paymentsSlice
+	return parts
+}
 
-	// Load Channels
-	nodeData.Channels = getChannelListItems(service, ctx)
+// The error of the first part that failed to load, in the order of the parts
+func (e DataErrors) first() error {
+	for part := partPayments; part&allParts != 0; part <<= 1 {
+		if err, ok := e[part]; ok {
+			return err
+		}
+	}
 
-	// Load Pending channels
-	nodeData.PendingChannels = getPendingChannels(service, ctx)
+	return nil
+}
 
-	// Load node data
-	nodeData.NodeInfo = lnd.GetDataFromAPI(service, ctx)
+// partsLoading and partLoaded report the parts of the node data to the
+// loading screen as they load
+type partsLoading struct {
+	parts dataPart
+}
 
-	return nodeData
+type partLoaded struct {
+	part dataPart
+	err  error
 }
 
-// Get list of pending channels
-func getPendingChannels(service *lndclient.GrpcLndServices, ctx context.Context) []lnd.PendingChannel {
-	var pendingChannels []lnd.PendingChannel
-	channels, err := service.Client.PendingChannels(ctx)
-	if err != nil {
-		log.Fatal(err)
-	}
+// GetData loads the node data and returns the first error of its parts
+func GetData(backend lnd.NodeBackend, ctx context.Context) (lnd.NodeData, error) {
+	nodeData, errs := getDataParts(backend, ctx, allParts, nil)
+	return nodeData, errs.first()
+}
 
-	// Force close channels
-	for _, fc := range channels.PendingForceClose {
-		remotePeerAlias := getNodeAliasFromPubKey(service, ctx, fc.PubKeyBytes)
-
-		pendingChannel := lnd.PendingChannel{
-			Capacity:            fc.Capacity,
-			LocalBalance:        fc.LocalBalance,
-			RecoveredBalance:    fc.RecoveredBalance,
-			LimboBalance:        fc.LimboBalance,
-			BlocksUntilMaturity: fc.BlocksUntilMaturity,
-			Type:                lnd.ForceClosure,
-			Alias:               remotePeerAlias,
-		}
-		pendingChannels = append(pendingChannels, pendingChannel)
-	}
+// LoadData loads all parts of the node data and sends the result of each
+// part, as they complete, to the loading screen with send, which is called
+// from several goroutines. The data of the parts that loaded is returned
+// along with the errors of the others.
+func LoadData(backend lnd.NodeBackend, ctx context.Context, send func(tea.Msg)) (lnd.NodeData, DataErrors) {
+	send(partsLoading{parts: allParts})
+	return getDataParts(backend, ctx, allParts, func(part dataPart, err error) {
+		send(partLoaded{part: part, err: err})
+	})
+}
+
+// Load the parts of the node data at the same time, the others are left
+// empty. done is called with the result of each part unless it is nil.
+func getDataParts(backend lnd.NodeBackend, ctx context.Context, parts dataPart,
+	done func(dataPart, error)) (lnd.NodeData, DataErrors) {
 
-	// Cooperative closing channels
-	for _, fc := range channels.WaitingClose {
-		remotePeerAlias := getNodeAliasFromPubKey(service, ctx, fc.PubKeyBytes)
+	var nodeData lnd.NodeData
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs DataErrors
 
-		pendingChannel := lnd.PendingChannel{
-			Capacity:     fc.Capacity,
-			LocalBalance: fc.LocalBalance,
-			Type:         lnd.CooperativeClosure,
-			Alias:        remotePeerAlias,
+	load := func(part dataPart, f func() error) {
+		if parts&part == 0 {
+			return
 		}
-		pendingChannels = append(pendingChannels, pendingChannel)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := f()
+			if err != nil {
+				mu.Lock()
+				if errs == nil {
+					errs = make(DataErrors)
+				}
+				errs[part] = err
+				mu.Unlock()
+			}
+
+			if done != nil {
+				done(part, err)
+			}
+		}()
 	}
 
-	// Pending channel opens
-	for _, fc := range channels.PendingOpen {
-		remotePeerAlias := getNodeAliasFromPubKey(service, ctx, fc.PubKeyBytes)
+	// Load Payments
+	load(partPayments, func() (err error) {
+		nodeData.Payments, err = backend.GetPayments(ctx, maxPayments)
+		return err
+	})
 
-		pendingChannel := lnd.PendingChannel{
-			Capacity:     fc.Capacity,
-			LocalBalance: fc.LocalBalance,
-			Type:         lnd.CooperativeClosure,
-			Alias:        remotePeerAlias,
-		}
-		pendingChannels = append(pendingChannels, pendingChannel)
-	}
+	// Load Channels
+	load(partChannels, func() (err error) {
+		nodeData.Channels, err = backend.GetChannels(ctx)
+		return err
+	})
 
-	return pendingChannels
-}
+	// Load Pending channels
+	load(partPendingChannels, func() (err error) {
+		nodeData.PendingChannels, err = backend.GetPendingChannels(ctx)
+		return err
+	})
 
-func getNodeAliasFromPubKey(service *lndclient.GrpcLndServices, ctx context.Context, pubKey route.Vertex) string {
-	node, err := service.Client.GetNodeInfo(ctx, pubKey, true)
-	if err != nil {
-		// TODO: add logging
-		return ""
-	}
+	// Load node data
+	load(partNodeInfo, func() (err error) {
+		nodeData.NodeInfo, err = backend.GetNode(ctx)
+		return err
+	})
 
-	return node.Alias
+	wg.Wait()
+	return nodeData, errs
 }
 
-func getChannelListItems(service *lndclient.GrpcLndServices, ctx context.Context) []lnd.Channel {
-	var channels []lnd.Channel
-	infos, err := service.Client.ListChannels(ctx, false, false)
-	if err != nil {
-		log.Fatal(err)
+// Copy the parts from the loaded node data
+func copyParts(dst *lnd.NodeData, src lnd.NodeData, parts dataPart) {
+	if parts&partNodeInfo != 0 {
+		dst.NodeInfo = src.NodeInfo
 	}
 
-	for _, chanInfo := range infos {
-		remotePeerAlias := getNodeAliasFromPubKey(service, ctx, chanInfo.PubKeyBytes)
+	if parts&partChannels != 0 {
+		dst.Channels = src.Channels
+	}
 
-		channels = append(channels, lnd.Channel{Info: chanInfo, Alias: remotePeerAlias})
+	if parts&partPayments != 0 {
+		dst.Payments = src.Payments
 	}
 
-	return channels
+	if parts&partPendingChannels != 0 {
+		dst.PendingChannels = src.PendingChannels
+	}
 }
 
-func Init(service *lndclient.GrpcLndServices) []tea.Model {
-	progress := InitLoading(service)
+func Init(backend lnd.NodeBackend) []tea.Model {
+	progress := InitLoading(backend, "", config.Defaults())
 	Models = []tea.Model{progress}
 	return Models
 }
@@ -0,0 +1,370 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ardevd/flash/internal/config"
+	"github.com/ardevd/flash/internal/lnd"
+	"github.com/ardevd/flash/internal/logging"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/lightninglabs/lndclient"
+	"github.com/stretchr/testify/assert"
+)
+
+// Backend serving fixed data, calls not listed here panic
+type fakeBackend struct {
+	lnd.NodeBackend
+	node        lnd.Node
+	channels    []lnd.Channel
+	payments    []lnd.Payment
+	channelsErr error
+}
+
+func (b *fakeBackend) GetNode(ctx context.Context) (lnd.Node, error) {
+	return b.node, nil
+}
+
+func (b *fakeBackend) GetChannels(ctx context.Context) ([]lnd.Channel, error) {
+	return b.channels, b.channelsErr
+}
+
+func (b *fakeBackend) GetPendingChannels(ctx context.Context) ([]lnd.PendingChannel, error) {
+	return nil, nil
+}
+
+func (b *fakeBackend) GetPayments(ctx context.Context, max uint64) ([]lnd.Payment, error) {
+	return b.payments, nil
+}
+
+func TestGetData(t *testing.T) {
+	backend := &fakeBackend{
+		node:     lnd.Node{Alias: "alice"},
+		channels: []lnd.Channel{{Alias: "bob"}, {Alias: "carol"}},
+		payments: []lnd.Payment{{}},
+	}
+
+	nodeData, err := GetData(backend, context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", nodeData.NodeInfo.Alias)
+	assert.Len(t, nodeData.Channels, 2)
+	assert.Len(t, nodeData.Payments, 1)
+
+	backend.channelsErr = &lnd.RPCError{Op: "list channels", Err: errors.New("connection refused")}
+	_, err = GetData(backend, context.Background())
+	assert.Error(t, err)
+}
+
+func TestSetItemsKeepSelection(t *testing.T) {
+	l := list.New([]list.Item{
+		lnd.Channel{Alias: "bob", Info: lndclient.ChannelInfo{ChannelPoint: "a:0"}},
+		lnd.Channel{Alias: "carol", Info: lndclient.ChannelInfo{ChannelPoint: "b:0"}},
+		lnd.Channel{Alias: "dave", Info: lndclient.ChannelInfo{ChannelPoint: "c:0"}},
+	}, list.NewDefaultDelegate(), 80, 40)
+	l.Select(1)
+
+	// The selected channel moved
+	setItemsKeepSelection(&l, []list.Item{
+		lnd.Channel{Alias: "erin", Info: lndclient.ChannelInfo{ChannelPoint: "d:0"}},
+		lnd.Channel{Alias: "bob", Info: lndclient.ChannelInfo{ChannelPoint: "a:0"}},
+		lnd.Channel{Alias: "dave", Info: lndclient.ChannelInfo{ChannelPoint: "c:0"}},
+		lnd.Channel{Alias: "carol", Info: lndclient.ChannelInfo{ChannelPoint: "b:0"}},
+	})
+	assert.Equal(t, 3, l.Index())
+
+	// The selected channel is gone
+	setItemsKeepSelection(&l, []list.Item{
+		lnd.Channel{Alias: "erin", Info: lndclient.ChannelInfo{ChannelPoint: "d:0"}},
+	})
+	assert.Equal(t, 0, l.Index())
+}
+
+func TestDashboardRefresh(t *testing.T) {
+	backend := &fakeBackend{
+		node:     lnd.Node{Alias: "alice"},
+		channels: []lnd.Channel{{Alias: "bob", Info: lndclient.ChannelInfo{ChannelPoint: "a:0"}}},
+	}
+	nodeData, err := GetData(backend, context.Background())
+	assert.NoError(t, err)
+	nodeData.Permissions = lnd.Permissions{lnd.SendPayment: "missing offchain:write"}
+
+	settings := config.Defaults()
+	m := InitDashboard(backend, nodeData, "", settings)
+	_, cmd := m.Update(tea.WindowSizeMsg{Width: 200, Height: 60})
+	assert.NotNil(t, cmd)
+
+	// Ticks of replaced refresh loops are dropped
+	_, cmd = m.Update(refreshTick{generation: m.refreshGeneration - 1})
+	assert.Nil(t, cmd)
+
+	backend.channels = append([]lnd.Channel{{Alias: "carol", Info: lndclient.ChannelInfo{ChannelPoint: "b:0"}}}, backend.channels...)
+	_, cmd = m.Update(refreshTick{generation: m.refreshGeneration})
+	msg := cmd()
+	assert.IsType(t, dataRefreshed{}, msg)
+
+	m.Update(msg)
+	assert.Len(t, m.lists[channels].Items(), 2)
+	assert.Equal(t, "bob", m.lists[channels].SelectedItem().(lnd.Channel).Alias)
+	assert.Equal(t, nodeData.Permissions, m.nodeData.Permissions)
+	assert.False(t, m.refreshing)
+
+	// A failed refresh keeps the data
+	backend.channelsErr = &lnd.RPCError{Op: "list channels", Err: errors.New("connection refused")}
+	_, cmd = m.Update(refreshTick{generation: m.refreshGeneration})
+	m.Update(cmd())
+	assert.Len(t, m.lists[channels].Items(), 2)
+	assert.Error(t, m.refreshErr)
+	assert.Contains(t, m.getLastUpdated(), "refresh failed")
+}
+
+func TestDashboardPaymentOrder(t *testing.T) {
+	// Backends return the latest payments newest first
+	backend := &fakeBackend{
+		node: lnd.Node{Alias: "alice"},
+		payments: []lnd.Payment{
+			{Payment: lndclient.Payment{Amount: 3_000_000}},
+			{Payment: lndclient.Payment{Amount: 2_000_000}},
+			{Payment: lndclient.Payment{Amount: 1_000_000}},
+		},
+	}
+	nodeData, err := GetData(backend, context.Background())
+	assert.NoError(t, err)
+
+	m := InitDashboard(backend, nodeData, "", config.Defaults())
+	m.Update(tea.WindowSizeMsg{Width: 200, Height: 60})
+
+	items := m.lists[payments].Items()
+	assert.Len(t, items, 3)
+	assert.Equal(t, "3000 sats", items[0].(lnd.Payment).Title())
+	assert.Equal(t, "1000 sats", items[2].(lnd.Payment).Title())
+}
+
+func TestDashboardEvents(t *testing.T) {
+	backend := &fakeBackend{
+		node: lnd.Node{Alias: "alice"},
+		channels: []lnd.Channel{
+			{Alias: "bob", Info: lndclient.ChannelInfo{ChannelPoint: "a:0", Active: true}},
+			{Alias: "carol", Info: lndclient.ChannelInfo{ChannelPoint: "b:0", Active: true}},
+		},
+	}
+	nodeData, err := GetData(backend, context.Background())
+	assert.NoError(t, err)
+
+	m := InitDashboard(backend, nodeData, "", config.Defaults())
+	m.Update(tea.WindowSizeMsg{Width: 200, Height: 60})
+
+	// Channel events are patched in right away
+	m.Update(NodeEvent{Kind: lnd.ChannelInactive, ChannelPoint: "b:0"})
+	assert.False(t, m.lists[channels].Items()[1].(lnd.Channel).Info.Active)
+
+	m.Update(NodeEvent{Kind: lnd.ChannelClosed, ChannelPoint: "a:0"})
+	assert.Len(t, m.lists[channels].Items(), 1)
+	assert.Len(t, backend.channels, 2)
+
+	// Further events join the reload scheduled by the close
+	assert.True(t, m.reloadScheduled)
+	backend.node.OnChainBalance = 1_000
+	_, cmd := m.Update(NodeEvent{Kind: lnd.BalanceChanged})
+	assert.Nil(t, cmd)
+	assert.Equal(t, partChannels|partNodeInfo|partPendingChannels, m.staleParts)
+
+	_, cmd = m.Update(reloadDue{})
+	m.Update(cmd())
+	assert.Len(t, m.lists[channels].Items(), 2)
+	assert.EqualValues(t, 1_000, m.nodeData.NodeInfo.OnChainBalance)
+	assert.False(t, m.reloadScheduled)
+}
+
+func TestDashboardEventsWhileHidden(t *testing.T) {
+	backend := &fakeBackend{
+		node:     lnd.Node{Alias: "alice"},
+		channels: []lnd.Channel{{Alias: "bob", Info: lndclient.ChannelInfo{ChannelPoint: "a:0", Active: true}}},
+	}
+	nodeData, err := GetData(backend, context.Background())
+	assert.NoError(t, err)
+
+	settings := config.Defaults()
+	settings.RefreshInterval = time.Millisecond
+	m := InitDashboard(backend, nodeData, "", settings)
+	m.Update(tea.WindowSizeMsg{Width: 200, Height: 60})
+
+	// Events reach the dashboard while another view is shown
+	var view tea.Model = newLogConsoleModel(&m.base)
+	view.Update(NodeEvent{Kind: lnd.ChannelInactive, ChannelPoint: "a:0"})
+	view.Update(NodeEvent{Kind: lnd.BalanceChanged})
+	assert.False(t, m.lists[channels].Items()[0].(lnd.Channel).Info.Active)
+	assert.Equal(t, partChannels|partNodeInfo, m.staleParts)
+
+	// Going back reloads what they changed
+	backend.node.OnChainBalance = 1_000
+	view, cmd := view.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.Same(t, m, view)
+	assert.Zero(t, m.staleParts)
+
+	var reloaded tea.Msg
+	for _, cmd := range cmd().(tea.BatchMsg) {
+		if cmd == nil {
+			continue
+		}
+		if msg, ok := cmd().(partsReloaded); ok {
+			reloaded = msg
+		}
+	}
+	assert.Equal(t, partChannels|partNodeInfo, reloaded.(partsReloaded).parts)
+	m.Update(reloaded)
+	assert.EqualValues(t, 1_000, m.nodeData.NodeInfo.OnChainBalance)
+}
+
+func TestLoadingProgress(t *testing.T) {
+	var m tea.Model = InitLoading(nil, "", config.Defaults())
+	m, _ = m.Update(LoadingProgress{Stage: "channels", Done: 0, Total: 340})
+	m, _ = m.Update(LoadingProgress{Stage: "pending channels", Done: 1, Total: 2})
+	m, _ = m.Update(LoadingProgress{Stage: "channels", Done: 120, Total: 340})
+	assert.Contains(t, m.View(), "channels 120/340\n   pending channels 1/2")
+
+	m, _ = m.Update(LoadingStage("Checking macaroon permissions"))
+	assert.NotContains(t, m.View(), "channels")
+}
+
+func TestDashboardShowsCachedData(t *testing.T) {
+	cached := &fakeBackend{channels: []lnd.Channel{{Alias: "bob", Info: lndclient.ChannelInfo{ChannelPoint: "a:0"}}}}
+	saved := time.Now().Add(-time.Hour)
+
+	var m tea.Model = InitLoading(nil, "", config.Defaults())
+	m, _ = m.Update(Connected{Backend: cached})
+	m, cmd := m.Update(CachedDataLoaded{NodeData: lnd.NodeData{Channels: cached.channels}, Saved: saved})
+	dashboard := m.(*DashboardModel)
+	assert.Nil(t, cmd, "cached data without a connection isn't refreshed")
+	assert.Contains(t, dashboard.getLastUpdated(), "cached data from "+saved.Format(time.DateTime))
+
+	// The live data reaches the dashboard while a channel is shown
+	channel, _ := dashboard.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.IsType(t, &ChannelModel{}, channel)
+
+	live := &fakeBackend{node: lnd.Node{Alias: "alice"}}
+	channel.Update(Connected{Backend: live})
+	channel.Update(DataLoaded(lnd.NodeData{NodeInfo: live.node}))
+	assert.True(t, dashboard.live)
+	assert.True(t, dashboard.cachedAt.IsZero())
+	assert.Equal(t, live, dashboard.backend)
+	assert.Equal(t, "alice", dashboard.nodeData.NodeInfo.Alias)
+	assert.Empty(t, dashboard.lists[channels].Items())
+	assert.Contains(t, dashboard.getLastUpdated(), "last updated")
+}
+
+func TestLoadingPartialData(t *testing.T) {
+	backend := &fakeBackend{
+		node:        lnd.Node{Alias: "alice"},
+		channels:    []lnd.Channel{{Alias: "bob", Info: lndclient.ChannelInfo{ChannelPoint: "a:0"}}},
+		channelsErr: &lnd.RPCError{Op: "list channels", Err: errors.New("deadline exceeded")},
+	}
+
+	var m tea.Model = InitLoading(nil, "", config.Defaults())
+	m.Update(tea.WindowSizeMsg{Width: 200, Height: 60})
+	m, _ = m.Update(Connected{Backend: backend})
+	var mu sync.Mutex
+	var msgs []tea.Msg
+	nodeData, errs := LoadData(backend, context.Background(), func(msg tea.Msg) {
+		mu.Lock()
+		defer mu.Unlock()
+		msgs = append(msgs, msg)
+	})
+	assert.Len(t, msgs, 5)
+	assert.Equal(t, partChannels, errs.parts())
+	for _, msg := range msgs {
+		m, _ = m.Update(msg)
+	}
+	m, _ = m.Update(PartialDataLoaded{NodeData: nodeData, Errors: errs})
+	assert.Contains(t, m.View(), "✓ Payments")
+	assert.Contains(t, m.View(), "✗ Channels: list channels: deadline exceeded")
+
+	// Retrying the failed part opens the dashboard once it loads
+	backend.channelsErr = nil
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	var reloaded tea.Msg
+	for _, cmd := range cmd().(tea.BatchMsg) {
+		if msg, ok := cmd().(partsReloaded); ok {
+			reloaded = msg
+		}
+	}
+	assert.Equal(t, partChannels, reloaded.(partsReloaded).parts)
+	m, _ = m.Update(reloaded)
+	dashboard := m.(*DashboardModel)
+	assert.Equal(t, "alice", dashboard.nodeData.NodeInfo.Alias)
+	assert.Len(t, dashboard.lists[channels].Items(), 1)
+}
+
+func TestDashboardPlaceholders(t *testing.T) {
+	backend := &fakeBackend{
+		node:        lnd.Node{Alias: "alice"},
+		channels:    []lnd.Channel{{Alias: "bob", Info: lndclient.ChannelInfo{ChannelPoint: "a:0"}}},
+		channelsErr: &lnd.RPCError{Op: "list channels", Err: errors.New("deadline exceeded")},
+	}
+	nodeData, errs := getDataParts(backend, context.Background(), allParts, nil)
+
+	// Skipping the failed part opens the dashboard with a placeholder
+	var m tea.Model = InitLoading(backend, "", config.Defaults())
+	m, _ = m.Update(PartialDataLoaded{NodeData: nodeData, Errors: errs})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	dashboard := m.(*DashboardModel)
+	assert.Contains(t, dashboard.View(), "Channels failed to load")
+	assert.Contains(t, dashboard.View(), "alice")
+
+	// A refresh failing again keeps the placeholder, the next one loads it
+	_, cmd := dashboard.Update(refreshTick{generation: dashboard.refreshGeneration})
+	dashboard.Update(cmd())
+	assert.Contains(t, dashboard.View(), "Channels failed to load")
+
+	backend.channelsErr = nil
+	_, cmd = dashboard.Update(refreshTick{generation: dashboard.refreshGeneration})
+	dashboard.Update(cmd())
+	assert.NotContains(t, dashboard.View(), "Channels failed to load")
+	assert.Len(t, dashboard.lists[channels].Items(), 1)
+	assert.NoError(t, dashboard.refreshErr)
+}
+
+func TestLogConsole(t *testing.T) {
+	ring := logging.NewRing(10)
+	ring.Add(logging.Entry{Level: log.DebugLevel, Message: "Loading node data"})
+	ring.Add(logging.Entry{Level: log.InfoLevel, Message: "Channel policy updated", Fields: "channel=a:0"})
+	ring.Add(logging.Entry{Level: log.ErrorLevel, Message: "Payment failed", Fields: "err=no route"})
+	ShowLogs(ring)
+	defer ShowLogs(nil)
+
+	m := InitDashboard(&fakeBackend{}, lnd.NodeData{}, "", config.Defaults())
+	m.Update(tea.WindowSizeMsg{Width: 200, Height: 60})
+
+	console, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlL})
+	assert.IsType(t, &LogConsoleModel{}, console)
+	assert.Contains(t, console.View(), "Channel policy updated")
+	assert.Contains(t, console.View(), "2 of 3 entries")
+
+	// Raise the level
+	console.Update(tea.KeyMsg{Type: tea.KeyRight})
+	console.Update(tea.KeyMsg{Type: tea.KeyRight})
+	assert.NotContains(t, console.View(), "Channel policy updated")
+	assert.Contains(t, console.View(), "Payment failed")
+
+	// Search all levels
+	console.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	console.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	console.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	console.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	console.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("node")})
+	console.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Contains(t, console.View(), "Loading node data")
+	assert.Contains(t, console.View(), "1 of 3 entries")
+
+	// New entries show up with the next tick
+	ring.Add(logging.Entry{Level: log.WarnLevel, Message: "Peer disconnected"})
+	console.Update(logTick{console: console.(*LogConsoleModel)})
+	assert.Contains(t, console.View(), "1 of 4 entries")
+
+	back, _ := console.Update(tea.KeyMsg{Type: tea.KeyCtrlL})
+	assert.Equal(t, m, back)
+}
@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/ardevd/flash/internal/lnd"
 	"github.com/ardevd/flash/internal/util"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/lightninglabs/lndclient"
 )
 
 // Value containers
@@ -17,16 +17,16 @@ var signedMessage string
 var signature string
 
 type VerifyMessageModel struct {
-	styles     *Styles
-	lndService *lndclient.GrpcLndServices
-	ctx        context.Context
-	base       *BaseModel
-	keys       keyMap
-	form       *huh.Form
+	styles  *Styles
+	backend lnd.NodeBackend
+	ctx     context.Context
+	base    *BaseModel
+	keys    keyMap
+	form    *huh.Form
 }
 
-func newVerifyMessageModel(service *lndclient.GrpcLndServices, base *BaseModel) *VerifyMessageModel {
-	m := VerifyMessageModel{lndService: service, base: base, ctx: context.Background(), keys: Keymap}
+func newVerifyMessageModel(backend lnd.NodeBackend, base *BaseModel) *VerifyMessageModel {
+	m := VerifyMessageModel{backend: backend, base: base, ctx: context.Background(), keys: Keymap}
 	m.styles = GetDefaultStyles()
 	m.base.pushView(&m)
 	m.form = getMessageVerificationForm()
@@ -101,7 +101,7 @@ func (m VerifyMessageModel) View() string {
 
 // Verify message
 func (m VerifyMessageModel) verifyMessage() string {
-	verified, pubkey, err := m.lndService.Client.VerifyMessage(m.ctx, []byte(signedMessage), signature)
+	verified, pubkey, err := m.backend.VerifyMessage(m.ctx, signedMessage, signature)
 	if err != nil {
 		return fmt.Sprintf("Error: %s", err.Error())
 	}